@@ -13,7 +13,6 @@ import (
 
 func init() {
 	// Get path of configuration file from cmdline
-	flag.Parse()
 	flag.StringVar(
 		&apiserver.ConfPath, "cfg-path",
 		"etc/default.toml", "Path to configuration file",
@@ -22,6 +21,8 @@ func init() {
 }
 
 func main() {
+	flag.Parse()
+
 	// We wanna graceful shutdown, right?
 	interruptContext, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -29,8 +30,16 @@ func main() {
 	cfg := apiserver.NewConfiguration()
 	logger := logruslogger.GetLogger(&cfg.LoggerConfiguration)
 
+	// gotcha-app [-cfg-path path] migrate up|down [steps]|status|version
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(interruptContext, cfg, logger, flag.Args()[1:]); err != nil {
+			logger.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
 	logger.Printf("Starting the %s", cfg.AppName)
 	if err := apiserver.Start(interruptContext, cfg, logger); err != nil {
-		logger.Panicf("failed to start the Gotcha apiserver: %v", err)
+		logger.Fatalf("Gotcha apiserver failed: %v", err)
 	}
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"Gotcha/internal/app/apiserver"
+	"Gotcha/internal/app/logging"
+	"Gotcha/internal/app/migrator"
+	"Gotcha/migrations"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up|down [steps]|status|version")
+
+// runMigrate executes migrate subcommand against the configured database
+func runMigrate(ctx context.Context, cfg *apiserver.GotchaConfiguration, logger logging.GotchaLogger, args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	db, err := apiserver.OpenDB(ctx, &cfg.DatabaseConfiguration, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	schemaMigrator, err := migrator.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := schemaMigrator.Up(ctx)
+		for _, migration := range applied {
+			logger.Printf("Applied %d_%s", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			logger.Println("Schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return errMigrateUsage
+			}
+		}
+		reverted, err := schemaMigrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			logger.Printf("Reverted %d_%s", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := schemaMigrator.Status(ctx)
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = fmt.Sprintf("applied at %s", status.AppliedAt.Format("2006-01-02 15:04:05"))
+			}
+			logger.Printf("%d_%s: %s", status.Version, status.Name, state)
+		}
+		return err
+	case "version":
+		version, err := schemaMigrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Printf("Schema version: %d", version)
+		return nil
+	}
+	return errMigrateUsage
+}
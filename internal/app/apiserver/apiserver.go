@@ -1,57 +1,175 @@
 package apiserver
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"Gotcha/internal/app/logging"
+	"Gotcha/internal/app/metrics"
 	"Gotcha/internal/app/storage"
+	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
 )
 
+// ApiVersion is the path segment of the API version
+type ApiVersion string
+
+const (
+	ApiV1 ApiVersion = "v1"
+
+	// ApiLegacyVersion is served on the unversioned ApiBasePath too. These routes are deprecated
+	ApiLegacyVersion = ApiV1
+
+	apiBoardsRoute = "/boards"
+)
+
+// apiVersions are ordered from the oldest one, the last version is the current one
+var apiVersions = []ApiVersion{ApiV1}
+
+// Root is the path prefix of the version routes
+func (v ApiVersion) Root() string {
+	return ApiBasePath + "/" + string(v)
+}
+
+// currentApiVersion is the latest version, ApiRootPath belongs to it
+func currentApiVersion() ApiVersion {
+	return apiVersions[len(apiVersions)-1]
+}
+
+// index is the position of the version in apiVersions, -1 for unknown versions
+func (v ApiVersion) index() int {
+	for i, version := range apiVersions {
+		if version == v {
+			return i
+		}
+	}
+	return -1
+}
+
 var (
-	ApiRootPath   = "/api"
-	ApiBoardsPath = ApiRootPath + "/boards"
+	ApiBasePath   = "/api"
+	ApiRootPath   = currentApiVersion().Root()
+	ApiBoardsPath = ApiRootPath + apiBoardsRoute
+
+	// Probes aren't versioned
+	ApiHealthLive  = newApiHandle(ApiBasePath+"/health/live", false, "GET")
+	ApiHealthReady = newApiHandle(ApiBasePath+"/health/ready", false, "GET")
 
 	ApiHeartbeat = newApiHandle("/heartbeat", true, "GET")
+	ApiOpenAPI   = newApiHandle("/openapi.json", true, "GET")
 	ApiSignup    = newApiHandle("/authority/signup", true, "POST")
 	ApiAuthorize = newApiHandle("/authority/signin", true, "POST")
 	ApiListUsers = newApiHandle("/authority/all", true, "GET")
 
+	ApiSignout           = newApiHandle("/authority/signout", true, "POST")
+	ApiSignoutEverywhere = newApiHandle("/authority/signout/all", true, "POST")
+
+	ApiNewToken    = newApiHandle("/authority/tokens", true, "POST")
+	ApiGetTokens   = newApiHandle("/authority/tokens", true, "GET")
+	ApiRevokeToken = newApiHandle("/authority/tokens/{token_id}", true, "DELETE")
+
+	ApiNewTeam          = newApiHandle("/teams", true, "POST")
+	ApiGetTeams         = newApiHandle("/teams", true, "GET")
+	ApiGetTeamMembers   = newApiHandle("/teams/{team_id}/members", true, "GET")
+	ApiAddTeamMember    = newApiHandle("/teams/{team_id}/members", true, "POST")
+	ApiRemoveTeamMember = newApiHandle("/teams/{team_id}/members/{user_id}", true, "DELETE")
+
+	ApiNewWorkspace          = newApiHandle("/workspaces", true, "POST")
+	ApiGetWorkspaces         = newApiHandle("/workspaces", true, "GET")
+	ApiGetWorkspaceMembers   = newApiHandle("/workspaces/{workspace_id}/members", true, "GET")
+	ApiAddWorkspaceMember    = newApiHandle("/workspaces/{workspace_id}/members", true, "POST")
+	ApiUpdateWorkspaceMember = newApiHandle("/workspaces/{workspace_id}/members/{user_id}", true, "PUT")
+	ApiRemoveWorkspaceMember = newApiHandle("/workspaces/{workspace_id}/members/{user_id}", true, "DELETE")
+
+	ApiSearch = newApiHandle("/search", true, "GET")
+
+	ApiGetSharedTree  = newApiHandle("/shared/{token}", true, "GET")
+	ApiGetSharedNotes = newApiHandle("/shared/{token}/boards/{board_id}/notes", true, "GET")
+
 	ApiGetBoards       = newApiHandle("/all", false, "GET")
 	ApiNewRootBoard    = newApiHandle("/root", false, "POST")
 	ApiDeleteRootBoard = newApiHandle("/root", false, "DELETE")
 	ApiPermitBoard     = newApiHandle("/permit", false, "POST")
-)
 
-type serverState int
+	ApiNewNestedBoard    = newApiHandle("/{board_id}/nested", false, "POST")
+	ApiGetNestedBoards   = newApiHandle("/{board_id}/nested", false, "GET")
+	ApiDeleteNestedBoard = newApiHandle("/{board_id}/nested/{nested_id}", false, "DELETE")
+	ApiGetBoardTree      = newApiHandle("/{board_id}/tree", false, "GET")
 
-const (
-	stateRunning serverState = iota
-	stateMangled
+	ApiGetCollaborators   = newApiHandle("/{board_id}/collaborators", false, "GET")
+	ApiUpdateCollaborator = newApiHandle("/{board_id}/collaborators/{user_id}", false, "PUT")
+	ApiRevokeCollaborator = newApiHandle("/{board_id}/collaborators/{user_id}", false, "DELETE")
+	ApiTransferBoard      = newApiHandle("/{board_id}/transfer", false, "POST")
+
+	ApiNewShareLink    = newApiHandle("/{board_id}/shares", false, "POST")
+	ApiGetShareLinks   = newApiHandle("/{board_id}/shares", false, "GET")
+	ApiRevokeShareLink = newApiHandle("/{board_id}/shares/{share_id}", false, "DELETE")
+
+	ApiGrantTeam  = newApiHandle("/{board_id}/teams", false, "POST")
+	ApiRevokeTeam = newApiHandle("/{board_id}/teams/{team_id}", false, "DELETE")
+
+	ApiNewNote    = newApiHandle("/{board_id}/notes", false, "POST")
+	ApiGetNotes   = newApiHandle("/{board_id}/notes", false, "GET")
+	ApiGetNote    = newApiHandle("/{board_id}/notes/{note_id:[0-9]+}", false, "GET")
+	ApiUpdateNote = newApiHandle("/{board_id}/notes/{note_id:[0-9]+}", false, "PUT")
+	ApiDeleteNote = newApiHandle("/{board_id}/notes/{note_id:[0-9]+}", false, "DELETE")
 )
 
+// ApiHandle is the route of the API. Path is the full path of the latest version, that serves it
+// (relative to ApiBoardsPath for board routes). Handles are available in every version by default
 type ApiHandle struct {
 	Path    string
 	Methods []string
+
+	route        string // relative to the version root or boards subrouter
+	addBase      bool
+	since, until ApiVersion
 }
 
 func newApiHandle(path string, addBase bool, methods ...string) ApiHandle {
+	handle := ApiHandle{Path: path, Methods: methods, route: path, addBase: addBase}
 	if addBase {
-		path = ApiRootPath + path
+		handle.Path = ApiRootPath + path
 	}
-	return ApiHandle{Path: path, Methods: methods}
+	return handle
+}
+
+// Since makes the handle available from the version on
+func (h ApiHandle) Since(version ApiVersion) ApiHandle {
+	h.since = version
+	return h
+}
+
+// Until makes the handle available up to the version inclusive, so the newer handle of the same
+// route may replace it
+func (h ApiHandle) Until(version ApiVersion) ApiHandle {
+	h.until = version
+	if h.addBase {
+		h.Path = version.Root() + h.route
+	}
+	return h
+}
+
+func (h ApiHandle) availableIn(version ApiVersion) bool {
+	return (h.since == "" || h.since.index() <= version.index()) &&
+		(h.until == "" || version.index() <= h.until.index())
 }
 
 // GotchaAPIServer is a container of server-needed interfaces like logging, cookie store and Router
 type GotchaAPIServer struct {
-	state       serverState
 	Router      *mux.Router
 	logger      logging.GotchaLogger
 	cfg         *GotchaConfiguration
 	storage     storage.Storage
 	cookieStore sessions.Store
+	metrics     *metrics.Metrics // nil unless metrics are enabled
+
+	healthChecks map[string]HealthCheck
+	errorRate    *errorRate
 	// storage store.storage
 }
 
@@ -62,8 +180,13 @@ func NewAPIServer(logger logging.GotchaLogger, cfg *GotchaConfiguration, storage
 		Router:      mux.NewRouter(),
 		cfg:         cfg,
 		storage:     storage,
-		state:       stateRunning,
 		cookieStore: cookieStore,
+
+		healthChecks: make(map[string]HealthCheck),
+		errorRate:    newErrorRate(),
+	}
+	if cfg.MetricsConfiguration.Enabled {
+		server.metrics = metrics.New()
 	}
 
 	// Register handlers & middlewares
@@ -73,30 +196,116 @@ func NewAPIServer(logger logging.GotchaLogger, cfg *GotchaConfiguration, storage
 }
 
 func (srv *GotchaAPIServer) registerHandlers() {
-	// Authorization not required
 	srv.Router.Use(srv.setRequestID)
 	srv.Router.Use(srv.loggingMiddleware)
-	srv.Router.HandleFunc(ApiHeartbeat.Path, srv.heartbeatAPIHandler()).Methods(ApiHeartbeat.Methods...)
-	srv.Router.HandleFunc(ApiSignup.Path, srv.signupHandler()).Methods(ApiSignup.Methods...)
-	srv.Router.HandleFunc(ApiAuthorize.Path, srv.signinHandler()).Methods(ApiAuthorize.Methods...)
+	srv.Router.Use(srv.tracingMiddleware)
+
+	srv.handle(srv.Router, currentApiVersion(), ApiHealthLive, srv.liveHandler())
+	srv.handle(srv.Router, currentApiVersion(), ApiHealthReady, srv.readyHandler())
+
+	for _, version := range apiVersions {
+		srv.registerVersion(srv.Router.PathPrefix(version.Root()).Subrouter(), version)
+	}
+
+	// Unversioned routes are kept for old clients of the first version
+	legacyRouter := srv.Router.PathPrefix(ApiBasePath).Subrouter()
+	legacyRouter.Use(srv.deprecationMiddleware())
+	srv.registerVersion(legacyRouter, ApiLegacyVersion)
+}
+
+// registerVersion mounts handles available in the version on its router. Handles of the same route
+// restricted to different versions are registered side by side
+func (srv *GotchaAPIServer) registerVersion(router *mux.Router, version ApiVersion) {
+	// Authorization not required
+	srv.handle(router, version, ApiHeartbeat, srv.heartbeatAPIHandler())
+	srv.handle(router, version, ApiOpenAPI, srv.openAPIHandler())
+	srv.handle(router, version, ApiSignup, srv.signupHandler())
+	srv.handle(router, version, ApiAuthorize, srv.signinHandler())
+	srv.handle(router, version, ApiGetSharedTree, srv.getSharedTreeHandler())
+	srv.handle(router, version, ApiGetSharedNotes, srv.getSharedNotesHandler())
 
 	listUsersHandler := srv.authorizationMiddleware(http.Handler(srv.listUsersHandler()))
-	srv.Router.Handle(ApiListUsers.Path, listUsersHandler).Methods(ApiListUsers.Methods...)
+	srv.handle(router, version, ApiListUsers, listUsersHandler)
+	srv.handle(router, version, ApiSignout, srv.authorizationMiddleware(srv.signoutHandler()))
+	srv.handle(router, version, ApiSignoutEverywhere, srv.authorizationMiddleware(srv.signoutEverywhereHandler()))
+	srv.handle(router, version, ApiNewToken, srv.authorizationMiddleware(srv.newTokenHandler()))
+	srv.handle(router, version, ApiGetTokens, srv.authorizationMiddleware(srv.getTokensHandler()))
+	srv.handle(router, version, ApiRevokeToken, srv.authorizationMiddleware(srv.revokeTokenHandler()))
+	srv.handle(router, version, ApiNewTeam, srv.authorizationMiddleware(srv.newTeamHandler()))
+	srv.handle(router, version, ApiGetTeams, srv.authorizationMiddleware(srv.getTeamsHandler()))
+	srv.handle(router, version, ApiGetTeamMembers, srv.authorizationMiddleware(srv.getTeamMembersHandler()))
+	srv.handle(router, version, ApiAddTeamMember, srv.authorizationMiddleware(srv.addTeamMemberHandler()))
+	srv.handle(router, version, ApiRemoveTeamMember, srv.authorizationMiddleware(srv.removeTeamMemberHandler()))
+	srv.handle(router, version, ApiNewWorkspace, srv.authorizationMiddleware(srv.newWorkspaceHandler()))
+	srv.handle(router, version, ApiGetWorkspaces, srv.authorizationMiddleware(srv.getWorkspacesHandler()))
+	srv.handle(router, version, ApiGetWorkspaceMembers, srv.authorizationMiddleware(srv.getWorkspaceMembersHandler()))
+	srv.handle(router, version, ApiAddWorkspaceMember, srv.authorizationMiddleware(srv.addWorkspaceMemberHandler()))
+	srv.handle(router, version, ApiUpdateWorkspaceMember, srv.authorizationMiddleware(srv.updateWorkspaceMemberHandler()))
+	srv.handle(router, version, ApiRemoveWorkspaceMember, srv.authorizationMiddleware(srv.removeWorkspaceMemberHandler()))
+	srv.handle(router, version, ApiSearch, srv.authorizationMiddleware(srv.searchHandler()))
 
 	// Authorization middleware enabled`
-	noteSubRouter := srv.Router.PathPrefix(ApiBoardsPath).Subrouter()
+	noteSubRouter := router.PathPrefix(apiBoardsRoute).Subrouter()
 	noteSubRouter.Use(srv.authorizationMiddleware)
-	noteSubRouter.HandleFunc(ApiGetBoards.Path, srv.getBoardsHandler()).Methods(ApiGetBoards.Methods...)
-	noteSubRouter.HandleFunc(ApiNewRootBoard.Path, srv.newRootBoardHandler()).Methods(ApiNewRootBoard.Methods...)
-	noteSubRouter.HandleFunc(ApiDeleteRootBoard.Path, srv.deleteRootBoardHandler()).Methods(ApiDeleteRootBoard.Methods...)
-	noteSubRouter.HandleFunc(ApiPermitBoard.Path, srv.permitBoard()).Methods(ApiPermitBoard.Methods...)
+	srv.handle(noteSubRouter, version, ApiGetBoards, srv.getBoardsHandler())
+	srv.handle(noteSubRouter, version, ApiNewRootBoard, srv.newRootBoardHandler())
+	srv.handle(noteSubRouter, version, ApiDeleteRootBoard, srv.deleteRootBoardHandler())
+	srv.handle(noteSubRouter, version, ApiPermitBoard, srv.permitBoard())
+	srv.handle(noteSubRouter, version, ApiNewNestedBoard, srv.newNestedBoardHandler())
+	srv.handle(noteSubRouter, version, ApiGetNestedBoards, srv.getNestedBoardsHandler())
+	srv.handle(noteSubRouter, version, ApiDeleteNestedBoard, srv.deleteNestedBoardHandler())
+	srv.handle(noteSubRouter, version, ApiGetBoardTree, srv.getBoardTreeHandler())
+	srv.handle(noteSubRouter, version, ApiGetCollaborators, srv.getCollaboratorsHandler())
+	srv.handle(noteSubRouter, version, ApiUpdateCollaborator, srv.updateCollaboratorHandler())
+	srv.handle(noteSubRouter, version, ApiRevokeCollaborator, srv.revokeCollaboratorHandler())
+	srv.handle(noteSubRouter, version, ApiTransferBoard, srv.transferBoardHandler())
+	srv.handle(noteSubRouter, version, ApiNewShareLink, srv.newShareLinkHandler())
+	srv.handle(noteSubRouter, version, ApiGetShareLinks, srv.getShareLinksHandler())
+	srv.handle(noteSubRouter, version, ApiRevokeShareLink, srv.revokeShareLinkHandler())
+	srv.handle(noteSubRouter, version, ApiGrantTeam, srv.grantTeamHandler())
+	srv.handle(noteSubRouter, version, ApiRevokeTeam, srv.revokeTeamHandler())
+	srv.handle(noteSubRouter, version, ApiNewNote, srv.newNoteHandler())
+	srv.handle(noteSubRouter, version, ApiGetNotes, srv.getNotesHandler())
+	srv.handle(noteSubRouter, version, ApiGetNote, srv.getNoteHandler())
+	srv.handle(noteSubRouter, version, ApiUpdateNote, srv.updateNoteHandler())
+	srv.handle(noteSubRouter, version, ApiDeleteNote, srv.deleteNoteHandler())
 }
 
+// handle registers the handler if the handle is available in the version
+func (srv *GotchaAPIServer) handle(router *mux.Router, version ApiVersion, apiHandle ApiHandle, handler http.Handler) {
+	if apiHandle.availableIn(version) {
+		router.Handle(apiHandle.route, handler).Methods(apiHandle.Methods...)
+	}
+}
+
+// error responds with APIError. Unexpected errors are logged, because the client gets only the generic message
 func (srv *GotchaAPIServer) error(w http.ResponseWriter, request *http.Request, code int, err error) {
-	if code == http.StatusInternalServerError && srv.state == stateRunning {
-		srv.state = stateMangled
+	apiError := newAPIError(code, err)
+	apiError.RequestID, _ = request.Context().Value(ctxRequestIDKey).(string)
+	if apiError.Code == CodeInternal {
+		srv.logger.WithFields(logrus.Fields{"Request-ID": apiError.RequestID}).Errorf("Internal error: %v", err)
+	}
+	srv.respond(w, request, code, errorResponse{Error: apiError})
+}
+
+// storageError responds with the status code, that matches the error returned by storage
+func (srv *GotchaAPIServer) storageError(w http.ResponseWriter, request *http.Request, err error) {
+	var validationErrors validation.Errors
+
+	switch {
+	case errors.Is(err, storage.ErrSecurityError):
+		srv.error(w, request, http.StatusForbidden, errNotPermitted)
+	case errors.Is(err, storage.ErrNotFound):
+		srv.error(w, request, http.StatusNotFound, err)
+	case errors.Is(err, storage.ErrEntityDuplicate):
+		srv.error(w, request, http.StatusConflict, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		srv.error(w, request, http.StatusGatewayTimeout, err)
+	case errors.As(err, &validationErrors):
+		srv.error(w, request, http.StatusUnprocessableEntity, err)
+	default:
+		srv.error(w, request, http.StatusInternalServerError, err)
 	}
-	srv.respond(w, request, code, map[string]string{"error": err.Error()})
 }
 
 func (srv *GotchaAPIServer) respond(w http.ResponseWriter, request *http.Request, code int, data any) {
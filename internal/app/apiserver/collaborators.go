@@ -0,0 +1,163 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"Gotcha/internal/app/model"
+	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var errIncorrectCollaborator = errors.New("incorrect collaborator")
+
+// collaboratorIDFromPath extracts {user_id} variable of the route
+func collaboratorIDFromPath(request *http.Request) (uuid.UUID, error) {
+	userID, err := uuid.Parse(mux.Vars(request)["user_id"])
+	if err != nil {
+		return uuid.Nil, errIncorrectCollaborator
+	}
+	return userID, nil
+}
+
+func (srv *GotchaAPIServer) getCollaboratorsHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		collaborators, err := srv.storage.Board().GetCollaborators(request.Context(), boardID, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, collaborators)
+	}
+}
+
+type updateCollaboratorRequest struct {
+	Permission string `json:"permission" valid:"required"`
+}
+
+func (srv *GotchaAPIServer) updateCollaboratorHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := updateCollaboratorRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		collaboratorID, err := collaboratorIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		privilege, err := model.ParseGrantablePrivilege(req.Permission)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := srv.storage.Board().UpdateCollaborator(request.Context(), boardID, collaboratorID, privilege, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+func (srv *GotchaAPIServer) revokeCollaboratorHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		collaboratorID, err := collaboratorIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := srv.storage.Board().RevokeCollaborator(request.Context(), boardID, collaboratorID, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+type transferRequest struct {
+	UserID     uuid.UUID `json:"user_id"     valid:"required_uuid"`
+	KeepAccess bool      `json:"keep_access" valid:"optional"`
+}
+
+// transferBoardHandler hands authorship of the board to the collaborator. Previous author keeps rw
+// access if keep_access is set.
+func (srv *GotchaAPIServer) transferBoardHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := transferRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		transfer, err := srv.storage.Board().TransferBoard(request.Context(), boardID, req.UserID, req.KeepAccess, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, transfer)
+	}
+}
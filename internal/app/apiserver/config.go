@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"Gotcha/internal/app/logging"
+	"Gotcha/internal/app/tracing"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
@@ -21,10 +22,17 @@ type DatabaseConfiguration struct {
 	DBHost           string `toml:"host" env:"DB_HOST" env-default:"127.0.0.1"`
 	DBPort           int    `toml:"port" env:"DB_PORT" env-default:"5432"`
 	SSLMode          string `toml:"ssl_mode" env:"SSL_MODE" env-default:"disable"`
-	Attempts         int    `toml:"attempts" env:"DB_ATTEMPTS" env-default:"5"`
+	Attempts         int    `toml:"attempts" env:"DB_ATTEMPTS" env-default:"5"` // connection attempts on start
 	DBUsername       string `toml:"username" env:"DB_USERNAME" env-default:"postgres"`
 	DBPassword       string `toml:"password" env:"DB_PASSWORD"`
 	SelectedDatabase string `toml:"database" env:"DATABASE" env-default:"postgres"`
+	QueryTimeout     int    `toml:"query_timeout" env:"DB_QUERY_TIMEOUT" env-default:"5"` // seconds, 0 - no limit
+
+	// Connection pool options. Zero means no limit, except of idle connections, which aren't kept then
+	MaxOpenConnections int `toml:"max_open_connections" env:"DB_MAX_OPEN_CONNECTIONS" env-default:"25"`
+	MaxIdleConnections int `toml:"max_idle_connections" env:"DB_MAX_IDLE_CONNECTIONS" env-default:"5"`
+	ConnectionLifetime int `toml:"connection_lifetime" env:"DB_CONNECTION_LIFETIME" env-default:"1800"`  // seconds
+	ConnectionIdleTime int `toml:"connection_idle_time" env:"DB_CONNECTION_IDLE_TIME" env-default:"300"` // seconds
 }
 
 func (dbc *DatabaseConfiguration) GetConnectionString() string {
@@ -42,6 +50,26 @@ type RedisConfiguration struct {
 	IdleConnections int    `toml:"idle_connections" env:"IDLE_CONNECTIONS"`
 }
 
+// MetricsConfiguration controls the prometheus endpoint. It's served on its own address,
+// so it isn't exposed together with the API.
+type MetricsConfiguration struct {
+	Enabled     bool   `toml:"enabled" env:"METRICS_ENABLED" env-default:"false"`
+	BindAddress string `toml:"bind_address" env:"METRICS_BIND_ADDRESS" env-default:"127.0.0.1:9090"`
+}
+
+// TLSConfiguration enables HTTPS (and HTTP/2) on the API address. Certificate files are
+// reloaded, when they change. Session cookies are secure, while TLS is enabled
+type TLSConfiguration struct {
+	Enabled      bool   `toml:"enabled" env:"TLS_ENABLED" env-default:"false"`
+	CertFile     string `toml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile      string `toml:"key_file" env:"TLS_KEY_FILE"`
+	MinVersion   string `toml:"min_version" env:"TLS_MIN_VERSION" env-default:"1.2"`         // 1.2, 1.3
+	CipherPolicy string `toml:"cipher_policy" env:"TLS_CIPHER_POLICY" env-default:"default"` // default, strict
+
+	// RedirectAddress is the plain HTTP listener, that redirects to HTTPS. Empty address disables it
+	RedirectAddress string `toml:"redirect_address" env:"TLS_REDIRECT_ADDRESS"`
+}
+
 // GotchaConfiguration is a simple container of presets that server really needs.
 type GotchaConfiguration struct {
 	AppName      string `toml:"app_name" env:"APP_NAME" env-default:"Gotcha app"`
@@ -49,11 +77,21 @@ type GotchaConfiguration struct {
 	BindPort     int    `toml:"bind_port" env:"BIND_PORT" env-default:"8080"`
 	SessionKey   string `toml:"session_key" env:"SESSION_KEY" env-required:"true"`
 	CookiesStore string `toml:"store" env:"STORE" env-default:"default"`
+	AutoMigrate  bool   `toml:"auto_migrate" env:"AUTO_MIGRATE" env-default:"false"`
+
+	// ShutdownTimeout is the time (seconds) to drain in-flight requests and close all resources
+	ShutdownTimeout int `toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"15"`
+
+	// LegacyApiSunset is the date (YYYY-MM-DD), when the unversioned /api routes are removed
+	LegacyApiSunset string `toml:"legacy_api_sunset" env:"LEGACY_API_SUNSET"`
 
 	// Just some nested settings
-	LoggerConfiguration   logging.LoggerConfiguration `toml:"logger_configuration"`
-	DatabaseConfiguration DatabaseConfiguration       `toml:"database_configuration"`
-	RedisConfiguration    RedisConfiguration          `toml:"redis_configuration"`
+	LoggerConfiguration   logging.LoggerConfiguration  `toml:"logger_configuration"`
+	DatabaseConfiguration DatabaseConfiguration        `toml:"database_configuration"`
+	RedisConfiguration    RedisConfiguration           `toml:"redis_configuration"`
+	MetricsConfiguration  MetricsConfiguration         `toml:"metrics_configuration"`
+	TLSConfiguration      TLSConfiguration             `toml:"tls_configuration"`
+	TracingConfiguration  tracing.TracingConfiguration `toml:"tracing_configuration"`
 }
 
 // NewConfiguration loads the configuration from toml file (or env variables). Panics on error.
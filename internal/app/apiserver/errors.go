@@ -0,0 +1,138 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/asaskevich/govalidator"
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// ErrorCode is a stable, machine-readable identifier of the API error. Clients should switch
+// on codes instead of messages, which may change
+type ErrorCode string
+
+const (
+	CodeBadRequest         ErrorCode = "bad_request"
+	CodeMalformedBody      ErrorCode = "malformed_body"
+	CodeValidationFailed   ErrorCode = "validation_failed"
+	CodeUnauthorized       ErrorCode = "unauthorized"
+	CodeInvalidCredentials ErrorCode = "invalid_credentials"
+	CodeForbidden          ErrorCode = "forbidden"
+	CodeNotFound           ErrorCode = "not_found"
+	CodeConflict           ErrorCode = "conflict"
+	CodeUserExists         ErrorCode = "user_exists"
+	CodeGone               ErrorCode = "gone"
+	CodeTimeout            ErrorCode = "timeout"
+	CodeInternal           ErrorCode = "internal_error"
+)
+
+// messageInternal replaces messages of unexpected errors, which may contain details of storage
+const messageInternal = "internal server error"
+
+// APIError is the body of every failed response, wrapped as {"error": APIError}
+type APIError struct {
+	Code      ErrorCode         `json:"code"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details,omitempty"` // field: reason
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+type errorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+// statusCodes are default codes of the HTTP statuses
+var statusCodes = map[int]ErrorCode{
+	http.StatusBadRequest:          CodeBadRequest,
+	http.StatusUnauthorized:        CodeUnauthorized,
+	http.StatusForbidden:           CodeForbidden,
+	http.StatusNotFound:            CodeNotFound,
+	http.StatusConflict:            CodeConflict,
+	http.StatusGone:                CodeGone,
+	http.StatusUnprocessableEntity: CodeValidationFailed,
+	http.StatusGatewayTimeout:      CodeTimeout,
+	http.StatusInternalServerError: CodeInternal,
+}
+
+// sentinelCodes refine codes of the well-known errors
+var sentinelCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{errMixedIncorrect, CodeInvalidCredentials},
+	{errUserExists, CodeUserExists},
+}
+
+// newAPIError describes err for the client. Messages of internal errors are hidden,
+// validation errors are split into field-level details
+func newAPIError(status int, err error) *APIError {
+	apiError := APIError{Code: statusCodes[status], Message: err.Error()}
+	if apiError.Code == "" {
+		apiError.Code = CodeBadRequest
+		if status >= http.StatusInternalServerError {
+			apiError.Code = CodeInternal
+		}
+	}
+	if status >= http.StatusInternalServerError && status != http.StatusGatewayTimeout {
+		apiError.Message = messageInternal
+		return &apiError
+	}
+
+	var (
+		syntaxError      *json.SyntaxError
+		typeError        *json.UnmarshalTypeError
+		validationErrors validation.Errors
+		govalidatorErrs  govalidator.Errors
+	)
+	switch {
+	case errors.As(err, &syntaxError), errors.As(err, &typeError), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		apiError.Code = CodeMalformedBody
+		apiError.Message = "request body is malformed"
+	case errors.As(err, &validationErrors):
+		apiError.Code = CodeValidationFailed
+		apiError.Message = "validation failed"
+		apiError.Details = make(map[string]string, len(validationErrors))
+		for field, fieldErr := range validationErrors {
+			if fieldErr != nil {
+				apiError.Details[field] = fieldErr.Error()
+			}
+		}
+	case errors.As(err, &govalidatorErrs):
+		apiError.Code = CodeValidationFailed
+		apiError.Message = "validation failed"
+		apiError.Details = govalidatorDetails(govalidatorErrs)
+	default:
+		for _, sentinel := range sentinelCodes {
+			if errors.Is(err, sentinel.err) {
+				apiError.Code = sentinel.code
+				break
+			}
+		}
+	}
+	return &apiError
+}
+
+// govalidatorDetails flattens errors of govalidator.ValidateStruct, which may be nested
+func govalidatorDetails(errs govalidator.Errors) map[string]string {
+	details := make(map[string]string, len(errs))
+	for _, err := range errs {
+		var (
+			fieldErr  govalidator.Error
+			nestedErr govalidator.Errors
+		)
+		switch {
+		case errors.As(err, &nestedErr):
+			for field, reason := range govalidatorDetails(nestedErr) {
+				details[field] = reason
+			}
+		case errors.As(err, &fieldErr):
+			details[fieldErr.Name] = fieldErr.Err.Error()
+		default:
+			details[err.Error()] = err.Error()
+		}
+	}
+	return details
+}
@@ -5,19 +5,30 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"Gotcha/internal/app/authorization"
 	"Gotcha/internal/app/model"
 	"Gotcha/internal/app/storage"
 	"github.com/asaskevich/govalidator"
+	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 )
 
 const (
-	StatusServerOK      = "working"
-	StatusServerMangled = "mangled"
+	StatusServerOK       = "working"
+	StatusServerDegraded = "degraded"
 
 	sessionName = "gotcha_auth"
+
+	// Keys of session values
+	sessionUserIDKey   = "user_id"
+	sessionIssuedAtKey = "issued_at"
+
+	defaultTreeDepth = 8
+	maxTreeDepth     = 32
 )
 
 var (
@@ -26,8 +37,18 @@ var (
 	errUnauthorized   = errors.New("unauthorized")
 	errNotPermitted   = errors.New("not permitted")
 	errMixedIncorrect = errors.New("incorrect username or password") // hides out that user not exists
+	errIncorrectDepth = fmt.Errorf("max_depth must be in range [0, %d]", maxTreeDepth)
+	errIncorrectOrder = errors.New("order must be asc or desc")
 )
 
+func init() {
+	// govalidator checks arrays element by element, so "required" rejects uuids containing zero bytes
+	govalidator.CustomTypeTagMap.Set("required_uuid", func(i any, _ any) bool {
+		id, converted := i.(uuid.UUID)
+		return converted && id != uuid.Nil
+	})
+}
+
 type ServerStatus struct {
 	AppName string        `json:"app_name"`
 	Status  string        `json:"status"`
@@ -38,12 +59,9 @@ func (srv *GotchaAPIServer) heartbeatAPIHandler() http.HandlerFunc {
 	handlerRegisteredTime := time.Now()
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		state := "responds"
-		switch srv.state {
-		case stateRunning:
-			state = StatusServerOK
-		case stateMangled:
-			state = StatusServerMangled
+		state := StatusServerOK
+		if srv.status() == HealthDegraded {
+			state = StatusServerDegraded
 		}
 
 		serverStatus := ServerStatus{
@@ -58,13 +76,13 @@ func (srv *GotchaAPIServer) heartbeatAPIHandler() http.HandlerFunc {
 
 // Authorization
 
-func (srv *GotchaAPIServer) signupHandler() http.HandlerFunc {
-	type RegisterRequest struct {
-		Email    string `json:"email"`
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
 
+func (srv *GotchaAPIServer) signupHandler() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		rReq := RegisterRequest{}
 		if err := json.NewDecoder(request.Body).Decode(&rReq); err != nil {
@@ -78,12 +96,14 @@ func (srv *GotchaAPIServer) signupHandler() http.HandlerFunc {
 			Password: rReq.Password,
 		}
 
-		if err := srv.storage.User().SaveUser(&tmpUser); err != nil {
+		if err := srv.storage.User().SaveUser(request.Context(), &tmpUser); err != nil {
+			var validationErrors validation.Errors
 
-			// Hide real error, because it contains sensitive information
+			// Hide real error, because it contains sensitive information. Validation errors are
+			// safe and describe the rejected fields
 			if errors.Is(err, storage.ErrEntityDuplicate) {
 				err = errUserExists
-			} else {
+			} else if !errors.As(err, &validationErrors) {
 				err = errInvalidUser
 			}
 			srv.error(writer, request, http.StatusUnprocessableEntity, err)
@@ -95,12 +115,12 @@ func (srv *GotchaAPIServer) signupHandler() http.HandlerFunc {
 	}
 }
 
-func (srv *GotchaAPIServer) signinHandler() http.HandlerFunc {
-	type loginRequest struct {
-		Sobriquet string `json:"sobriquet"`
-		Password  string `json:"password"`
-	}
+type loginRequest struct {
+	Sobriquet string `json:"sobriquet"`
+	Password  string `json:"password"`
+}
 
+func (srv *GotchaAPIServer) signinHandler() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		lReq := loginRequest{}
 		if err := json.NewDecoder(request.Body).Decode(&lReq); err != nil {
@@ -108,7 +128,7 @@ func (srv *GotchaAPIServer) signinHandler() http.HandlerFunc {
 			return
 		}
 
-		user, err := srv.storage.User().FindUserBySobriquet(lReq.Sobriquet)
+		user, err := srv.storage.User().FindUserBySobriquet(request.Context(), lReq.Sobriquet)
 		if err != nil || !user.IsCorrectPassword(lReq.Password) {
 			srv.error(writer, request, http.StatusUnauthorized, errMixedIncorrect)
 			return
@@ -119,14 +139,64 @@ func (srv *GotchaAPIServer) signinHandler() http.HandlerFunc {
 			srv.error(writer, request, http.StatusInternalServerError, err)
 			return
 		}
-		session.Values["user_id"] = user.ID.String()
+		session.Values[sessionUserIDKey] = user.ID.String()
+		session.Values[sessionIssuedAtKey] = time.Now().UnixNano()
 		if err := srv.cookieStore.Save(request, writer, session); err != nil {
 			srv.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+// signoutHandler destroys the current session. Redis store removes the session from the
+// storage, cookie store just expires the cookie.
+func (srv *GotchaAPIServer) signoutHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if err := srv.destroySession(writer, request); err != nil {
+			srv.error(writer, request, http.StatusInternalServerError, err)
+			return
 		}
 		srv.respond(writer, request, http.StatusOK, nil)
 	}
 }
 
+// signoutEverywhereHandler invalidates all sessions of the user, including the stolen ones
+func (srv *GotchaAPIServer) signoutEverywhereHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		if err := srv.storage.User().RevokeSessions(request.Context(), &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		if err := srv.destroySession(writer, request); err != nil {
+			srv.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+func (srv *GotchaAPIServer) destroySession(writer http.ResponseWriter, request *http.Request) error {
+	session, err := srv.cookieStore.Get(request, sessionName)
+	if err != nil {
+		return err
+	}
+
+	// Negative MaxAge marks the session for deletion
+	session.Options.MaxAge = -1
+	for key := range session.Values {
+		delete(session.Values, key)
+	}
+	return srv.cookieStore.Save(request, writer, session)
+}
+
 // takes user from authorizationMiddleware
 func (srv *GotchaAPIServer) getBoardsHandler() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
@@ -136,20 +206,29 @@ func (srv *GotchaAPIServer) getBoardsHandler() http.HandlerFunc {
 			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
 			return
 		}
-		boards, err := srv.storage.Board().GetRootBoardsOfUser(&user)
+
+		opts, err := listOptionsFromQuery(request, true)
 		if err != nil {
-			srv.error(writer, request, http.StatusInternalServerError, err)
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		boards, err := srv.storage.Board().GetRootBoardsOfUser(request.Context(), &user, opts)
+		if err != nil {
+			srv.storageError(writer, request, err)
 			return
 		}
 		srv.respond(writer, request, http.StatusOK, boards)
 	}
 }
 
-func (srv *GotchaAPIServer) newRootBoardHandler() http.HandlerFunc {
-	type newBoardRequest struct {
-		Title string `json:"title" valid:"required"`
-	}
+type newBoardRequest struct {
+	Title       string    `json:"title"        valid:"required"`
+	WorkspaceID uuid.UUID `json:"workspace_id" valid:"optional"`
+}
 
+func (srv *GotchaAPIServer) newRootBoardHandler() http.HandlerFunc {
+	// Board without workspace is a personal one
 	return func(writer http.ResponseWriter, request *http.Request) {
 		// Get user and title
 		req := newBoardRequest{}
@@ -169,22 +248,22 @@ func (srv *GotchaAPIServer) newRootBoardHandler() http.HandlerFunc {
 			return
 		}
 
-		board, err := srv.storage.Board().NewRootBoard(&user, req.Title)
+		board, err := srv.storage.Board().NewWorkspaceBoard(request.Context(), req.WorkspaceID, &user, req.Title)
 		if err != nil {
-			srv.error(writer, request, http.StatusUnprocessableEntity, err)
+			srv.storageError(writer, request, err)
 			return
 		}
 		srv.respond(writer, request, http.StatusOK, board)
 	}
 }
 
+type deleteRootBoardRequest struct {
+	BoardID uuid.UUID `json:"board_id" valid:"required_uuid"`
+}
+
 func (srv *GotchaAPIServer) deleteRootBoardHandler() http.HandlerFunc {
-	type deleteRequest struct {
-		BoardID   uuid.UUID   `json:"board_id" valid:"required"`
-		Relations []uuid.UUID `json:"relations" valid:"required"`
-	}
 	return func(writer http.ResponseWriter, request *http.Request) {
-		req := deleteRequest{}
+		req := deleteRootBoardRequest{}
 		userWrapped := request.Context().Value(ctxVerifiedUserKey)
 		user, converted := userWrapped.(model.User)
 
@@ -204,14 +283,8 @@ func (srv *GotchaAPIServer) deleteRootBoardHandler() http.HandlerFunc {
 		}
 
 		// Perform delete operation
-		if err := srv.storage.Board().DeleteRootBoard(req.BoardID, req.Relations, &user); err != nil {
-			if err == storage.ErrSecurityError {
-				srv.error(writer, request, http.StatusUnauthorized, err)
-			} else if err == storage.ErrNotFound {
-				srv.error(writer, request, http.StatusUnprocessableEntity, err)
-			} else {
-				srv.error(writer, request, http.StatusInternalServerError, err)
-			}
+		if err := srv.storage.Board().DeleteRootBoard(request.Context(), req.BoardID, &user); err != nil {
+			srv.storageError(writer, request, err)
 			return
 		}
 
@@ -220,17 +293,16 @@ func (srv *GotchaAPIServer) deleteRootBoardHandler() http.HandlerFunc {
 	}
 }
 
-func (srv *GotchaAPIServer) permitBoard() http.HandlerFunc {
-	type permitRequest struct {
-		Description string    `json:"description" valid:"required"`
-		BoardID     uuid.UUID `json:"board_id"    valid:"required"`
-		UserID      uuid.UUID `json:"user_id"     valid:"required"`
-		Permission  string    `json:"permission"  valid:"required"`
-	}
+type permitRequest struct {
+	Description string    `json:"description" valid:"required"`
+	BoardID     uuid.UUID `json:"board_id"    valid:"required_uuid"`
+	UserID      uuid.UUID `json:"user_id"     valid:"required_uuid"`
+	Permission  string    `json:"permission"  valid:"required"`
+}
 
+func (srv *GotchaAPIServer) permitBoard() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		req := permitRequest{}
-		var permission model.PrivilegeType
 
 		userWrapped := request.Context().Value(ctxVerifiedUserKey)
 		user, converted := userWrapped.(model.User)
@@ -250,47 +322,235 @@ func (srv *GotchaAPIServer) permitBoard() http.HandlerFunc {
 			return
 		}
 
-		// Switch over permission type
-		switch req.Permission {
-		case "ro":
-			permission = model.PrivilegeReadOnly
-		case "rw":
-			permission = model.PrivilegeReadWrite
-		default:
-			srv.error(writer, request, http.StatusBadRequest, errors.New("incorrect permission"))
+		permission, err := model.ParseGrantablePrivilege(req.Permission)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		board, err := srv.storage.Board().GetBoardInfo(request.Context(), req.BoardID)
+		// Trigger on incorrect boards: nested & unreal. Root boards always have the author relation
+		if err != nil || board.Base.ID != req.BoardID || len(board.U2BRelations) == 0 {
+			srv.error(writer, request, http.StatusBadRequest, errIncorrectBoard)
 			return
 		}
 
-		board, err := srv.storage.Board().GetBoardInfo(req.BoardID)
-		// Trigger on incorrect boards: nested & unreal
-		if err != nil || board.Base.ID != req.BoardID {
-			srv.error(writer, request, http.StatusBadRequest, errors.New("incorrect board"))
+		// Only owner can permit other users to work with table
+		if err := authorization.New(srv.storage.Board()).Can(request.Context(), &user, authorization.ShareBoard, board.Base.ID); err != nil {
+			srv.storageError(writer, request, err)
 			return
 		}
 
-		for _, rel := range board.U2BRelations {
-			bp, err := srv.storage.Board().GetPrivilegeFromRelation(rel)
-			if err != nil {
-				// We sure that board is correct, but relations are broken
-				srv.error(writer, request, http.StatusInternalServerError, err)
-				return
-			}
+		relation, err := srv.storage.Board().CreateRelation(request.Context(), board.Base.ID, req.UserID, req.Description, permission)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+
+		// Added the relation
+		response := fmt.Sprintf("Granted user:%v %s access to board:%v as %v",
+			req.UserID, req.Permission, req.BoardID, relation)
+		srv.respond(writer, request, http.StatusOK, response)
+	}
+}
+
+type newNestedBoardRequest struct {
+	Title string `json:"title" valid:"required"`
+}
 
-			// Only owner can permit other users to work with table
-			if bp.UserID == user.ID && bp.Privilege == model.PrivilegeAuthor {
-				relation, err := srv.storage.Board().CreateRelation(bp.BoardID, req.UserID, req.Description, permission)
-				if err != nil {
-					continue
-				}
-
-				// Added the relation
-				response := fmt.Sprintf("Granted user:%v %s access to board:%v as %v",
-					req.UserID, req.Permission, req.BoardID, relation)
-				srv.respond(writer, request, http.StatusOK, response)
-				return
+func (srv *GotchaAPIServer) newNestedBoardHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := newNestedBoardRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		board, err := srv.storage.Board().NewNestedBoard(request.Context(), boardID, req.Title, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, board)
+	}
+}
+
+func (srv *GotchaAPIServer) getNestedBoardsHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		boards, err := srv.storage.Board().GetNestedBoards(request.Context(), boardID, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, boards)
+	}
+}
+
+func (srv *GotchaAPIServer) deleteNestedBoardHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		nestedID, err := uuid.Parse(mux.Vars(request)["nested_id"])
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, errIncorrectBoard)
+			return
+		}
+
+		// Make sure that the nested board really belongs to the specified board
+		boards, err := srv.storage.Board().GetNestedBoards(request.Context(), boardID, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		isChild := false
+		for _, board := range boards {
+			if board.Base.ID == nestedID {
+				isChild = true
+				break
 			}
 		}
-		srv.error(writer, request, http.StatusForbidden, errNotPermitted)
+		if !isChild {
+			srv.storageError(writer, request, storage.ErrNotFound)
+			return
+		}
+
+		if err := srv.storage.Board().DeleteNestedBoard(request.Context(), nestedID, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+// treeDepthFromQuery extracts the max_depth query parameter, defaultTreeDepth is used if it's not set
+func treeDepthFromQuery(request *http.Request) (int, error) {
+	rawDepth := request.URL.Query().Get("max_depth")
+	if rawDepth == "" {
+		return defaultTreeDepth, nil
+	}
+
+	maxDepth, err := strconv.Atoi(rawDepth)
+	if err != nil || maxDepth < 0 || maxDepth > maxTreeDepth {
+		return 0, errIncorrectDepth
+	}
+	return maxDepth, nil
+}
+
+// listOptionsFromQuery extracts the page of listing from limit, cursor, sort, order and title query
+// parameters. The privilege filter is accepted by board listings only.
+func listOptionsFromQuery(request *http.Request, withPrivilege bool) (*model.ListOptions, error) {
+	query := request.URL.Query()
+	opts := model.DefaultListOptions()
+	opts.Title = query.Get("title")
+
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil {
+			return nil, model.ErrIncorrectLimit
+		}
+		opts.Limit = limit
+	}
+	if rawSort := query.Get("sort"); rawSort != "" {
+		sortField, err := model.ParseSortField(rawSort)
+		if err != nil {
+			return nil, err
+		}
+		opts.Sort = sortField
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Descending = true
+	default:
+		return nil, errIncorrectOrder
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := model.DecodeCursor(rawCursor)
+		if err != nil {
+			return nil, err
+		}
+		opts.Cursor = cursor
+	}
+	if rawPrivilege := query.Get("privilege"); rawPrivilege != "" && withPrivilege {
+		privilege, err := model.ParsePrivilege(rawPrivilege)
+		if err != nil {
+			return nil, err
+		}
+		opts.Privilege = privilege
+	}
+
+	return opts, opts.Validate()
+}
+
+// getBoardTreeHandler returns the whole hierarchy under the board. Depth is limited by the
+// max_depth query parameter
+func (srv *GotchaAPIServer) getBoardTreeHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		maxDepth, err := treeDepthFromQuery(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		tree, err := srv.storage.Board().GetBoardTree(request.Context(), boardID, maxDepth, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, tree)
 	}
 }
 
@@ -304,9 +564,16 @@ func (srv *GotchaAPIServer) listUsersHandler() http.HandlerFunc {
 			return
 		}
 
-		users, err := srv.storage.User().GetAllUsers(&user)
+		opts, err := listOptionsFromQuery(request, false)
 		if err != nil {
-			srv.error(writer, request, http.StatusInternalServerError, err)
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		// Users outside of the workspaces of user aren't visible
+		users, err := srv.storage.User().GetColleagues(request.Context(), &user, opts)
+		if err != nil {
+			srv.storageError(writer, request, err)
 			return
 		}
 		srv.respond(writer, request, http.StatusOK, users)
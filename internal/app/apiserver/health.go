@@ -0,0 +1,213 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Server is degraded when the share of failed requests in the window exceeds degradedErrorRate.
+	// A few requests don't tell anything, so at least degradedMinRequests are required
+	errorRateWindow     = time.Minute
+	errorRateBuckets    = 6
+	degradedErrorRate   = 0.25
+	degradedMinRequests = 20
+
+	healthCheckTimeout = 2 * time.Second
+)
+
+type HealthStatus string
+
+const (
+	HealthUp       HealthStatus = "up"
+	HealthDown     HealthStatus = "down"
+	HealthDegraded HealthStatus = "degraded"
+)
+
+var errHealthCheckTimeout = errors.New("timeout")
+
+// HealthCheck pings the dependency of the server. Check must respect the deadline of ctx
+type HealthCheck func(ctx context.Context) error
+
+// DependencyHealth is the result of the dependency check. Latency is in milliseconds
+type DependencyHealth struct {
+	Status  HealthStatus `json:"status"`
+	Latency float64      `json:"latency_ms"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// HealthReport is the response of the health probes. Server is up, if all dependencies are up,
+// and degraded if they are, but too many requests fail
+type HealthReport struct {
+	Status       HealthStatus                `json:"status"`
+	ErrorRate    float64                     `json:"error_rate"`
+	Dependencies map[string]DependencyHealth `json:"dependencies,omitempty"`
+}
+
+// AddHealthCheck makes the readiness of the server depend on the check
+func (srv *GotchaAPIServer) AddHealthCheck(name string, check HealthCheck) {
+	srv.healthChecks[name] = check
+}
+
+// pingRedis checks the connection of the sessions store pool
+func pingRedis(pool *redis.Pool) HealthCheck {
+	return func(ctx context.Context) error {
+		conn, err := pool.GetContext(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		timeout := healthCheckTimeout
+		if deadline, found := ctx.Deadline(); found {
+			timeout = time.Until(deadline)
+		}
+		_, err = redis.DoWithTimeout(conn, timeout, "PING")
+		return err
+	}
+}
+
+// liveHandler reports that the server is able to serve requests. Dependencies aren't checked,
+// so the restart of the server doesn't depend on them
+func (srv *GotchaAPIServer) liveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rate, _ := srv.errorRate.rate()
+		srv.respond(w, r, http.StatusOK, HealthReport{Status: HealthUp, ErrorRate: rate})
+	}
+}
+
+// readyHandler pings every dependency concurrently. Server isn't ready, while any of them is down
+func (srv *GotchaAPIServer) readyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := HealthReport{Status: srv.status(), Dependencies: srv.checkDependencies(r)}
+		report.ErrorRate, _ = srv.errorRate.rate()
+
+		code := http.StatusOK
+		for _, dependency := range report.Dependencies {
+			if dependency.Status != HealthUp {
+				report.Status = HealthDown
+				code = http.StatusServiceUnavailable
+			}
+		}
+		srv.respond(w, r, code, report)
+	}
+}
+
+// status is the state of the server based on the error rate of recent requests
+func (srv *GotchaAPIServer) status() HealthStatus {
+	if rate, requests := srv.errorRate.rate(); requests >= degradedMinRequests && rate > degradedErrorRate {
+		return HealthDegraded
+	}
+	return HealthUp
+}
+
+func (srv *GotchaAPIServer) checkDependencies(request *http.Request) map[string]DependencyHealth {
+	ctx, cancel := context.WithTimeout(request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	type result struct {
+		name   string
+		health DependencyHealth
+	}
+	results := make(chan result, len(srv.healthChecks))
+	for name, check := range srv.healthChecks {
+		go func(name string, check HealthCheck) {
+			startTime := time.Now()
+			err := check(ctx)
+			health := DependencyHealth{Status: HealthUp, Latency: float64(time.Since(startTime).Microseconds()) / 1000}
+			if err != nil {
+				health.Status = HealthDown
+				health.Error = dependencyError(err).Error()
+				srv.logger.WithFields(logrus.Fields{"Dependency": name}).Errorf("Health check failed: %v", err)
+			}
+			results <- result{name, health}
+		}(name, check)
+	}
+
+	// Checks, that ignore the deadline, are reported as timed out
+	dependencies := make(map[string]DependencyHealth, len(srv.healthChecks))
+	for len(dependencies) < len(srv.healthChecks) {
+		select {
+		case res := <-results:
+			dependencies[res.name] = res.health
+		case <-ctx.Done():
+			for name := range srv.healthChecks {
+				if _, found := dependencies[name]; !found {
+					dependencies[name] = DependencyHealth{
+						Status:  HealthDown,
+						Latency: float64(healthCheckTimeout.Microseconds()) / 1000,
+						Error:   errHealthCheckTimeout.Error(),
+					}
+				}
+			}
+		}
+	}
+	return dependencies
+}
+
+// dependencyError hides details of the failure, probes are public
+func dependencyError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errHealthCheckTimeout
+	}
+	return errors.New("unavailable")
+}
+
+// errorRate is a share of failed requests in the sliding window. Window is split into buckets,
+// the oldest bucket is reused, when it's out of the window
+type errorRate struct {
+	mu      sync.Mutex
+	buckets [errorRateBuckets]rateBucket
+	now     func() time.Time
+}
+
+type rateBucket struct {
+	start              time.Time
+	requests, failures int
+}
+
+func newErrorRate() *errorRate {
+	return &errorRate{now: time.Now}
+}
+
+func (er *errorRate) observe(failed bool) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	width := errorRateWindow / errorRateBuckets
+	start := er.now().Truncate(width)
+	bucket := &er.buckets[(start.UnixNano()/int64(width))%errorRateBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = rateBucket{start: start}
+	}
+
+	bucket.requests++
+	if failed {
+		bucket.failures++
+	}
+}
+
+// rate returns the share of failed requests and the number of requests in the window
+func (er *errorRate) rate() (float64, int) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	windowStart := er.now().Add(-errorRateWindow)
+	var requests, failures int
+	for _, bucket := range er.buckets {
+		if bucket.start.After(windowStart) {
+			requests += bucket.requests
+			failures += bucket.failures
+		}
+	}
+	if requests == 0 {
+		return 0, 0
+	}
+	return float64(failures) / float64(requests), requests
+}
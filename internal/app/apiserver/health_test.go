@@ -0,0 +1,45 @@
+package apiserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorRate(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	er := newErrorRate()
+	er.now = func() time.Time { return now }
+
+	rate, requests := er.rate()
+	assert.Equal(t, 0.0, rate)
+	assert.Equal(t, 0, requests)
+
+	for i := 0; i < 3; i++ {
+		er.observe(false)
+	}
+	er.observe(true)
+	rate, requests = er.rate()
+	assert.Equal(t, 0.25, rate)
+	assert.Equal(t, 4, requests)
+
+	// Requests of the previous buckets are still in the window
+	now = now.Add(errorRateWindow / 2)
+	er.observe(true)
+	rate, requests = er.rate()
+	assert.Equal(t, 0.4, rate)
+	assert.Equal(t, 5, requests)
+
+	// Failures are forgotten, when they're out of the window
+	now = now.Add(errorRateWindow / 2)
+	er.observe(false)
+	rate, requests = er.rate()
+	assert.Equal(t, 0.5, rate, "Outdated bucket counted")
+	assert.Equal(t, 2, requests)
+
+	now = now.Add(errorRateWindow * 2)
+	rate, requests = er.rate()
+	assert.Equal(t, 0.0, rate, "Error rate never recovers")
+	assert.Equal(t, 0, requests)
+}
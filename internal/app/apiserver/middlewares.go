@@ -2,11 +2,22 @@ package apiserver
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/tracing"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type middlewareContextKey int
@@ -30,37 +41,92 @@ func getIPAddress(req *http.Request) string {
 
 func (srv *GotchaAPIServer) authorizationMiddleware(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var user *model.User
 
-		// Get the uuid and do some sanity checks
-		session, err := srv.cookieStore.Get(request, sessionName)
-		if err != nil {
-			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
-			return
-		}
+		// API tokens have priority over the cookie session
+		if rawToken, found := bearerToken(request); found {
+			token, err := srv.verifyToken(request, rawToken)
+			if err != nil {
+				srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+				return
+			}
 
-		userID, ok := session.Values["user_id"]
-		if !ok {
-			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
-			return
-		}
+			// Read-only tokens are allowed to perform safe requests only
+			if token.Scope == model.TokenScopeReadOnly && !isSafeMethod(request.Method) {
+				srv.error(writer, request, http.StatusForbidden, errNotPermitted)
+				return
+			}
 
-		userUUID, err := uuid.Parse(userID.(string))
-		if err != nil {
-			srv.error(writer, request, http.StatusUnauthorized, err)
-			return
-		}
+			if user, err = srv.storage.User().FindUserByID(request.Context(), token.UserID); err != nil {
+				srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+				return
+			}
+		} else {
+			// Get the uuid and do some sanity checks
+			session, err := srv.cookieStore.Get(request, sessionName)
+			if err != nil {
+				srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+				return
+			}
+
+			userID, ok := session.Values[sessionUserIDKey]
+			if !ok {
+				srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+				return
+			}
 
-		user, err := srv.storage.User().FindUserByID(userUUID)
-		if err != nil {
-			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
-			return
+			userUUID, err := uuid.Parse(userID.(string))
+			if err != nil {
+				srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+				return
+			}
+
+			if user, err = srv.storage.User().FindUserByID(request.Context(), userUUID); err != nil {
+				srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+				return
+			}
+
+			// Session could be revoked by "sign out everywhere"
+			issuedAt, _ := session.Values[sessionIssuedAtKey].(int64)
+			if user.IsSessionRevoked(time.Unix(0, issuedAt)) {
+				srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+				return
+			}
 		}
 
+		trace.SpanFromContext(request.Context()).SetAttributes(tracing.UserID(user.ID))
 		wrappedContext := context.WithValue(request.Context(), ctxVerifiedUserKey, *user)
 		handler.ServeHTTP(writer, request.WithContext(wrappedContext))
 	})
 }
 
+// bearerToken extracts token from the "Authorization: Bearer <token>" header
+func bearerToken(request *http.Request) (string, bool) {
+	const prefix = "Bearer "
+
+	header := request.Header.Get("Authorization")
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
+}
+
+// verifyToken finds the token by its hash and checks that it's not expired
+func (srv *GotchaAPIServer) verifyToken(request *http.Request, rawToken string) (*model.APIToken, error) {
+	token, err := srv.storage.Token().FindTokenByHash(request.Context(), model.HashToken(rawToken))
+	if err != nil {
+		return nil, err
+	}
+	if token.IsExpired() {
+		return nil, errTokenExpired
+	}
+	return token, nil
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
 func (srv *GotchaAPIServer) setRequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(
 		func(writer http.ResponseWriter, request *http.Request) {
@@ -76,7 +142,7 @@ func (srv *GotchaAPIServer) loggingMiddleware(handler http.Handler) http.Handler
 		var resultCode int
 		requestID := request.Context().Value(ctxRequestIDKey)
 		fields := logrus.Fields{
-			"Path":       request.RequestURI,
+			"Path":       redactedURI(request),
 			"Request-ID": requestID,
 		}
 
@@ -85,6 +151,141 @@ func (srv *GotchaAPIServer) loggingMiddleware(handler http.Handler) http.Handler
 		startTime := time.Now()
 
 		handler.ServeHTTP(writer, request.WithContext(wrappedContext))
-		srv.logger.WithFields(fields).Infof("Served for %v. Status code: %d", time.Since(startTime), resultCode)
+		elapsed := time.Since(startTime)
+		srv.logger.WithFields(fields).Infof("Served for %v. Status code: %d", elapsed, resultCode)
+
+		// 503 is the verdict of the readiness probe, not a failure of the request
+		status := statusOrDefault(resultCode)
+		srv.errorRate.observe(status >= http.StatusInternalServerError && status != http.StatusServiceUnavailable)
+
+		if srv.metrics != nil {
+			srv.metrics.ObserveRequest(request.Method, routeTemplate(request), status, elapsed)
+		}
+	})
+}
+
+var tracer = otel.Tracer("Gotcha/internal/app/apiserver")
+
+// tracingMiddleware continues the trace of incoming traceparent header (or starts the new one)
+// with the span of the handler. Trace context is injected into the response headers.
+func (srv *GotchaAPIServer) tracingMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		propagator := otel.GetTextMapPropagator()
+		route := routeTemplate(request)
+		requestID, _ := request.Context().Value(ctxRequestIDKey).(string)
+
+		ctx := propagator.Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+		ctx, span := tracer.Start(ctx, request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(request.Method),
+				semconv.HTTPRoute(route),
+				semconv.HTTPTarget(redactedPath(request)),
+				attribute.String("gotcha.request_id", requestID),
+			),
+		)
+		defer span.End()
+
+		if boardID, err := uuid.Parse(mux.Vars(request)["board_id"]); err == nil {
+			span.SetAttributes(tracing.BoardID(boardID))
+		}
+		propagator.Inject(ctx, propagation.HeaderCarrier(writer.Header()))
+
+		handler.ServeHTTP(writer, request.WithContext(ctx))
+
+		status := http.StatusOK
+		if resultCode, ok := ctx.Value(ctxStatusCodeKey).(*int); ok {
+			status = statusOrDefault(*resultCode)
+		}
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
 	})
 }
+
+// routeTemplate returns the path template of the matched route, e.g. /api/v1/boards/{id}
+func routeTemplate(request *http.Request) string {
+	if route := mux.CurrentRoute(request); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unknown"
+}
+
+// sensitiveRouteVars are secrets passed in the path, like share link tokens. They are never
+// written to logs or traces
+var sensitiveRouteVars = []string{"token"}
+
+// redactedPath returns the escaped path of the request, where segments of sensitive route
+// variables are replaced with their templates, e.g. /api/v1/shared/{token}
+func redactedPath(request *http.Request) string {
+	path := request.URL.EscapedPath()
+	vars := mux.Vars(request)
+	sensitive := false
+	for _, name := range sensitiveRouteVars {
+		_, found := vars[name]
+		sensitive = sensitive || found
+	}
+	if !sensitive {
+		return path
+	}
+
+	template := strings.Split(routeTemplate(request), "/")
+	segments := strings.Split(path, "/")
+	if len(segments) != len(template) {
+		return strings.Join(template, "/")
+	}
+	for i := range segments {
+		for _, name := range sensitiveRouteVars {
+			if template[i] == "{"+name+"}" {
+				segments[i] = template[i]
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// redactedURI is redactedPath with the query of the request
+func redactedURI(request *http.Request) string {
+	if request.URL.RawQuery == "" {
+		return redactedPath(request)
+	}
+	return redactedPath(request) + "?" + request.URL.RawQuery
+}
+
+// statusOrDefault treats the response, which status wasn't set explicitly, as successful
+func statusOrDefault(code int) int {
+	if code == 0 {
+		return http.StatusOK
+	}
+	return code
+}
+
+const legacySunsetLayout = "2006-01-02"
+
+// deprecationMiddleware marks responses of the unversioned routes as deprecated and points
+// clients to the same route of ApiLegacyVersion
+func (srv *GotchaAPIServer) deprecationMiddleware() mux.MiddlewareFunc {
+	var sunset string
+	if srv.cfg.LegacyApiSunset != "" {
+		if sunsetDate, err := time.Parse(legacySunsetLayout, srv.cfg.LegacyApiSunset); err != nil {
+			srv.logger.Printf("Incorrect sunset date of the legacy API: %v", err)
+		} else {
+			sunset = sunsetDate.UTC().Format(http.TimeFormat)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			successor := ApiLegacyVersion.Root() + strings.TrimPrefix(request.URL.Path, ApiBasePath)
+			writer.Header().Set("Deprecation", "true")
+			writer.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+			if sunset != "" {
+				writer.Header().Set("Sunset", sunset)
+			}
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
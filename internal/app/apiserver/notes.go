@@ -0,0 +1,205 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Gotcha/internal/app/model"
+	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	errIncorrectBoard = errors.New("incorrect board")
+	errIncorrectNote  = errors.New("incorrect note")
+)
+
+type noteRequest struct {
+	Title    string `json:"title"     valid:"required"`
+	Content  string `json:"content"   valid:"optional"`
+	ReadOnly bool   `json:"read_only" valid:"optional"`
+}
+
+// boardIDFromPath extracts {board_id} variable of the route
+func boardIDFromPath(request *http.Request) (uuid.UUID, error) {
+	boardID, err := uuid.Parse(mux.Vars(request)["board_id"])
+	if err != nil {
+		return uuid.Nil, errIncorrectBoard
+	}
+	return boardID, nil
+}
+
+// noteIDFromPath extracts {note_id} variable of the route
+func noteIDFromPath(request *http.Request) (int, error) {
+	noteID, err := strconv.Atoi(mux.Vars(request)["note_id"])
+	if err != nil {
+		return 0, errIncorrectNote
+	}
+	return noteID, nil
+}
+
+func (srv *GotchaAPIServer) newNoteHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := noteRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		note := model.Note{
+			Title:    req.Title,
+			Content:  req.Content,
+			ReadOnly: req.ReadOnly,
+		}
+		if err := srv.storage.Note().NewNote(request.Context(), boardID, &note, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, note)
+	}
+}
+
+func (srv *GotchaAPIServer) getNotesHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		notes, err := srv.storage.Note().GetNotes(request.Context(), boardID, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, notes)
+	}
+}
+
+func (srv *GotchaAPIServer) getNoteHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		noteID, err := noteIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		note, err := srv.storage.Note().GetNote(request.Context(), boardID, noteID, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, note)
+	}
+}
+
+func (srv *GotchaAPIServer) updateNoteHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := noteRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		noteID, err := noteIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		note := model.Note{
+			ID:       noteID,
+			Title:    req.Title,
+			Content:  req.Content,
+			ReadOnly: req.ReadOnly,
+		}
+		if err := srv.storage.Note().UpdateNote(request.Context(), boardID, &note, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, note)
+	}
+}
+
+func (srv *GotchaAPIServer) deleteNoteHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		noteID, err := noteIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := srv.storage.Note().DeleteNote(request.Context(), boardID, noteID, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
@@ -0,0 +1,316 @@
+package apiserver
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"github.com/google/uuid"
+)
+
+const openAPIVersion = "3.0.3"
+
+var (
+	// listQuery are parameters of listOptionsFromQuery
+	listQuery = []string{"limit", "cursor", "sort", "order", "title"}
+
+	pathVariablePattern  = regexp.MustCompile(`\{(\w+)(:[^}]*)?}`)
+	typeQualifierPattern = regexp.MustCompile(`[\w./-]*\.|\*`)
+)
+
+// apiOperation documents the route. Request and response are zero values of the JSON bodies,
+// nil means that there is no body
+type apiOperation struct {
+	handle   ApiHandle
+	summary  string
+	public   bool
+	query    []string
+	request  any
+	response any
+}
+
+// boardsHandle completes the path of the handle registered on the boards subrouter
+func boardsHandle(handle ApiHandle) ApiHandle {
+	handle.Path = ApiBoardsPath + handle.Path
+	return handle
+}
+
+// apiOperations must describe every registered route, the spec is generated from them
+var apiOperations = []apiOperation{
+	{handle: ApiHealthLive, summary: "Liveness probe", public: true, response: HealthReport{}},
+	{handle: ApiHealthReady, summary: "Readiness probe, checks dependencies of the server", public: true, response: HealthReport{}},
+	{handle: ApiHeartbeat, summary: "Status of the server", public: true, response: ServerStatus{}},
+	{handle: ApiOpenAPI, summary: "OpenAPI specification of the API", public: true, response: map[string]any{}},
+	{handle: ApiSignup, summary: "Register a new user", public: true, request: RegisterRequest{}, response: ""},
+	{handle: ApiAuthorize, summary: "Sign in and start the session", public: true, request: loginRequest{}},
+	{handle: ApiListUsers, summary: "Colleagues of the user", query: listQuery, response: model.Page[*model.User]{}},
+	{handle: ApiSignout, summary: "Finish the current session"},
+	{handle: ApiSignoutEverywhere, summary: "Revoke all sessions of the user"},
+
+	{handle: ApiNewToken, summary: "Issue a personal access token", request: newTokenRequest{}, response: model.APIToken{}},
+	{handle: ApiGetTokens, summary: "Personal access tokens of the user", response: []*model.APIToken{}},
+	{handle: ApiRevokeToken, summary: "Revoke the personal access token"},
+
+	{handle: ApiNewTeam, summary: "Create a team", request: newTeamRequest{}, response: model.Team{}},
+	{handle: ApiGetTeams, summary: "Teams of the user", response: []*model.Team{}},
+	{handle: ApiGetTeamMembers, summary: "Members of the team", response: []*model.TeamMember{}},
+	{handle: ApiAddTeamMember, summary: "Add the user to the team", request: addTeamMemberRequest{}},
+	{handle: ApiRemoveTeamMember, summary: "Remove the member from the team"},
+
+	{handle: ApiNewWorkspace, summary: "Create a workspace", request: newWorkspaceRequest{}, response: model.Workspace{}},
+	{handle: ApiGetWorkspaces, summary: "Workspaces of the user", response: []*model.Workspace{}},
+	{handle: ApiGetWorkspaceMembers, summary: "Members of the workspace", response: []*model.WorkspaceMember{}},
+	{handle: ApiAddWorkspaceMember, summary: "Add the user to the workspace", request: addWorkspaceMemberRequest{}},
+	{handle: ApiUpdateWorkspaceMember, summary: "Change role of the member", request: updateWorkspaceMemberRequest{}},
+	{handle: ApiRemoveWorkspaceMember, summary: "Remove the member from the workspace"},
+
+	{handle: ApiSearch, summary: "Search boards and notes", query: []string{"q", "limit"}, response: []*model.SearchResult{}},
+
+	{handle: ApiGetSharedTree, summary: "Board tree of the share link", public: true, query: []string{"max_depth"}, response: model.BoardTree{}},
+	{handle: ApiGetSharedNotes, summary: "Notes of the shared board", public: true, response: []*model.Note{}},
+
+	{handle: boardsHandle(ApiGetBoards), summary: "Root boards of the user", query: append(listQuery, "privilege"), response: model.Page[*model.Board]{}},
+	{handle: boardsHandle(ApiNewRootBoard), summary: "Create a root board", request: newBoardRequest{}, response: model.Board{}},
+	{handle: boardsHandle(ApiDeleteRootBoard), summary: "Delete the root board", request: deleteRootBoardRequest{}},
+	{handle: boardsHandle(ApiPermitBoard), summary: "Grant the user access to the root board", request: permitRequest{}, response: ""},
+
+	{handle: boardsHandle(ApiNewNestedBoard), summary: "Create a nested board", request: newNestedBoardRequest{}, response: model.NestedBoard{}},
+	{handle: boardsHandle(ApiGetNestedBoards), summary: "Nested boards of the board", response: []*model.NestedBoard{}},
+	{handle: boardsHandle(ApiDeleteNestedBoard), summary: "Delete the nested board"},
+	{handle: boardsHandle(ApiGetBoardTree), summary: "Hierarchy under the board", query: []string{"max_depth"}, response: model.BoardTree{}},
+
+	{handle: boardsHandle(ApiGetCollaborators), summary: "Collaborators of the root board", response: []*model.Collaborator{}},
+	{handle: boardsHandle(ApiUpdateCollaborator), summary: "Change privilege of the collaborator", request: updateCollaboratorRequest{}},
+	{handle: boardsHandle(ApiRevokeCollaborator), summary: "Revoke access of the collaborator"},
+	{handle: boardsHandle(ApiTransferBoard), summary: "Transfer authorship of the root board", request: transferRequest{}, response: model.BoardTransfer{}},
+
+	{handle: boardsHandle(ApiNewShareLink), summary: "Create a read-only share link", request: newShareLinkRequest{}, response: model.ShareLink{}},
+	{handle: boardsHandle(ApiGetShareLinks), summary: "Share links of the board", response: []*model.ShareLink{}},
+	{handle: boardsHandle(ApiRevokeShareLink), summary: "Revoke the share link"},
+
+	{handle: boardsHandle(ApiGrantTeam), summary: "Grant the team access to the root board", request: grantTeamRequest{}, response: map[string]uuid.UUID{}},
+	{handle: boardsHandle(ApiRevokeTeam), summary: "Revoke access of the team"},
+
+	{handle: boardsHandle(ApiNewNote), summary: "Create a note", request: noteRequest{}, response: model.Note{}},
+	{handle: boardsHandle(ApiGetNotes), summary: "Notes of the board", response: []*model.Note{}},
+	{handle: boardsHandle(ApiGetNote), summary: "Note of the board", response: model.Note{}},
+	{handle: boardsHandle(ApiUpdateNote), summary: "Update the note", request: noteRequest{}, response: model.Note{}},
+	{handle: boardsHandle(ApiDeleteNote), summary: "Delete the note"},
+}
+
+// openAPIHandler serves the specification, which is generated once on start
+func (srv *GotchaAPIServer) openAPIHandler() http.HandlerFunc {
+	title := srv.cfg.AppName
+	if title == "" {
+		title = "Gotcha"
+	}
+	spec := newOpenAPISpec(title, apiOperations)
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		srv.respond(writer, request, http.StatusOK, spec)
+	}
+}
+
+// newOpenAPISpec builds the OpenAPI document. Named structs become component schemas
+func newOpenAPISpec(title string, operations []apiOperation) map[string]any {
+	schemas := openAPISchemas{components: map[string]any{}}
+	errorSchema := schemas.of(reflect.TypeOf(errorResponse{}))
+
+	paths := map[string]any{}
+	for _, operation := range operations {
+		if !operation.handle.availableIn(currentApiVersion()) {
+			continue
+		}
+		path, parameters := openAPIPath(operation.handle.Path)
+		for _, name := range operation.query {
+			parameters = append(parameters, map[string]any{
+				"name": name, "in": "query", "schema": map[string]any{"type": "string"},
+			})
+		}
+
+		item := map[string]any{
+			"summary": operation.summary,
+			"responses": map[string]any{
+				"200": schemas.content("OK", operation.response),
+				"default": map[string]any{
+					"description": "Error",
+					"content":     map[string]any{"application/json": map[string]any{"schema": errorSchema}},
+				},
+			},
+		}
+		if len(parameters) != 0 {
+			item["parameters"] = parameters
+		}
+		if operation.request != nil {
+			body := schemas.content("", operation.request)
+			body["required"] = true
+			delete(body, "description")
+			item["requestBody"] = body
+		}
+		if !operation.public {
+			item["security"] = []map[string][]string{{"cookieAuth": {}}, {"bearerAuth": {}}}
+		}
+
+		methods, found := paths[path].(map[string]any)
+		if !found {
+			methods = map[string]any{}
+			paths[path] = methods
+		}
+		for _, method := range operation.handle.Methods {
+			methods[strings.ToLower(method)] = item
+		}
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info":    map[string]any{"title": title, "version": "1.0.0"},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				"cookieAuth": map[string]any{"type": "apiKey", "in": "cookie", "name": sessionName},
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// openAPIPath converts mux path template to the OpenAPI one. Variables with the numeric pattern are
+// integers, *_id variables are uuids
+func openAPIPath(template string) (string, []map[string]any) {
+	parameters := make([]map[string]any, 0)
+	for _, match := range pathVariablePattern.FindAllStringSubmatch(template, -1) {
+		schema := map[string]any{"type": "string"}
+		switch {
+		case match[2] == ":[0-9]+":
+			schema = map[string]any{"type": "integer"}
+		case strings.HasSuffix(match[1], "_id"):
+			schema["format"] = "uuid"
+		}
+		parameters = append(parameters, map[string]any{
+			"name": match[1], "in": "path", "required": true, "schema": schema,
+		})
+	}
+	return pathVariablePattern.ReplaceAllString(template, "{$1}"), parameters
+}
+
+// openAPISchemas generates schemas of Go types the way encoding/json marshals them
+type openAPISchemas struct {
+	components map[string]any
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// content describes JSON body of the value, nil value has no body
+func (s *openAPISchemas) content(description string, value any) map[string]any {
+	content := map[string]any{"description": description}
+	if value != nil {
+		content["content"] = map[string]any{
+			"application/json": map[string]any{"schema": s.of(reflect.TypeOf(value))},
+		}
+	}
+	return content
+}
+
+func (s *openAPISchemas) of(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	case t == durationType:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	case t.Implements(jsonMarshalerType):
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		return s.component(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+// component registers the struct schema and refers to it. Recursive types are supported,
+// because the name is reserved before the fields are described
+func (s *openAPISchemas) component(t reflect.Type) map[string]any {
+	name := componentName(t)
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	if _, found := s.components[name]; found {
+		return ref
+	}
+	s.components[name] = nil
+
+	properties := map[string]any{}
+	required := make([]string, 0)
+	s.describeFields(t, properties, &required)
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) != 0 {
+		schema["required"] = required
+	}
+	s.components[name] = schema
+	return ref
+}
+
+// describeFields follows encoding/json rules: embedded structs are flattened, untagged fields keep
+// their names. Fields with valid:"required" or valid:"required_uuid" tag are required
+func (s *openAPISchemas) describeFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.describeFields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.of(field.Type)
+		if valid := field.Tag.Get("valid"); valid == "required" || valid == "required_uuid" {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// componentName strips package qualifiers: Page[*Gotcha/internal/app/model.Board] becomes PageBoard
+func componentName(t reflect.Type) string {
+	name := typeQualifierPattern.ReplaceAllString(t.Name(), "")
+	name = strings.NewReplacer("[", "", "]", "", ",", "").Replace(name)
+	return strings.ToUpper(name[:1]) + name[1:]
+}
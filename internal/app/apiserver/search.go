@@ -0,0 +1,47 @@
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Gotcha/internal/app/model"
+)
+
+var errIncorrectSearchLimit = fmt.Errorf("limit must be in range [1, %d]", model.MaxPageLimit)
+
+// searchHandler looks for boards and notes, that user can access, by the q query parameter.
+// Results are ordered by rank, matches in snippets are highlighted
+func (srv *GotchaAPIServer) searchHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		query := strings.TrimSpace(request.URL.Query().Get("q"))
+		if query == "" {
+			srv.error(writer, request, http.StatusBadRequest, model.ErrEmptySearchQuery)
+			return
+		}
+		limit := model.DefaultSearchLimit
+		if rawLimit := request.URL.Query().Get("limit"); rawLimit != "" {
+			parsedLimit, err := strconv.Atoi(rawLimit)
+			if err != nil || parsedLimit < 1 || parsedLimit > model.MaxPageLimit {
+				srv.error(writer, request, http.StatusBadRequest, errIncorrectSearchLimit)
+				return
+			}
+			limit = parsedLimit
+		}
+
+		results, err := srv.storage.Search().Search(request.Context(), query, limit, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, results)
+	}
+}
@@ -4,30 +4,67 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"time"
 
+	"Gotcha/internal/app/lifecycle"
 	"Gotcha/internal/app/logging"
+	"Gotcha/internal/app/migrator"
 	internalStorage "Gotcha/internal/app/storage"
 	"Gotcha/internal/app/storage/postgres"
+	"Gotcha/internal/app/tracing"
+	"Gotcha/migrations"
 	"github.com/boj/redistore"
+	"github.com/gomodule/redigo/redis"
 	"github.com/gorilla/sessions"
 )
 
+const metricsPath = "/metrics"
+
 // Start is a core function of api-server module. It opens a database connection
-// configures some dependencies and fires the API server.
+// configures some dependencies and fires the API server. Start returns, when ctx is done
+// and all components are stopped, or when any of them fails to serve.
 func Start(ctx context.Context, cfg *GotchaConfiguration, logger logging.GotchaLogger) error {
+	manager := lifecycle.New(logger, time.Duration(cfg.ShutdownTimeout)*time.Second)
+	if err := startComponents(ctx, manager, cfg, logger); err != nil {
+		return manager.Stop(err)
+	}
+	return manager.Wait(ctx)
+}
+
+// startComponents registers every opened resource in manager, so it's closed even if
+// the start fails halfway
+func startComponents(ctx context.Context, manager *lifecycle.Manager, cfg *GotchaConfiguration, logger logging.GotchaLogger) error {
+	shutdownTracing, err := tracing.Setup(ctx, &cfg.TracingConfiguration, cfg.AppName)
+	if err != nil {
+		return err
+	}
+	manager.Register("tracing", lifecycle.Closer(shutdownTracing))
+
 	// Get DB ...
 	var sessionsStore sessions.Store
-	db, err := OpenDB(cfg.DatabaseConfiguration.GetConnectionString(), cfg.DatabaseConfiguration.Attempts)
+	var redisPool *redis.Pool
+	db, err := OpenDB(ctx, &cfg.DatabaseConfiguration, logger)
 	if err != nil {
 		return err
 	}
 
 	// Get storage
 	storage := postgres.NewStore(db)
+	storage.SetQueryTimeout(time.Duration(cfg.DatabaseConfiguration.QueryTimeout) * time.Second)
+	manager.Register("postgres", func(context.Context) error {
+		storage.Close()
+		return nil
+	})
 	logger.Println("Initialized storage")
 
+	if cfg.AutoMigrate {
+		if err := migrateSchema(ctx, db, logger); err != nil {
+			return err
+		}
+	}
+
 	// And cookie store
 	switch cfg.CookiesStore {
 	case internalStorage.SessionsStoreRedis:
@@ -38,71 +75,183 @@ func Start(ctx context.Context, cfg *GotchaConfiguration, logger logging.GotchaL
 			"", []byte(cfg.SessionKey),
 		)
 		if err != nil {
-			logger.Panicln("Failed to open redis connection.")
+			return fmt.Errorf("open redis connection: %w", err)
 		}
 		currStore.SetMaxAge(cfg.RedisConfiguration.SessionLifetime)
-		defer func() {
-			logger.Println("Closing redis store")
-			_ = currStore.Close()
-		}()
+		currStore.Options.Secure = cfg.TLSConfiguration.Enabled
+		manager.Register("redis", func(context.Context) error {
+			return currStore.Close()
+		})
 		sessionsStore = currStore
+		redisPool = currStore.Pool
 		logger.Printf("Connected to redis: %s", conString)
 	default:
-		sessionsStore = sessions.NewCookieStore([]byte(cfg.SessionKey))
+		cookieStore := sessions.NewCookieStore([]byte(cfg.SessionKey))
+		cookieStore.Options.Secure = cfg.TLSConfiguration.Enabled
+		sessionsStore = cookieStore
 	}
 
 	// Create server
-	bindAddress := fmt.Sprintf("%s:%d", cfg.BindIP, cfg.BindPort)
 	srv := NewAPIServer(logger, cfg, storage, sessionsStore)
-	httpServer := http.Server{
+	srv.AddHealthCheck("postgres", db.PingContext)
+	if redisPool != nil {
+		srv.AddHealthCheck("redis", pingRedis(redisPool))
+	}
+
+	if srv.metrics != nil {
+		if err := serveMetrics(manager, srv, db, redisPool, cfg, logger); err != nil {
+			return err
+		}
+	}
+	return serveAPI(manager, srv, cfg, logger)
+}
+
+// serveAPI fires the API server in second goroutine! With TLS enabled it serves HTTPS and HTTP/2,
+// plain HTTP is redirected to it. In-flight requests are drained on stop
+func serveAPI(manager *lifecycle.Manager, srv *GotchaAPIServer, cfg *GotchaConfiguration, logger logging.GotchaLogger) error {
+	bindAddress := fmt.Sprintf("%s:%d", cfg.BindIP, cfg.BindPort)
+	httpServer := &http.Server{
 		Addr:    bindAddress,
 		Handler: srv.Router,
 	}
 
-	// Then fire it in second goroutine!
-	go func() {
-		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
-			logger.Panicln(err)
-		}
-	}()
-	logger.Printf("Ready to serve requests on %s", bindAddress)
+	if !cfg.TLSConfiguration.Enabled {
+		manager.Go("http server", httpServer.ListenAndServe)
+		manager.Register("http server", httpServer.Shutdown)
+		logger.Printf("Ready to serve requests on http://%s", bindAddress)
+		return nil
+	}
 
-	// close all stores on ctx.done()
-	<-ctx.Done()
-	logger.Println("Shutting down the server")
+	reloader, err := newCertificateReloader(cfg.TLSConfiguration.CertFile, cfg.TLSConfiguration.KeyFile)
+	if err != nil {
+		return err
+	}
+	if httpServer.TLSConfig, err = newTLSConfig(&cfg.TLSConfiguration, reloader); err != nil {
+		return err
+	}
+
+	watchContext, stopWatch := context.WithCancel(context.Background())
+	go reloader.watch(watchContext, certificateCheckInterval, logger)
+	manager.Register("certificate watcher", func(context.Context) error {
+		stopWatch()
+		return nil
+	})
 
-	shutdownContext, cancel := context.WithTimeout(context.Background(), time.Second*3)
-	defer cancel()
+	manager.Go("https server", func() error {
+		return httpServer.ListenAndServeTLS("", "")
+	})
+	manager.Register("https server", httpServer.Shutdown)
+	logger.Printf("Ready to serve requests on https://%s", bindAddress)
 
-	if err := httpServer.Shutdown(shutdownContext); err != nil {
-		logger.Error(err)
+	if cfg.TLSConfiguration.RedirectAddress != "" {
+		redirectServer := &http.Server{
+			Addr:    cfg.TLSConfiguration.RedirectAddress,
+			Handler: redirectToHTTPS(cfg.BindPort),
+		}
+		manager.Go("redirect server", redirectServer.ListenAndServe)
+		manager.Register("redirect server", redirectServer.Shutdown)
+		logger.Printf("Redirecting plain HTTP from %s", cfg.TLSConfiguration.RedirectAddress)
 	}
+	return nil
+}
 
-	mainShutdown := make(chan struct{}, 1)
-	go func() {
-		storage.Close()
-		mainShutdown <- struct{}{}
-	}()
+// serveMetrics attaches sources of the instance to the metrics of srv and serves them
+// on the separate address
+func serveMetrics(manager *lifecycle.Manager, srv *GotchaAPIServer, db *sql.DB, redisPool *redis.Pool, cfg *GotchaConfiguration, logger logging.GotchaLogger) error {
+	if err := srv.metrics.RegisterDB(db, cfg.DatabaseConfiguration.SelectedDatabase); err != nil {
+		return err
+	}
+	if redisPool != nil {
+		if err := srv.metrics.RegisterRedisPool(redisPool); err != nil {
+			return err
+		}
+	}
+	if err := srv.metrics.RegisterStorage(srv.storage); err != nil {
+		return err
+	}
+
+	router := http.NewServeMux()
+	router.Handle(metricsPath, srv.metrics.Handler())
+	metricsServer := &http.Server{
+		Addr:    cfg.MetricsConfiguration.BindAddress,
+		Handler: router,
+	}
+
+	manager.Go("metrics server", metricsServer.ListenAndServe)
+	manager.Register("metrics server", metricsServer.Shutdown)
+	logger.Printf("Serving metrics on %s%s", cfg.MetricsConfiguration.BindAddress, metricsPath)
+	return nil
+}
+
+// migrateSchema applies all pending embedded migrations
+func migrateSchema(ctx context.Context, db *sql.DB, logger logging.GotchaLogger) error {
+	schemaMigrator, err := migrator.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
 
-	select {
-	case <-shutdownContext.Done():
-		return fmt.Errorf("server shutdown: %w", ctx.Err())
-	case <-mainShutdown:
-		logger.Println("Bye...")
+	applied, err := schemaMigrator.Up(ctx)
+	for _, migration := range applied {
+		logger.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("auto migration: %w", err)
 	}
 	return nil
 }
 
-func OpenDB(conStr string, attempts int) (*sql.DB, error) {
-	var err error
-	var db *sql.DB
+const (
+	connectRetryDelay    = 500 * time.Millisecond
+	connectMaxRetryDelay = 15 * time.Second
+)
+
+// OpenDB opens the connection pool and makes sure, that the database is reachable. Connection
+// is attempted up to dbc.Attempts times with exponential backoff
+func OpenDB(ctx context.Context, dbc *DatabaseConfiguration, logger logging.GotchaLogger) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dbc.GetConnectionString())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(dbc.MaxOpenConnections)
+	db.SetMaxIdleConns(dbc.MaxIdleConnections)
+	db.SetConnMaxLifetime(time.Duration(dbc.ConnectionLifetime) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(dbc.ConnectionIdleTime) * time.Second)
 
-	for i := 0; i < attempts; i++ {
-		db, err = sql.Open("postgres", conStr)
+	attempts := dbc.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		pingContext, cancel := context.WithTimeout(ctx, connectMaxRetryDelay)
+		err = db.PingContext(pingContext)
+		cancel()
 		if err == nil {
 			return db, nil
 		}
-		time.Sleep(time.Second * 5)
+		if attempt == attempts {
+			break
+		}
+
+		delay := retryDelay(attempt)
+		logger.Printf("Database is unavailable (attempt %d of %d), retrying in %v: %v", attempt, attempts, delay, err)
+		select {
+		case <-ctx.Done():
+			_ = db.Close()
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	_ = db.Close()
+	return nil, fmt.Errorf("connect to database after %d attempts: %w", attempts, err)
+}
+
+// retryDelay is the exponential backoff with full jitter: random delay up to the doubled
+// delay of the previous attempt
+func retryDelay(attempt int) time.Duration {
+	ceiling := connectMaxRetryDelay
+	if attempt < 32 && connectRetryDelay<<(attempt-1) < connectMaxRetryDelay {
+		ceiling = connectRetryDelay << (attempt - 1)
 	}
-	return db, err
+	return time.Duration(rand.Int63n(int64(ceiling)))
 }
@@ -2,18 +2,33 @@ package apiserver_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 
 	"Gotcha/internal/app/apiserver"
 	"Gotcha/internal/app/model"
+	internalStorage "Gotcha/internal/app/storage"
 	"Gotcha/internal/app/storage/teststore"
+	"Gotcha/internal/app/tracing"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 var (
@@ -46,6 +61,7 @@ func TestGotchaAPIServer_heartbeat(t *testing.T) {
 }
 
 func TestGotchaAPIServer_signup(t *testing.T) {
+	ctx := context.Background()
 	storage := teststore.New()
 	testUser := model.TestUser(t)
 	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
@@ -55,7 +71,7 @@ func TestGotchaAPIServer_signup(t *testing.T) {
 	anotherUser := model.TestUser(t)
 	anotherUser.Email += "2"
 	anotherUser.Username += "2"
-	_ = storage.User().SaveUser(anotherUser)
+	_ = storage.User().SaveUser(ctx, anotherUser)
 
 	testCases := []struct {
 		caseName, testFailedDescription string
@@ -114,9 +130,10 @@ func TestGotchaAPIServer_signup(t *testing.T) {
 }
 
 func TestGotchaAPIServer_signin(t *testing.T) {
+	ctx := context.Background()
 	storage := teststore.New()
 	testUser := model.TestUser(t)
-	_ = storage.User().SaveUser(testUser)
+	_ = storage.User().SaveUser(ctx, testUser)
 
 	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
 	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
@@ -174,3 +191,1048 @@ func TestGotchaAPIServer_signin(t *testing.T) {
 		})
 	}
 }
+
+// signIn authorizes the user and returns cookies of the created session
+func signIn(t *testing.T, srv *apiserver.GotchaAPIServer, user *model.User) []*http.Cookie {
+	t.Helper()
+
+	buf := bytes.Buffer{}
+	_ = json.NewEncoder(&buf).Encode(map[string]string{
+		"sobriquet": user.Username,
+		"password":  user.Password,
+	})
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, apiserver.ApiAuthorize.Path, &buf)
+	srv.Router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to sign in: %d", rec.Code)
+	}
+	return rec.Result().Cookies()
+}
+
+// authorizedRequest builds the request with session cookies attached
+func authorizedRequest(method, path string, payload any, cookies []*http.Cookie) *http.Request {
+	buf := bytes.Buffer{}
+	if payload != nil {
+		_ = json.NewEncoder(&buf).Encode(payload)
+	}
+
+	req, _ := http.NewRequest(method, path, &buf)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestGotchaAPIServer_notes(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	testUser := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, testUser)
+	rootBoard, _ := storage.Board().NewRootBoard(ctx, testUser, "Root")
+	nestedBoard, _ := storage.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", testUser)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	cookies := signIn(t, srv, testUser)
+
+	notesPath := apiserver.ApiBoardsPath + "/" + nestedBoard.Base.ID.String() + "/notes"
+
+	// Unauthorized
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, notesPath, nil, nil))
+	assert.Equal(t, rec.Code, http.StatusUnauthorized, "Notes listed without session")
+
+	// Create
+	rec = httptest.NewRecorder()
+	payload := map[string]any{"title": "Title", "content": "Content"}
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, notesPath, payload, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to create note")
+
+	note := model.Note{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&note), "Result not in Note format")
+	notePath := notesPath + "/" + strconv.Itoa(note.ID)
+
+	// Update
+	rec = httptest.NewRecorder()
+	payload = map[string]any{"title": "Updated", "content": "Content"}
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPut, notePath, payload, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to update note")
+
+	// Get
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, notePath, nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to get note")
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&note))
+	assert.Equal(t, note.Title, "Updated")
+
+	// Root boards have no notes
+	rec = httptest.NewRecorder()
+	rootNotesPath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/notes"
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, rootNotesPath, nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	// Delete
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodDelete, notePath, nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to delete note")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, notePath, nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusNotFound, "Note still exists")
+}
+
+func TestGotchaAPIServer_nestedBoards(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	testUser := model.TestUser(t)
+	anotherUser := model.TestUser(t)
+	anotherUser.Username += "a"
+	anotherUser.Email += "a"
+	_ = storage.User().SaveUser(ctx, testUser)
+	_ = storage.User().SaveUser(ctx, anotherUser)
+	rootBoard, _ := storage.Board().NewRootBoard(ctx, testUser, "Root")
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	cookies := signIn(t, srv, testUser)
+	anotherCookies := signIn(t, srv, anotherUser)
+
+	nestedPath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/nested"
+
+	// Create
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, nestedPath, map[string]string{"title": "Nested"}, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to create nested board")
+
+	nestedBoard := model.NestedBoard{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&nestedBoard), "Result not in NestedBoard format")
+	assert.Equal(t, nestedBoard.RootBoard, rootBoard.Base.ID)
+
+	// Guest without privileges
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, nestedPath, map[string]string{"title": "Nested"}, anotherCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Nested board created by stranger")
+
+	// List
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, nestedPath, nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to list nested boards")
+
+	boards := make([]model.NestedBoard, 0)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&boards))
+	assert.Len(t, boards, 1)
+
+	// Delete board, that isn't a child of the specified one
+	rec = httptest.NewRecorder()
+	foreignPath := nestedPath + "/" + rootBoard.Base.ID.String()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodDelete, foreignPath, nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusNotFound, "Deleted board which is not nested")
+
+	// Delete
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodDelete, nestedPath+"/"+nestedBoard.Base.ID.String(), nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to delete nested board")
+
+	leftBoards, _ := storage.Board().GetNestedBoards(ctx, rootBoard.Base.ID, testUser)
+	assert.Len(t, leftBoards, 0, "Nested board not deleted")
+}
+
+func TestGotchaAPIServer_boardTree(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	testUser := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, testUser)
+	rootBoard, _ := storage.Board().NewRootBoard(ctx, testUser, "Root")
+	nestedBoard, _ := storage.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", testUser)
+	_, _ = storage.Board().NewNestedBoard(ctx, nestedBoard.Base.ID, "Deep", testUser)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	cookies := signIn(t, srv, testUser)
+
+	treePath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/tree"
+
+	testCases := []struct {
+		caseName, query     string
+		expectedCode        int
+		expectedDeepestSize int
+	}{
+		{caseName: "Default depth", query: "", expectedCode: http.StatusOK, expectedDeepestSize: 1},
+		{caseName: "Cut tree", query: "?max_depth=1", expectedCode: http.StatusOK, expectedDeepestSize: 0},
+		{caseName: "Negative depth", query: "?max_depth=-1", expectedCode: http.StatusBadRequest},
+		{caseName: "Malformed depth", query: "?max_depth=abc", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.caseName, func(tc *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, treePath+testCase.query, nil, cookies))
+			assert.Equal(tc, rec.Code, testCase.expectedCode)
+			if testCase.expectedCode != http.StatusOK {
+				return
+			}
+
+			tree := model.BoardTree{}
+			assert.NoError(tc, json.NewDecoder(rec.Body).Decode(&tree), "Result not in BoardTree format")
+			assert.Len(tc, tree.Children, 1)
+			assert.Len(tc, tree.Children[0].Children, testCase.expectedDeepestSize)
+			assert.Equal(tc, tree.Children[0].ChildCount, 1)
+		})
+	}
+}
+
+// bearerRequest builds the request authorized with the API token
+func bearerRequest(method, path string, payload any, token string) *http.Request {
+	req := authorizedRequest(method, path, payload, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestGotchaAPIServer_tokens(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	testUser := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, testUser)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	cookies := signIn(t, srv, testUser)
+
+	newToken := func(scope string) model.APIToken {
+		rec := httptest.NewRecorder()
+		payload := map[string]string{"name": "cli " + scope, "scope": scope}
+		srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, apiserver.ApiNewToken.Path, payload, cookies))
+		assert.Equal(t, rec.Code, http.StatusOK, "Failed to create token")
+
+		token := model.APIToken{}
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&token), "Result not in APIToken format")
+		assert.NotEmpty(t, token.Token, "Token isn't shown on creation")
+		return token
+	}
+	rwToken, roToken := newToken("rw"), newToken("ro")
+	newRootPath := apiserver.ApiBoardsPath + apiserver.ApiNewRootBoard.Path
+	getBoardsPath := apiserver.ApiBoardsPath + apiserver.ApiGetBoards.Path
+
+	testCases := []struct {
+		caseName     string
+		request      *http.Request
+		expectedCode int
+	}{
+		{
+			caseName:     "Read with ro token",
+			request:      bearerRequest(http.MethodGet, getBoardsPath, nil, roToken.Token),
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName:     "Write with ro token",
+			request:      bearerRequest(http.MethodPost, newRootPath, map[string]string{"title": "Root"}, roToken.Token),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caseName:     "Write with rw token",
+			request:      bearerRequest(http.MethodPost, newRootPath, map[string]string{"title": "Root"}, rwToken.Token),
+			expectedCode: http.StatusOK,
+		},
+		{
+			caseName:     "Unknown token",
+			request:      bearerRequest(http.MethodGet, getBoardsPath, nil, "gotcha_unknown"),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			caseName:     "Malformed scope",
+			request:      authorizedRequest(http.MethodPost, apiserver.ApiNewToken.Path, map[string]string{"name": "a", "scope": "admin"}, cookies),
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.caseName, func(tc *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.Router.ServeHTTP(rec, testCase.request)
+			assert.Equal(tc, rec.Code, testCase.expectedCode)
+		})
+	}
+
+	// Tokens are listed without secrets
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, apiserver.ApiGetTokens.Path, nil, cookies))
+	tokens := make([]model.APIToken, 0)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&tokens))
+	assert.Len(t, tokens, 2)
+	assert.Empty(t, tokens[0].Token, "Token secret is listed")
+
+	// Revoked token can't be used anymore
+	rec = httptest.NewRecorder()
+	revokePath := apiserver.ApiGetTokens.Path + "/" + rwToken.ID.String()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodDelete, revokePath, nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to revoke token")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, bearerRequest(http.MethodGet, getBoardsPath, nil, rwToken.Token))
+	assert.Equal(t, rec.Code, http.StatusUnauthorized, "Revoked token is accepted")
+}
+
+func TestGotchaAPIServer_signout(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	testUser := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, testUser)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	getBoardsPath := apiserver.ApiBoardsPath + apiserver.ApiGetBoards.Path
+
+	// Sign out expires the cookie of the current session
+	cookies := signIn(t, srv, testUser)
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, apiserver.ApiSignout.Path, nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to sign out")
+
+	expiredCookies := rec.Result().Cookies()
+	assert.Len(t, expiredCookies, 1)
+	assert.True(t, expiredCookies[0].MaxAge < 0, "Session cookie isn't expired")
+
+	// Sign out everywhere invalidates all issued sessions
+	firstSession := signIn(t, srv, testUser)
+	secondSession := signIn(t, srv, testUser)
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, apiserver.ApiSignoutEverywhere.Path, nil, firstSession))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to sign out everywhere")
+
+	for _, session := range [][]*http.Cookie{firstSession, secondSession} {
+		rec = httptest.NewRecorder()
+		srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, getBoardsPath, nil, session))
+		assert.Equal(t, rec.Code, http.StatusUnauthorized, "Revoked session is accepted")
+	}
+
+	// New sessions are still welcome
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, getBoardsPath, nil, signIn(t, srv, testUser)))
+	assert.Equal(t, rec.Code, http.StatusOK, "Session issued after revocation is rejected")
+}
+
+func TestGotchaAPIServer_collaborators(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	author := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, author)
+	collaborator := model.TestUser(t)
+	collaborator.Username += "collaborator"
+	collaborator.Email += "collaborator"
+	_ = storage.User().SaveUser(ctx, collaborator)
+	stranger := model.TestUser(t)
+	stranger.Username += "stranger"
+	stranger.Email += "stranger"
+	_ = storage.User().SaveUser(ctx, stranger)
+	internalStorage.TestColleagues(t, storage, author, collaborator)
+	rootBoard, _ := storage.Board().NewRootBoard(ctx, author, "Root")
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	authorCookies := signIn(t, srv, author)
+	collaboratorCookies := signIn(t, srv, collaborator)
+
+	collaboratorsPath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/collaborators"
+	collaboratorPath := collaboratorsPath + "/" + collaborator.ID.String()
+
+	// Grant rw access, the same user can't be granted twice
+	permitPath := apiserver.ApiBoardsPath + apiserver.ApiPermitBoard.Path
+	payload := map[string]any{
+		"description": "Friend",
+		"board_id":    rootBoard.Base.ID,
+		"user_id":     collaborator.ID,
+		"permission":  "rw",
+	}
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, permitPath, payload, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to permit board")
+
+	// Personal boards aren't shared with users outside of the author workspaces
+	strangerPayload := map[string]any{
+		"description": "Stranger",
+		"board_id":    rootBoard.Base.ID,
+		"user_id":     stranger.ID,
+		"permission":  "ro",
+	}
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, permitPath, strangerPayload, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Board shared with stranger")
+	t.Log(rec.Code, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, permitPath, payload, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusConflict, "Duplicate relation created")
+
+	// List
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, collaboratorsPath, nil, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to list collaborators")
+
+	var collaborators []map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&collaborators))
+	assert.Len(t, collaborators, 2)
+	assert.Equal(t, collaborators[1]["username"], collaborator.Username)
+	assert.Equal(t, collaborators[1]["privilege"], "rw")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, collaboratorsPath, nil, collaboratorCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Collaborator listed collaborators")
+
+	// Change privilege
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPut, collaboratorPath, map[string]string{"permission": "author"}, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusBadRequest, "Author privilege granted")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPut, collaboratorPath, map[string]string{"permission": "ro"}, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to change privilege")
+
+	rec = httptest.NewRecorder()
+	nestedPath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/nested"
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, nestedPath, map[string]string{"title": "Nested"}, collaboratorCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Read-only collaborator created nested board")
+
+	// Revoke
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodDelete, collaboratorPath, nil, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to revoke access")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, nestedPath, nil, collaboratorCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Revoked collaborator has access")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodDelete, collaboratorPath, nil, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestGotchaAPIServer_transferBoard(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	author := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, author)
+	collaborator := model.TestUser(t)
+	collaborator.Username += "collaborator"
+	collaborator.Email += "collaborator"
+	_ = storage.User().SaveUser(ctx, collaborator)
+	internalStorage.TestColleagues(t, storage, author, collaborator)
+	rootBoard, _ := storage.Board().NewRootBoard(ctx, author, "Root")
+	_, _ = storage.Board().CreateRelation(ctx, rootBoard.Base.ID, collaborator.ID, "Friend", model.PrivilegeReadWrite)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	authorCookies := signIn(t, srv, author)
+	collaboratorCookies := signIn(t, srv, collaborator)
+
+	transferPath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/transfer"
+	payload := map[string]any{"user_id": author.ID, "keep_access": true}
+
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, transferPath, payload, collaboratorCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Collaborator took authorship")
+
+	rec = httptest.NewRecorder()
+	payload = map[string]any{"user_id": collaborator.ID}
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, transferPath, payload, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to transfer board")
+
+	transfer := model.BoardTransfer{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&transfer), "Result not in BoardTransfer format")
+	assert.Equal(t, transfer.ToUserID, collaborator.ID)
+	assert.False(t, transfer.KeepAccess)
+
+	// Previous author left the board
+	rec = httptest.NewRecorder()
+	nestedPath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/nested"
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, nestedPath, nil, authorCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Previous author still has access")
+
+	rec = httptest.NewRecorder()
+	collaboratorsPath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/collaborators"
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, collaboratorsPath, nil, collaboratorCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "New author can't manage collaborators")
+}
+
+func TestGotchaAPIServer_shareLinks(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	author := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, author)
+	rootBoard, _ := storage.Board().NewRootBoard(ctx, author, "Root")
+	sharedBoard, _ := storage.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Shared", author)
+	siblingBoard, _ := storage.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Sibling", author)
+	_ = storage.Note().NewNote(ctx, sharedBoard.Base.ID, &model.Note{Title: "Shared note"}, author)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	hookedLogger, logHook := logrustest.NewNullLogger()
+	srv := apiserver.NewAPIServer(hookedLogger, cfg, storage, sessionStore)
+	cookies := signIn(t, srv, author)
+
+	sharesPath := apiserver.ApiBoardsPath + "/" + sharedBoard.Base.ID.String() + "/shares"
+
+	// Create
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, sharesPath, map[string]any{}, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to create share link")
+
+	link := model.ShareLink{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&link), "Result not in ShareLink format")
+	assert.NotEmpty(t, link.Token)
+	sharedPath := apiserver.ApiRootPath + "/shared/" + link.Token
+
+	// Shared reads don't need a session
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, sharedPath, nil, nil))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to get shared tree")
+
+	tree := model.BoardTree{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&tree))
+	assert.Equal(t, tree.Base.ID, sharedBoard.Base.ID)
+
+	rec = httptest.NewRecorder()
+	notesPath := sharedPath + "/boards/" + sharedBoard.Base.ID.String() + "/notes"
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, notesPath, nil, nil))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to get shared notes")
+
+	// Link token is a secret, it's never logged
+	for _, entry := range logHook.AllEntries() {
+		line, _ := entry.String()
+		assert.NotContains(t, line, link.Token, "Share link token is logged")
+	}
+	assert.Equal(t, apiserver.ApiRootPath+"/shared/{token}/boards/"+sharedBoard.Base.ID.String()+"/notes", logHook.LastEntry().Data["Path"])
+
+	// Scoped to the shared tree and read-only
+	rec = httptest.NewRecorder()
+	siblingNotesPath := sharedPath + "/boards/" + siblingBoard.Base.ID.String() + "/notes"
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, siblingNotesPath, nil, nil))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Board outside the shared tree is visible")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, notesPath, map[string]any{"title": "Spam"}, nil))
+	assert.Equal(t, rec.Code, http.StatusMethodNotAllowed, "Shared link allows writes")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, apiserver.ApiRootPath+"/shared/unknown", nil, nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	// Revoke
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodDelete, sharesPath+"/"+link.ID.String(), nil, cookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to revoke share link")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, sharedPath, nil, nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound, "Revoked link still works")
+}
+
+func TestGotchaAPIServer_teams(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	owner := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, owner)
+	member := model.TestUser(t)
+	member.Username += "member"
+	member.Email += "member"
+	_ = storage.User().SaveUser(ctx, member)
+	internalStorage.TestColleagues(t, storage, owner, member)
+	rootBoard, _ := storage.Board().NewRootBoard(ctx, owner, "Root")
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	ownerCookies := signIn(t, srv, owner)
+	memberCookies := signIn(t, srv, member)
+
+	// Create
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, apiserver.ApiNewTeam.Path, map[string]string{"title": "Backend"}, ownerCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to create team")
+
+	team := model.Team{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&team), "Result not in Team format")
+	membersPath := apiserver.ApiRootPath + "/teams/" + team.ID.String() + "/members"
+
+	// Members
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, membersPath, map[string]any{"user_id": member.ID}, memberCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Stranger joined the team")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, membersPath, map[string]any{"user_id": member.ID}, ownerCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to add member")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, membersPath, nil, memberCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to list members")
+
+	var members []model.TeamMember
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&members))
+	assert.Len(t, members, 2)
+
+	// Board access via team
+	teamsPath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/teams"
+	nestedPath := apiserver.ApiBoardsPath + "/" + rootBoard.Base.ID.String() + "/nested"
+	payload := map[string]any{"team_id": team.ID, "permission": "ro"}
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, teamsPath, payload, memberCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Member granted access to foreign board")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, teamsPath, payload, ownerCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to grant team")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, nestedPath, nil, memberCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Team member has no access")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, nestedPath, map[string]string{"title": "Nested"}, memberCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Read-only team member created nested board")
+
+	// Revoke
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodDelete, teamsPath+"/"+team.ID.String(), nil, ownerCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to revoke team")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, nestedPath, nil, memberCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Revoked team member has access")
+
+	// Leave
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodDelete, membersPath+"/"+member.ID.String(), nil, memberCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to leave the team")
+}
+
+func TestGotchaAPIServer_workspaces(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	admin := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, admin)
+	member := model.TestUser(t)
+	member.Username += "member"
+	member.Email += "member"
+	_ = storage.User().SaveUser(ctx, member)
+	stranger := model.TestUser(t)
+	stranger.Username += "stranger"
+	stranger.Email += "stranger"
+	_ = storage.User().SaveUser(ctx, stranger)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	adminCookies := signIn(t, srv, admin)
+	strangerCookies := signIn(t, srv, stranger)
+
+	// Create
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, apiserver.ApiNewWorkspace.Path, map[string]string{"title": "Acme"}, adminCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to create workspace")
+
+	workspace := model.Workspace{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&workspace), "Result not in Workspace format")
+	membersPath := apiserver.ApiRootPath + "/workspaces/" + workspace.ID.String() + "/members"
+
+	// Members
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, membersPath, map[string]any{"user_id": member.ID, "role": "owner"}, adminCookies))
+	assert.Equal(t, rec.Code, http.StatusBadRequest, "Unknown role accepted")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, membersPath, map[string]any{"user_id": member.ID, "role": "member"}, adminCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to add member")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, membersPath, nil, strangerCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Stranger listed members")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPut, membersPath+"/"+admin.ID.String(), map[string]string{"role": "member"}, adminCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Last admin demoted")
+
+	// User listing is scoped to workspaces
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, apiserver.ApiListUsers.Path, nil, adminCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to list users")
+
+	users := model.Page[model.User]{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&users))
+	assert.Len(t, users.Items, 1)
+	assert.Equal(t, users.Items[0].ID, member.ID)
+
+	// Boards inside workspace
+	rootPath := apiserver.ApiBoardsPath + apiserver.ApiNewRootBoard.Path
+	payload := map[string]any{"title": "Roadmap", "workspace_id": workspace.ID}
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, rootPath, payload, strangerCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Stranger created workspace board")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, rootPath, payload, adminCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to create workspace board")
+
+	board := model.Board{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&board))
+	assert.Equal(t, board.WorkspaceID, workspace.ID)
+
+	rec = httptest.NewRecorder()
+	permitPath := apiserver.ApiBoardsPath + apiserver.ApiPermitBoard.Path
+	permit := map[string]any{"description": "Friend", "board_id": board.Base.ID, "user_id": stranger.ID, "permission": "ro"}
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodPost, permitPath, permit, adminCookies))
+	assert.Equal(t, rec.Code, http.StatusForbidden, "Board shared outside of workspace")
+	t.Log(rec.Code, rec.Body.String())
+}
+
+func TestGotchaAPIServer_boardPages(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	testUser := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, testUser)
+	for _, title := range []string{"Alpha", "Beta", "Gamma"} {
+		_, _ = storage.Board().NewRootBoard(ctx, testUser, title)
+	}
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	cookies := signIn(t, srv, testUser)
+	getBoardsPath := apiserver.ApiBoardsPath + apiserver.ApiGetBoards.Path
+
+	getPage := func(query string) model.Page[model.Board] {
+		rec := httptest.NewRecorder()
+		srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, getBoardsPath+query, nil, cookies))
+		assert.Equal(t, rec.Code, http.StatusOK, "Failed to get boards")
+
+		page := model.Page[model.Board]{}
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&page), "Result not in Page format")
+		return page
+	}
+
+	page := getPage("?limit=2&sort=title&order=desc")
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "Gamma", page.Items[0].Base.Title)
+	assert.Equal(t, model.PrivilegeAuthor, page.Items[0].Privilege)
+	assert.NotEmpty(t, page.NextCursor)
+
+	page = getPage("?limit=2&sort=title&order=desc&cursor=" + page.NextCursor)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, "Alpha", page.Items[0].Base.Title)
+	assert.Empty(t, page.NextCursor, "Cursor on the last page")
+
+	page = getPage("?title=et&privilege=author")
+	assert.Len(t, page.Items, 1)
+
+	// Incorrect parameters
+	for _, query := range []string{"?limit=0", "?sort=id", "?order=up", "?cursor=garbage", "?privilege=admin"} {
+		rec := httptest.NewRecorder()
+		srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, getBoardsPath+query, nil, cookies))
+		assert.Equal(t, rec.Code, http.StatusBadRequest, "Accepted %s", query)
+	}
+}
+
+func TestGotchaAPIServer_search(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	owner := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, owner)
+	stranger := model.TestUser(t)
+	stranger.Username += "stranger"
+	stranger.Email += "stranger"
+	_ = storage.User().SaveUser(ctx, stranger)
+	rootBoard, _ := storage.Board().NewRootBoard(ctx, owner, "Roadmap")
+	nestedBoard, _ := storage.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Planning", owner)
+	_ = storage.Note().NewNote(ctx, nestedBoard.Base.ID, &model.Note{Title: "Release", Content: "Ship the roadmap"}, owner)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	ownerCookies := signIn(t, srv, owner)
+	strangerCookies := signIn(t, srv, stranger)
+
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, apiserver.ApiSearch.Path, nil, ownerCookies))
+	assert.Equal(t, rec.Code, http.StatusBadRequest, "Empty query accepted")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, apiserver.ApiSearch.Path+"?q=roadmap&limit=1000", nil, ownerCookies))
+	assert.Equal(t, rec.Code, http.StatusBadRequest, "Limit isn't validated")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, apiserver.ApiSearch.Path+"?q=roadmap", nil, ownerCookies))
+	assert.Equal(t, rec.Code, http.StatusOK, "Failed to search")
+
+	var results []model.SearchResult
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&results), "Result not in SearchResult format")
+	assert.Len(t, results, 2)
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, apiserver.ApiSearch.Path+"?q=roadmap", nil, strangerCookies))
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	results = nil
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&results))
+	assert.Empty(t, results, "Stranger found foreign boards")
+}
+
+func TestGotchaAPIServer_errors(t *testing.T) {
+	ctx := context.Background()
+	storage := teststore.New()
+	user := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, user)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	cookies := signIn(t, srv, user)
+	missingBoardPath := apiserver.ApiBoardsPath + "/" + uuid.NewString() + "/nested"
+
+	testCases := []struct {
+		caseName     string
+		request      *http.Request
+		expectedCode int
+		errorCode    apiserver.ErrorCode
+		detailsOf    string
+	}{
+		{
+			caseName:     "Malformed body",
+			request:      httptest.NewRequest(http.MethodPost, apiserver.ApiSignup.Path, bytes.NewBufferString("{")),
+			expectedCode: http.StatusBadRequest,
+			errorCode:    apiserver.CodeMalformedBody,
+		},
+		{
+			caseName:     "Invalid user",
+			request:      authorizedRequest(http.MethodPost, apiserver.ApiSignup.Path, map[string]string{"email": "abc", "username": "test", "password": "password"}, nil),
+			expectedCode: http.StatusUnprocessableEntity,
+			errorCode:    apiserver.CodeValidationFailed,
+			detailsOf:    "email",
+		},
+		{
+			caseName:     "Missing required field",
+			request:      authorizedRequest(http.MethodPost, apiserver.ApiNewTeam.Path, map[string]string{}, cookies),
+			expectedCode: http.StatusBadRequest,
+			errorCode:    apiserver.CodeValidationFailed,
+			detailsOf:    "title",
+		},
+		{
+			caseName: "Identifier with zero bytes",
+			request: authorizedRequest(http.MethodPost, apiserver.ApiBoardsPath+apiserver.ApiPermitBoard.Path, map[string]string{
+				"description": "Friend",
+				"board_id":    "00000000-0000-4000-8000-000000000001",
+				"user_id":     user.ID.String(),
+				"permission":  "ro",
+			}, cookies),
+			expectedCode: http.StatusBadRequest,
+			errorCode:    apiserver.CodeBadRequest,
+		},
+		{
+			caseName:     "Wrong password",
+			request:      authorizedRequest(http.MethodPost, apiserver.ApiAuthorize.Path, map[string]string{"sobriquet": user.Username, "password": "wrong"}, nil),
+			expectedCode: http.StatusUnauthorized,
+			errorCode:    apiserver.CodeInvalidCredentials,
+		},
+		{
+			caseName:     "No session",
+			request:      authorizedRequest(http.MethodGet, apiserver.ApiGetTeams.Path, nil, nil),
+			expectedCode: http.StatusUnauthorized,
+			errorCode:    apiserver.CodeUnauthorized,
+		},
+		{
+			caseName:     "Missing board",
+			request:      authorizedRequest(http.MethodGet, missingBoardPath, nil, cookies),
+			expectedCode: http.StatusNotFound,
+			errorCode:    apiserver.CodeNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.caseName, func(tc *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.Router.ServeHTTP(rec, testCase.request)
+			assert.Equal(tc, testCase.expectedCode, rec.Code)
+
+			response := struct {
+				Error apiserver.APIError `json:"error"`
+			}{}
+			assert.NoError(tc, json.NewDecoder(rec.Body).Decode(&response), "Result not in APIError format")
+			assert.Equal(tc, testCase.errorCode, response.Error.Code)
+			assert.NotEmpty(tc, response.Error.Message)
+			assert.Equal(tc, rec.Header().Get("Request-ID"), response.Error.RequestID)
+			if testCase.detailsOf != "" {
+				assert.Contains(tc, response.Error.Details, testCase.detailsOf)
+			}
+		})
+	}
+}
+
+func TestGotchaAPIServer_openAPI(t *testing.T) {
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, teststore.New(), sessionStore)
+
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiserver.ApiOpenAPI.Path, nil))
+	assert.Equal(t, rec.Code, http.StatusOK, "Specification isn't served")
+
+	spec := struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&spec), "Specification isn't JSON")
+	assert.NotEmpty(t, spec.OpenAPI)
+	assert.Contains(t, spec.Components.Schemas, "RegisterRequest")
+	assert.Contains(t, spec.Components.Schemas, "APIError")
+
+	// Every route of the current version must be documented. Older versions and the legacy alias
+	// aren't described. Patterns of mux variables aren't part of OpenAPI paths
+	variablePattern := regexp.MustCompile(`\{(\w+):[^}]*}`)
+	err := srv.Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, apiserver.ApiRootPath+"/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // subrouters are matched by prefix only
+		}
+
+		path := variablePattern.ReplaceAllString(template, "{$1}")
+		for _, method := range methods {
+			assert.Contains(t, spec.Paths[path], strings.ToLower(method), "Route %s %s is missing from the specification", method, template)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestGotchaAPIServer_versions(t *testing.T) {
+	legacyCfg := *cfg
+	legacyCfg.LegacyApiSunset = "2027-04-17"
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, &legacyCfg, teststore.New(), sessionStore)
+
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiserver.ApiHeartbeat.Path, nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, apiserver.ApiV1.Root()+"/heartbeat", apiserver.ApiHeartbeat.Path, "Routes aren't versioned")
+	assert.Empty(t, rec.Header().Get("Deprecation"), "Current version is deprecated")
+
+	// Unversioned alias
+	legacyPath := apiserver.ApiBasePath + "/heartbeat"
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, legacyPath, nil))
+	assert.Equal(t, rec.Code, http.StatusOK, "Legacy route isn't served")
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+	assert.Equal(t, "Sat, 17 Apr 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+	assert.Contains(t, rec.Header().Get("Link"), apiserver.ApiLegacyVersion.Root()+"/heartbeat")
+
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiserver.ApiBasePath+"/boards/all", nil))
+	assert.Equal(t, rec.Code, http.StatusUnauthorized, "Legacy board routes aren't authorized")
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+}
+
+func TestGotchaAPIServer_tracing(t *testing.T) {
+	ctx := context.Background()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	}()
+
+	storage := teststore.New()
+	testUser := model.TestUser(t)
+	_ = storage.User().SaveUser(ctx, testUser)
+	rootBoard, _ := storage.Board().NewRootBoard(ctx, testUser, "Root")
+	nestedBoard, _ := storage.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", testUser)
+
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, storage, sessionStore)
+	cookies := signIn(t, srv, testUser)
+
+	// Trace of the caller is continued
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	notesPath := apiserver.ApiBoardsPath + "/" + nestedBoard.Base.ID.String() + "/notes"
+	req := authorizedRequest(http.MethodGet, notesPath, nil, cookies)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Contains(t, rec.Header().Get("traceparent"), traceID, "Trace context isn't injected")
+
+	var handlerSpan sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			handlerSpan = span
+		}
+	}
+	if !assert.NotNil(t, handlerSpan, "Trace isn't continued") {
+		return
+	}
+	assert.Equal(t, http.MethodGet+" "+apiserver.ApiBoardsPath+"/{board_id}/notes", handlerSpan.Name())
+	assert.Contains(t, handlerSpan.Attributes(), tracing.UserID(testUser.ID))
+	assert.Contains(t, handlerSpan.Attributes(), tracing.BoardID(nestedBoard.Base.ID))
+	assert.Contains(t, handlerSpan.Attributes(), semconv.HTTPStatusCode(http.StatusOK))
+
+	// Share link token is redacted
+	link, _ := model.NewShareLink(rootBoard.Base.ID, testUser.ID, nil)
+	_ = storage.Share().NewShareLink(ctx, link, testUser)
+	spanCount := len(recorder.Ended())
+	rec = httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, authorizedRequest(http.MethodGet, apiserver.ApiRootPath+"/shared/"+link.Token, nil, nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	spans := recorder.Ended()[spanCount:]
+	assert.NotEmpty(t, spans)
+	for _, span := range spans {
+		assert.NotContains(t, span.Name(), link.Token)
+		for _, attr := range span.Attributes() {
+			assert.NotContains(t, attr.Value.Emit(), link.Token, "Share link token is traced in %s", attr.Key)
+		}
+	}
+	assert.Contains(t, spans[len(spans)-1].Attributes(), semconv.HTTPTarget(apiserver.ApiRootPath+"/shared/{token}"))
+}
+
+func TestGotchaAPIServer_health(t *testing.T) {
+	sessionStore := sessions.NewCookieStore([]byte("TestKey"))
+	srv := apiserver.NewAPIServer(logger, cfg, teststore.New(), sessionStore)
+
+	probe := func(path string) (int, apiserver.HealthReport) {
+		rec := httptest.NewRecorder()
+		srv.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		report := apiserver.HealthReport{}
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&report), "Result not in HealthReport format")
+		return rec.Code, report
+	}
+
+	code, report := probe(apiserver.ApiHealthLive.Path)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, apiserver.HealthUp, report.Status)
+	assert.Equal(t, "/api/health/live", apiserver.ApiHealthLive.Path, "Probes are versioned")
+
+	srv.AddHealthCheck("postgres", func(ctx context.Context) error { return nil })
+	code, report = probe(apiserver.ApiHealthReady.Path)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, apiserver.HealthUp, report.Status)
+	assert.Equal(t, apiserver.HealthUp, report.Dependencies["postgres"].Status)
+
+	// Dependency, that doesn't respond in time
+	srv.AddHealthCheck("redis", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	code, report = probe(apiserver.ApiHealthReady.Path)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, apiserver.HealthDown, report.Status)
+	assert.Equal(t, apiserver.HealthUp, report.Dependencies["postgres"].Status)
+	assert.Equal(t, apiserver.HealthDown, report.Dependencies["redis"].Status)
+	assert.Equal(t, "timeout", report.Dependencies["redis"].Error)
+
+	// Failed readiness isn't an error of the server
+	code, report = probe(apiserver.ApiHealthLive.Path)
+	assert.Equal(t, http.StatusOK, code, "Liveness depends on dependencies")
+	assert.Equal(t, 0.0, report.ErrorRate)
+}
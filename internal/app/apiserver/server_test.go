@@ -0,0 +1,37 @@
+package apiserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 1; attempt < 100; attempt++ {
+		delay := retryDelay(attempt)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.Less(t, delay, connectMaxRetryDelay, "Delay isn't capped")
+		if attempt == 1 {
+			assert.Less(t, delay, connectRetryDelay)
+		}
+	}
+}
+
+func TestOpenDB(t *testing.T) {
+	// Nothing listens on the port
+	dbc := &DatabaseConfiguration{DBHost: "127.0.0.1", DBPort: 1, SSLMode: "disable", Attempts: 2}
+
+	startTime := time.Now()
+	_, err := OpenDB(context.Background(), dbc, logrus.New())
+	assert.ErrorContains(t, err, "after 2 attempts", "Unreachable database opened")
+	assert.Less(t, time.Since(startTime), connectRetryDelay+time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dbc.Attempts = 5
+	_, err = OpenDB(ctx, dbc, logrus.New())
+	assert.ErrorIs(t, err, context.Canceled, "Start isn't interrupted")
+}
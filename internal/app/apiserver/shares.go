@@ -0,0 +1,175 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	errShareLinkExpired   = errors.New("share link expired")
+	errIncorrectShareLink = errors.New("incorrect share link")
+)
+
+type newShareLinkRequest struct {
+	ExpiresAt *time.Time `json:"expires_at" valid:"-"`
+}
+
+func (srv *GotchaAPIServer) newShareLinkHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := newShareLinkRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		link, err := model.NewShareLink(boardID, user.ID, req.ExpiresAt)
+		if err != nil {
+			srv.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+		if err := srv.storage.Share().NewShareLink(request.Context(), link, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+
+		// The only time the link token is shown
+		srv.respond(writer, request, http.StatusOK, link)
+	}
+}
+
+func (srv *GotchaAPIServer) getShareLinksHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		links, err := srv.storage.Share().GetShareLinks(request.Context(), boardID, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, links)
+	}
+}
+
+func (srv *GotchaAPIServer) revokeShareLinkHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		linkID, err := uuid.Parse(mux.Vars(request)["share_id"])
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, errIncorrectShareLink)
+			return
+		}
+
+		if err := srv.storage.Share().RevokeShareLink(request.Context(), boardID, linkID, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+// sharedLink finds the link by the {token} variable of the route and checks that it's not expired.
+// Responds with the error itself, so handlers just return if link is nil.
+func (srv *GotchaAPIServer) sharedLink(writer http.ResponseWriter, request *http.Request) *model.ShareLink {
+	link, err := srv.storage.Share().FindShareLinkByHash(request.Context(), model.HashToken(mux.Vars(request)["token"]))
+	if err != nil {
+		srv.storageError(writer, request, err)
+		return nil
+	}
+	if link.IsExpired() {
+		srv.error(writer, request, http.StatusGone, errShareLinkExpired)
+		return nil
+	}
+	return link
+}
+
+// getSharedTreeHandler returns the hierarchy under the shared board. No authorization required
+func (srv *GotchaAPIServer) getSharedTreeHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		maxDepth, err := treeDepthFromQuery(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		link := srv.sharedLink(writer, request)
+		if link == nil {
+			return
+		}
+
+		tree, err := srv.storage.Share().GetSharedTree(request.Context(), link, maxDepth)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, tree)
+	}
+}
+
+// getSharedNotesHandler returns notes of the board from the shared tree. No authorization required
+func (srv *GotchaAPIServer) getSharedNotesHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		link := srv.sharedLink(writer, request)
+		if link == nil {
+			return
+		}
+
+		notes, err := srv.storage.Share().GetSharedNotes(request.Context(), link, boardID)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, notes)
+	}
+}
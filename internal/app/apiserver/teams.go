@@ -0,0 +1,239 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"Gotcha/internal/app/model"
+	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var errIncorrectTeam = errors.New("incorrect team")
+
+// teamIDFromPath extracts {team_id} variable of the route
+func teamIDFromPath(request *http.Request) (uuid.UUID, error) {
+	teamID, err := uuid.Parse(mux.Vars(request)["team_id"])
+	if err != nil {
+		return uuid.Nil, errIncorrectTeam
+	}
+	return teamID, nil
+}
+
+type newTeamRequest struct {
+	Title string `json:"title" valid:"required"`
+}
+
+func (srv *GotchaAPIServer) newTeamHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := newTeamRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		team := model.Team{Title: req.Title}
+		if err := srv.storage.Team().NewTeam(request.Context(), &team, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, team)
+	}
+}
+
+func (srv *GotchaAPIServer) getTeamsHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		teams, err := srv.storage.Team().GetTeamsOfUser(request.Context(), &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, teams)
+	}
+}
+
+func (srv *GotchaAPIServer) getTeamMembersHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		teamID, err := teamIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		members, err := srv.storage.Team().GetTeamMembers(request.Context(), teamID, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, members)
+	}
+}
+
+type addTeamMemberRequest struct {
+	UserID uuid.UUID `json:"user_id" valid:"required_uuid"`
+}
+
+// addTeamMemberHandler adds the user to the team. Only the owner of the team is allowed to.
+func (srv *GotchaAPIServer) addTeamMemberHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := addTeamMemberRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		teamID, err := teamIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := srv.storage.Team().AddTeamMember(request.Context(), teamID, req.UserID, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+// removeTeamMemberHandler removes the member from the team. Owner removes anyone but himself,
+// members may only leave the team.
+func (srv *GotchaAPIServer) removeTeamMemberHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		teamID, err := teamIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		memberID, err := collaboratorIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := srv.storage.Team().RemoveTeamMember(request.Context(), teamID, memberID, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+type grantTeamRequest struct {
+	TeamID      uuid.UUID `json:"team_id"     valid:"required_uuid"`
+	Permission  string    `json:"permission"  valid:"required"`
+	Description string    `json:"description" valid:"optional"`
+}
+
+// grantTeamHandler gives every member of the team access to the root board
+func (srv *GotchaAPIServer) grantTeamHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := grantTeamRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		privilege, err := model.ParseGrantablePrivilege(req.Permission)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		relationID, err := srv.storage.Team().GrantTeam(request.Context(), boardID, req.TeamID, req.Description, privilege, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, map[string]uuid.UUID{"relation_id": relationID})
+	}
+}
+
+func (srv *GotchaAPIServer) revokeTeamHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		boardID, err := boardIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		teamID, err := teamIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := srv.storage.Team().RevokeTeam(request.Context(), boardID, teamID, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
@@ -0,0 +1,162 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"Gotcha/internal/app/logging"
+)
+
+const (
+	TLSCipherPolicyDefault = "default" // Go defaults
+	TLSCipherPolicyStrict  = "strict"  // forward secrecy and AEAD ciphers only
+
+	certificateCheckInterval = 10 * time.Second
+)
+
+var (
+	tlsVersions = map[string]uint16{
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+
+	// strictCipherSuites apply to TLS 1.2 only, suites of TLS 1.3 aren't configurable
+	strictCipherSuites = []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	}
+)
+
+// newTLSConfig builds the server TLS config, that takes certificates from the reloader
+// and negotiates HTTP/2
+func newTLSConfig(cfg *TLSConfiguration, reloader *certificateReloader) (*tls.Config, error) {
+	minVersion, found := tlsVersions[cfg.MinVersion]
+	if !found {
+		return nil, fmt.Errorf("unsupported minimal TLS version %q", cfg.MinVersion)
+	}
+
+	config := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	switch cfg.CipherPolicy {
+	case TLSCipherPolicyDefault, "":
+	case TLSCipherPolicyStrict:
+		config.CipherSuites = strictCipherSuites
+	default:
+		return nil, fmt.Errorf("unknown TLS cipher policy %q", cfg.CipherPolicy)
+	}
+	return config, nil
+}
+
+// certificateReloader serves the certificate and reloads it, when its files change
+type certificateReloader struct {
+	certFile, keyFile string
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	modTime     time.Time
+}
+
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	reloader := &certificateReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := reloader.reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.certificate, nil
+}
+
+// reload loads the key pair, if any of its files is changed since the last load. The pair,
+// that fails to load, doesn't replace the current certificate
+func (r *certificateReloader) reload() (bool, error) {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	changed := !modTime.Equal(r.modTime)
+	r.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.certificate = &certificate
+	r.modTime = modTime
+	r.mu.Unlock()
+	return true, nil
+}
+
+// lastModified is the latest modification time of the certificate and key files
+func (r *certificateReloader) lastModified() (time.Time, error) {
+	var modTime time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("load TLS certificate: %w", err)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
+
+// watch checks files of the certificate every interval until ctx is done
+func (r *certificateReloader) watch(ctx context.Context, interval time.Duration, logger logging.GotchaLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.reload()
+			if err != nil {
+				logger.Errorf("Keep serving the previous certificate: %v", err)
+			} else if reloaded {
+				logger.Printf("Reloaded TLS certificate %s", r.certFile)
+			}
+		}
+	}
+}
+
+// redirectToHTTPS responds with the permanent redirect to the same URL on the HTTPS port.
+// Method and body of the request are preserved by the client
+func redirectToHTTPS(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		host := request.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), fmt.Sprint(httpsPort))
+		}
+		http.Redirect(writer, request, "https://"+host+request.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
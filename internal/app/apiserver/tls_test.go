@@ -0,0 +1,148 @@
+package apiserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCertificate writes the self-signed certificate of localhost and its key into dir
+func writeCertificate(t *testing.T, dir string, serial int64) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	cert, _ = x509.ParseCertificate(der)
+	return certFile, keyFile, cert
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile, _ := writeCertificate(t, t.TempDir(), 1)
+	reloader, err := newCertificateReloader(certFile, keyFile)
+	assert.NoError(t, err)
+
+	config, err := newTLSConfig(&TLSConfiguration{MinVersion: "1.3", CipherPolicy: TLSCipherPolicyStrict}, reloader)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	assert.Equal(t, strictCipherSuites, config.CipherSuites)
+	assert.Contains(t, config.NextProtos, "h2")
+
+	_, err = newTLSConfig(&TLSConfiguration{MinVersion: "1.0"}, reloader)
+	assert.Error(t, err, "Obsolete TLS version accepted")
+	_, err = newTLSConfig(&TLSConfiguration{MinVersion: "1.2", CipherPolicy: "weak"}, reloader)
+	assert.Error(t, err, "Unknown cipher policy accepted")
+
+	_, err = newCertificateReloader(filepath.Join(t.TempDir(), "missing.crt"), keyFile)
+	assert.Error(t, err, "Missing certificate loaded")
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, firstCert := writeCertificate(t, dir, 1)
+	reloader, err := newCertificateReloader(certFile, keyFile)
+	assert.NoError(t, err)
+	config, _ := newTLSConfig(&TLSConfiguration{MinVersion: "1.2"}, reloader)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = config
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	request := func(cert *x509.Certificate) *http.Response {
+		roots := x509.NewCertPool()
+		roots.AddCert(cert)
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, ServerName: "localhost"},
+			ForceAttemptHTTP2: true,
+		}}
+		response, err := client.Get(server.URL)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		_ = response.Body.Close()
+		return response
+	}
+
+	if response := request(firstCert); response != nil {
+		assert.Equal(t, "HTTP/2.0", response.Proto, "HTTP/2 isn't negotiated")
+		assert.Equal(t, firstCert.SerialNumber, response.TLS.PeerCertificates[0].SerialNumber)
+	}
+
+	// Broken pair doesn't replace the served certificate
+	_ = os.WriteFile(keyFile, []byte("broken"), 0600)
+	_ = os.Chtimes(keyFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	reloaded, err := reloader.reload()
+	assert.Error(t, err)
+	assert.False(t, reloaded)
+	request(firstCert)
+
+	_, _, secondCert := writeCertificate(t, dir, 2)
+	future := time.Now().Add(2 * time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+	reloaded, err = reloader.reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded, "Changed certificate isn't reloaded")
+	if response := request(secondCert); response != nil {
+		assert.Equal(t, secondCert.SerialNumber, response.TLS.PeerCertificates[0].SerialNumber)
+	}
+
+	reloaded, _ = reloader.reload()
+	assert.False(t, reloaded, "Certificate reloaded without changes")
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	testCases := []struct {
+		host, target, expected string
+		port                   int
+	}{
+		{"example.com", "/api/v1/boards/all?limit=5", "https://example.com/api/v1/boards/all?limit=5", 443},
+		{"example.com:80", "/api/health/live", "https://example.com:8443/api/health/live", 8443},
+		{"[::1]:80", "/", "https://[::1]:8443/", 8443},
+	}
+
+	for _, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, testCase.target, nil)
+		req.Host = testCase.host
+		redirectToHTTPS(testCase.port).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, testCase.expected, rec.Header().Get("Location"))
+	}
+}
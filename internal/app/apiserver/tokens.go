@@ -0,0 +1,99 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	errTokenExpired   = errors.New("token expired")
+	errIncorrectToken = errors.New("incorrect token")
+)
+
+type newTokenRequest struct {
+	Name      string     `json:"name"       valid:"required"`
+	Scope     string     `json:"scope"      valid:"required"`
+	ExpiresAt *time.Time `json:"expires_at" valid:"-"`
+}
+
+func (srv *GotchaAPIServer) newTokenHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := newTokenRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		token, err := model.NewAPIToken(user.ID, req.Name, model.TokenScope(req.Scope), req.ExpiresAt)
+		if err != nil {
+			srv.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+		if err := srv.storage.Token().NewToken(request.Context(), token); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+
+		// The only time the token is shown
+		srv.respond(writer, request, http.StatusOK, token)
+	}
+}
+
+func (srv *GotchaAPIServer) getTokensHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		tokens, err := srv.storage.Token().GetTokensOfUser(request.Context(), &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, tokens)
+	}
+}
+
+func (srv *GotchaAPIServer) revokeTokenHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		tokenID, err := uuid.Parse(mux.Vars(request)["token_id"])
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, errIncorrectToken)
+			return
+		}
+
+		if err := srv.storage.Token().RevokeToken(request.Context(), tokenID, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
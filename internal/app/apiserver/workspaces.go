@@ -0,0 +1,218 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"Gotcha/internal/app/model"
+	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var errIncorrectWorkspace = errors.New("incorrect workspace")
+
+// workspaceIDFromPath extracts {workspace_id} variable of the route
+func workspaceIDFromPath(request *http.Request) (uuid.UUID, error) {
+	workspaceID, err := uuid.Parse(mux.Vars(request)["workspace_id"])
+	if err != nil {
+		return uuid.Nil, errIncorrectWorkspace
+	}
+	return workspaceID, nil
+}
+
+type newWorkspaceRequest struct {
+	Title string `json:"title" valid:"required"`
+}
+
+func (srv *GotchaAPIServer) newWorkspaceHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := newWorkspaceRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		workspace := model.Workspace{Title: req.Title}
+		if err := srv.storage.Workspace().NewWorkspace(request.Context(), &workspace, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, workspace)
+	}
+}
+
+func (srv *GotchaAPIServer) getWorkspacesHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		workspaces, err := srv.storage.Workspace().GetWorkspacesOfUser(request.Context(), &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, workspaces)
+	}
+}
+
+func (srv *GotchaAPIServer) getWorkspaceMembersHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		workspaceID, err := workspaceIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		members, err := srv.storage.Workspace().GetWorkspaceMembers(request.Context(), workspaceID, &user)
+		if err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, members)
+	}
+}
+
+type addWorkspaceMemberRequest struct {
+	UserID uuid.UUID `json:"user_id" valid:"required_uuid"`
+	Role   string    `json:"role"    valid:"required"`
+}
+
+// addWorkspaceMemberHandler adds the user to the workspace. Only admins are allowed to.
+func (srv *GotchaAPIServer) addWorkspaceMemberHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := addWorkspaceMemberRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		workspaceID, err := workspaceIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		role, err := model.ParseWorkspaceRole(req.Role)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := srv.storage.Workspace().AddWorkspaceMember(request.Context(), workspaceID, req.UserID, role, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+type updateWorkspaceMemberRequest struct {
+	Role string `json:"role" valid:"required"`
+}
+
+func (srv *GotchaAPIServer) updateWorkspaceMemberHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := updateWorkspaceMemberRequest{}
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		workspaceID, err := workspaceIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		memberID, err := collaboratorIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		if passedValidation, err := govalidator.ValidateStruct(req); !passedValidation {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		role, err := model.ParseWorkspaceRole(req.Role)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := srv.storage.Workspace().UpdateWorkspaceMember(request.Context(), workspaceID, memberID, role, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+// removeWorkspaceMemberHandler removes the member from the workspace. Admins remove anyone,
+// members may only leave the workspace. The last admin stays.
+func (srv *GotchaAPIServer) removeWorkspaceMemberHandler() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userWrapped := request.Context().Value(ctxVerifiedUserKey)
+		user, converted := userWrapped.(model.User)
+		if !converted {
+			srv.error(writer, request, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		workspaceID, err := workspaceIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		memberID, err := collaboratorIDFromPath(request)
+		if err != nil {
+			srv.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := srv.storage.Workspace().RemoveWorkspaceMember(request.Context(), workspaceID, memberID, &user); err != nil {
+			srv.storageError(writer, request, err)
+			return
+		}
+		srv.respond(writer, request, http.StatusOK, nil)
+	}
+}
@@ -0,0 +1,105 @@
+// Package authorization answers whether user may perform an action on a board.
+// Privileges are granted on root boards only, nested boards inherit them from the root.
+package authorization
+
+import (
+	"context"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+type Action string
+
+const (
+	ViewBoard           Action = "board:view"
+	CreateNestedBoard   Action = "board:create-nested"
+	DeleteNestedBoard   Action = "board:delete-nested"
+	DeleteRootBoard     Action = "board:delete-root"
+	ShareBoard          Action = "board:share"
+	ManageCollaborators Action = "board:manage-collaborators"
+	TransferBoard       Action = "board:transfer"
+	ViewNote            Action = "note:view"
+	WriteNote           Action = "note:write"
+	WriteReadOnlyNote   Action = "note:write-read-only"
+)
+
+// Actions lists every known action, the matrix must mention all of them
+var Actions = []Action{
+	ViewBoard, CreateNestedBoard, DeleteNestedBoard, DeleteRootBoard,
+	ShareBoard, ManageCollaborators, TransferBoard, ViewNote, WriteNote, WriteReadOnlyNote,
+}
+
+// matrix declares actions allowed for each privilege. Anything not listed is forbidden.
+var matrix = map[model.PrivilegeType][]Action{
+	model.PrivilegeAuthor: {
+		ViewBoard, CreateNestedBoard, DeleteNestedBoard, DeleteRootBoard,
+		ShareBoard, ManageCollaborators, TransferBoard, ViewNote, WriteNote, WriteReadOnlyNote,
+	},
+	model.PrivilegeReadWrite: {
+		ViewBoard, CreateNestedBoard, DeleteNestedBoard, ViewNote, WriteNote,
+	},
+	model.PrivilegeReadOnly: {
+		ViewBoard, ViewNote,
+	},
+}
+
+// Allowed reports whether the privilege allows the action according to the matrix
+func Allowed(privilege model.PrivilegeType, action Action) bool {
+	for _, allowed := range matrix[privilege] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// NoteAction returns the action required to create, change or remove the note.
+// Read-only notes are managed by the author of the board only.
+func NoteAction(readOnly bool) Action {
+	if readOnly {
+		return WriteReadOnlyNote
+	}
+	return WriteNote
+}
+
+// PermissionResolver finds the strongest privilege of user on the root of the board.
+// Implemented by storage.BoardRepository.
+type PermissionResolver interface {
+	GetPermissionOfUser(ctx context.Context, boardID, userID uuid.UUID) (*model.BoardPermission, error)
+}
+
+type Authorizer struct {
+	resolver PermissionResolver
+}
+
+func New(resolver PermissionResolver) *Authorizer {
+	return &Authorizer{resolver: resolver}
+}
+
+// Permission returns the permission of user on the root of the board.
+// Returns storage.ErrSecurityError if user has no relations with the board.
+func (a *Authorizer) Permission(ctx context.Context, user *model.User, boardID uuid.UUID) (*model.BoardPermission, error) {
+	bp, err := a.resolver.GetPermissionOfUser(ctx, boardID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if bp.Privilege == 0 {
+		return nil, storage.ErrSecurityError
+	}
+	return bp, nil
+}
+
+// Can returns nil if user may perform the action on the board and storage.ErrSecurityError otherwise.
+// storage.ErrNotFound is returned for unknown boards.
+func (a *Authorizer) Can(ctx context.Context, user *model.User, action Action, boardID uuid.UUID) error {
+	bp, err := a.Permission(ctx, user, boardID)
+	if err != nil {
+		return err
+	}
+	if !Allowed(bp.Privilege, action) {
+		return storage.ErrSecurityError
+	}
+	return nil
+}
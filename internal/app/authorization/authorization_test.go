@@ -0,0 +1,101 @@
+package authorization_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"Gotcha/internal/app/authorization"
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowed(t *testing.T) {
+	const (
+		author    = model.PrivilegeAuthor
+		readWrite = model.PrivilegeReadWrite
+		readOnly  = model.PrivilegeReadOnly
+	)
+
+	// Every pair of privilege and action is listed explicitly
+	expected := map[authorization.Action]map[model.PrivilegeType]bool{
+		authorization.ViewBoard:           {author: true, readWrite: true, readOnly: true},
+		authorization.CreateNestedBoard:   {author: true, readWrite: true, readOnly: false},
+		authorization.DeleteNestedBoard:   {author: true, readWrite: true, readOnly: false},
+		authorization.DeleteRootBoard:     {author: true, readWrite: false, readOnly: false},
+		authorization.ShareBoard:          {author: true, readWrite: false, readOnly: false},
+		authorization.ManageCollaborators: {author: true, readWrite: false, readOnly: false},
+		authorization.TransferBoard:       {author: true, readWrite: false, readOnly: false},
+		authorization.ViewNote:            {author: true, readWrite: true, readOnly: true},
+		authorization.WriteNote:           {author: true, readWrite: true, readOnly: false},
+		authorization.WriteReadOnlyNote:   {author: true, readWrite: false, readOnly: false},
+	}
+	assert.Len(t, expected, len(authorization.Actions), "Not all actions are covered")
+
+	for _, action := range authorization.Actions {
+		privileges, found := expected[action]
+		assert.True(t, found, "Action %s isn't covered", action)
+
+		for privilege, allowed := range privileges {
+			t.Run(fmt.Sprintf("%s/%d", action, privilege), func(t *testing.T) {
+				assert.Equal(t, allowed, authorization.Allowed(privilege, action))
+			})
+		}
+
+		// Users without relations can't do anything
+		assert.False(t, authorization.Allowed(0, action), "Action %s is allowed without privilege", action)
+	}
+}
+
+func TestNoteAction(t *testing.T) {
+	assert.Equal(t, authorization.WriteReadOnlyNote, authorization.NoteAction(true))
+	assert.Equal(t, authorization.WriteNote, authorization.NoteAction(false))
+}
+
+type resolverStub map[uuid.UUID]model.PrivilegeType
+
+func (r resolverStub) GetPermissionOfUser(ctx context.Context, boardID, userID uuid.UUID) (*model.BoardPermission, error) {
+	privilege, found := r[boardID]
+	if !found {
+		return nil, storage.ErrNotFound
+	}
+	return &model.BoardPermission{BoardID: boardID, UserID: userID, Privilege: privilege}, nil
+}
+
+func TestAuthorizer_Can(t *testing.T) {
+	ctx := context.Background()
+	user := model.TestUser(t)
+
+	authorBoard, readOnlyBoard, foreignBoard := uuid.New(), uuid.New(), uuid.New()
+	authorizer := authorization.New(resolverStub{
+		authorBoard:   model.PrivilegeAuthor,
+		readOnlyBoard: model.PrivilegeReadOnly,
+		foreignBoard:  0,
+	})
+
+	testCases := []struct {
+		name    string
+		action  authorization.Action
+		boardID uuid.UUID
+		err     error
+	}{
+		{name: "Author shares board", action: authorization.ShareBoard, boardID: authorBoard},
+		{name: "Reader views board", action: authorization.ViewBoard, boardID: readOnlyBoard},
+		{name: "Reader writes note", action: authorization.WriteNote, boardID: readOnlyBoard, err: storage.ErrSecurityError},
+		{name: "Stranger views board", action: authorization.ViewBoard, boardID: foreignBoard, err: storage.ErrSecurityError},
+		{name: "Unknown board", action: authorization.ViewBoard, boardID: uuid.New(), err: storage.ErrNotFound},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := authorizer.Can(ctx, user, testCase.action, testCase.boardID)
+			if testCase.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, testCase.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package lifecycle runs long-living components of gotcha and stops them gracefully
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"Gotcha/internal/app/logging"
+)
+
+// Closer stops the component. It must return, when ctx is done, even if the component isn't drained
+type Closer func(ctx context.Context) error
+
+// ComponentError is a failure of the component to serve or to stop
+type ComponentError struct {
+	Component string
+	Err       error
+}
+
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Component, e.Err)
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// ShutdownError lists components, that failed to stop. Cause is the reason of the shutdown,
+// it's nil if the shutdown was requested
+type ShutdownError struct {
+	Cause    error
+	Failures []*ComponentError
+}
+
+func (e *ShutdownError) Error() string {
+	failures := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		failures = append(failures, failure.Error())
+	}
+	message := "shutdown failed: " + strings.Join(failures, "; ")
+	if e.Cause != nil {
+		return fmt.Sprintf("%v (%s)", e.Cause, message)
+	}
+	return message
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Cause
+}
+
+type component struct {
+	name   string
+	closer Closer
+}
+
+// Manager stops registered components in reverse order, so the component is stopped
+// before the resources it depends on. The whole shutdown is limited by the drain timeout,
+// components left after the deadline are closed with the expired context and aren't awaited
+type Manager struct {
+	logger  logging.GotchaLogger
+	timeout time.Duration
+
+	mu         sync.Mutex
+	components []component
+	failures   chan *ComponentError
+}
+
+func New(logger logging.GotchaLogger, timeout time.Duration) *Manager {
+	return &Manager{
+		logger:   logger,
+		timeout:  timeout,
+		failures: make(chan *ComponentError, 1),
+	}
+}
+
+// Register adds the component to stop
+func (m *Manager) Register(name string, closer Closer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component{name, closer})
+}
+
+// Go runs the serving function of the component in background. The first failure of serve
+// stops the whole application, http.ErrServerClosed isn't a failure
+func (m *Manager) Go(name string, serve func() error) {
+	go func() {
+		if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			select {
+			case m.failures <- &ComponentError{Component: name, Err: err}:
+			default: // shutdown is already started by another failure
+			}
+		}
+	}()
+}
+
+// Wait blocks until ctx is done or any component fails to serve, then stops all components.
+// Failure of the component is returned as *ComponentError
+func (m *Manager) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		m.logger.Println("Shutting down the server")
+		return m.Stop(nil)
+	case failure := <-m.failures:
+		m.logger.Errorf("Shutting down the server: %v", failure)
+		return m.Stop(failure)
+	}
+}
+
+// Stop stops components registered so far and returns the cause of the stop. Components, that
+// failed to stop, are reported as *ShutdownError
+func (m *Manager) Stop(cause error) error {
+	m.mu.Lock()
+	components := m.components
+	m.components = nil
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	var failures []*ComponentError
+	for i := len(components) - 1; i >= 0; i-- {
+		if err := m.stop(ctx, components[i]); err != nil {
+			m.logger.Errorf("Failed to stop %s: %v", components[i].name, err)
+			failures = append(failures, &ComponentError{Component: components[i].name, Err: err})
+		}
+	}
+
+	if len(failures) != 0 {
+		return &ShutdownError{Cause: cause, Failures: failures}
+	}
+	m.logger.Println("Bye...")
+	return cause
+}
+
+// stop runs the closer, but doesn't wait for it after the deadline
+func (m *Manager) stop(ctx context.Context, c component) error {
+	m.logger.Printf("Stopping %s", c.name)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.closer(ctx)
+	}()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
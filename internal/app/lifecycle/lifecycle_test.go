@@ -0,0 +1,125 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"Gotcha/internal/app/lifecycle"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var logger = logrus.New()
+
+func TestManager_Wait(t *testing.T) {
+	manager := lifecycle.New(logger, time.Second)
+	stopped := make([]string, 0)
+	for _, name := range []string{"postgres", "redis", "http server"} {
+		name := name
+		manager.Register(name, func(context.Context) error {
+			stopped = append(stopped, name)
+			return nil
+		})
+	}
+	manager.Go("http server", func() error { return http.ErrServerClosed })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, manager.Wait(ctx), "Requested shutdown failed")
+	assert.Equal(t, []string{"http server", "redis", "postgres"}, stopped, "Resources are closed before their users")
+}
+
+func TestManager_serveFailure(t *testing.T) {
+	manager := lifecycle.New(logger, time.Second)
+	errListen := errors.New("address already in use")
+	manager.Go("http server", func() error { return errListen })
+
+	err := manager.Wait(context.Background())
+	assert.ErrorIs(t, err, errListen, "Serve failure isn't surfaced")
+
+	componentError := &lifecycle.ComponentError{}
+	if assert.ErrorAs(t, err, &componentError) {
+		assert.Equal(t, "http server", componentError.Component)
+	}
+}
+
+func TestManager_Stop(t *testing.T) {
+	manager := lifecycle.New(logger, time.Second)
+	errClose := errors.New("close failed")
+	closed := false
+	manager.Register("postgres", func(context.Context) error {
+		closed = true
+		return nil
+	})
+	manager.Register("redis", func(context.Context) error { return errClose })
+	manager.Register("http server", func(context.Context) error { return nil })
+
+	errStart := errors.New("migration failed")
+	err := manager.Stop(errStart)
+	assert.ErrorIs(t, err, errStart)
+	assert.True(t, closed, "Component skipped after the failure of another one")
+
+	shutdownError := &lifecycle.ShutdownError{}
+	if assert.ErrorAs(t, err, &shutdownError) && assert.Len(t, shutdownError.Failures, 1) {
+		assert.Equal(t, "redis", shutdownError.Failures[0].Component)
+		assert.ErrorIs(t, shutdownError.Failures[0], errClose)
+	}
+}
+
+func TestManager_StopTimeout(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	manager := lifecycle.New(logger, timeout)
+	slowCloser := func(ctx context.Context) error {
+		select {
+		case <-time.After(timeout * 3 / 4):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	closed := make(chan struct{})
+	manager.Register("postgres", func(context.Context) error {
+		close(closed)
+		return nil
+	})
+	manager.Register("redis", slowCloser)
+	manager.Register("http server", slowCloser)
+
+	// Closers fit into the timeout one by one, but not together
+	startTime := time.Now()
+	err := manager.Stop(nil)
+	assert.Less(t, time.Since(startTime), timeout*5/4, "Shutdown exceeds the timeout")
+
+	shutdownError := &lifecycle.ShutdownError{}
+	if assert.ErrorAs(t, err, &shutdownError) && assert.Len(t, shutdownError.Failures, 2) {
+		assert.Equal(t, "redis", shutdownError.Failures[0].Component)
+		assert.ErrorIs(t, shutdownError.Failures[0], context.DeadlineExceeded)
+		assert.Equal(t, "postgres", shutdownError.Failures[1].Component)
+		assert.ErrorIs(t, shutdownError.Failures[1], context.DeadlineExceeded)
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("Component isn't closed after the deadline")
+	}
+}
+
+func TestManager_StopHanging(t *testing.T) {
+	manager := lifecycle.New(logger, 50*time.Millisecond)
+	manager.Register("http server", func(ctx context.Context) error {
+		<-time.After(time.Second) // ignores the deadline
+		return nil
+	})
+
+	startTime := time.Now()
+	err := manager.Stop(nil)
+	assert.Less(t, time.Since(startTime), time.Second/2, "Manager waits for the hanging component")
+	shutdownError := &lifecycle.ShutdownError{}
+	if assert.ErrorAs(t, err, &shutdownError) && assert.Len(t, shutdownError.Failures, 1) {
+		assert.ErrorIs(t, shutdownError.Failures[0], context.DeadlineExceeded)
+	}
+}
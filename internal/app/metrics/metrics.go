@@ -0,0 +1,152 @@
+// Package metrics exposes the state of gotcha instance in the prometheus format
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Gotcha/internal/app/storage"
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "gotcha"
+
+	// totalsTimeout bounds the storage query made on every scrape
+	totalsTimeout = 5 * time.Second
+)
+
+// Metrics is a registry of gotcha collectors. Sources of the instance (database pool,
+// sessions store, storage) are attached separately once they're opened.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// New returns Metrics with registered HTTP, go runtime and process collectors
+func New() *Metrics {
+	metrics := Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of served HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of served HTTP requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	metrics.registry.MustRegister(
+		metrics.requests,
+		metrics.duration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return &metrics
+}
+
+// ObserveRequest counts the served request. Route is a template of the matched route,
+// so the cardinality of labels doesn't depend on identifiers in the path.
+func (m *Metrics) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.duration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+// RegisterDB exposes statistics of the database connections pool
+func (m *Metrics) RegisterDB(db *sql.DB, dbName string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// RegisterRedisPool exposes statistics of the connections pool of redis sessions store
+func (m *Metrics) RegisterRedisPool(pool *redis.Pool) error {
+	return m.registry.Register(&redisPoolCollector{pool: pool})
+}
+
+// RegisterStorage exposes the number of users, boards and notes. Storage is queried on scrape.
+func (m *Metrics) RegisterStorage(storage storage.Storage) error {
+	return m.registry.Register(&totalsCollector{storage: storage})
+}
+
+// Handler serves the registered metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+var (
+	redisActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "redis_pool", "active_connections"),
+		"Number of connections in the redis pool, including idle ones.", nil, nil,
+	)
+	redisIdleDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "redis_pool", "idle_connections"),
+		"Number of idle connections in the redis pool.", nil, nil,
+	)
+)
+
+type redisPoolCollector struct {
+	pool *redis.Pool
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisActiveDesc
+	ch <- redisIdleDesc
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.Stats()
+	ch <- prometheus.MustNewConstMetric(redisActiveDesc, prometheus.GaugeValue, float64(stats.ActiveCount))
+	ch <- prometheus.MustNewConstMetric(redisIdleDesc, prometheus.GaugeValue, float64(stats.IdleCount))
+}
+
+var (
+	usersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "users"),
+		"Number of registered users.", nil, nil,
+	)
+	boardsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "boards"),
+		"Number of boards, including nested ones.", nil, nil,
+	)
+	notesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "notes"),
+		"Number of notes.", nil, nil,
+	)
+)
+
+type totalsCollector struct {
+	storage storage.Storage
+}
+
+func (c *totalsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- usersDesc
+	ch <- boardsDesc
+	ch <- notesDesc
+}
+
+func (c *totalsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), totalsTimeout)
+	defer cancel()
+
+	totals, err := c.storage.Stats().GetTotals(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(usersDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(totals.Users))
+	ch <- prometheus.MustNewConstMetric(boardsDesc, prometheus.GaugeValue, float64(totals.Boards))
+	ch <- prometheus.MustNewConstMetric(notesDesc, prometheus.GaugeValue, float64(totals.Notes))
+}
@@ -0,0 +1,51 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Gotcha/internal/app/metrics"
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestMetrics_ObserveRequest(t *testing.T) {
+	m := metrics.New()
+	m.ObserveRequest(http.MethodGet, "/api/v1/boards/{id}", http.StatusOK, 10*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "/api/v1/boards/{id}", http.StatusOK, 20*time.Millisecond)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `gotcha_http_requests_total{method="GET",route="/api/v1/boards/{id}",status="200"} 2`)
+	assert.Contains(t, body, `gotcha_http_request_duration_seconds_count{method="GET",route="/api/v1/boards/{id}",status="200"} 2`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestMetrics_RegisterStorage(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+	user := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, user)
+	_, _ = store.Board().NewRootBoard(ctx, user, "Root")
+
+	m := metrics.New()
+	assert.NoError(t, m.RegisterStorage(store))
+
+	body := scrape(t, m)
+	assert.Contains(t, body, "gotcha_users 1")
+	assert.Contains(t, body, "gotcha_boards 1")
+	assert.Contains(t, body, "gotcha_notes 0")
+}
@@ -0,0 +1,331 @@
+// Package migrator applies versioned SQL migrations and tracks applied versions in the schema table.
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	createSchemaTableQuery = `
+		CREATE TABLE IF NOT EXISTS "SchemaVersions"(
+			"version" BIGINT NOT NULL PRIMARY KEY,
+			"name" VARCHAR(255) NOT NULL,
+			"applied_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`
+	getAppliedVersionsQuery = `
+		SELECT version, applied_at FROM "SchemaVersions" ORDER BY version;
+	`
+	isVersionAppliedQuery = `
+		SELECT EXISTS(SELECT 1 FROM "SchemaVersions" WHERE version = $1);
+	`
+	insertVersionQuery = `
+		INSERT INTO "SchemaVersions"(version, name) VALUES($1, $2);
+	`
+	deleteVersionQuery = `
+		DELETE FROM "SchemaVersions" WHERE version = $1;
+	`
+	// Deployments migrated by migrate/migrate have this table with a single row
+	getLegacyVersionQuery = `
+		SELECT version FROM schema_migrations WHERE NOT dirty LIMIT 1;
+	`
+	legacyTableExistsQuery = `
+		SELECT to_regclass('schema_migrations') IS NOT NULL;
+	`
+	lockQuery = `
+		SELECT pg_advisory_xact_lock($1);
+	`
+
+	// lockID is a random constant, that protects migrations from the concurrent run
+	lockID = 4761835392
+)
+
+var (
+	ErrNoMigrations    = errors.New("no migrations found")
+	ErrUnknownVersion  = errors.New("applied version has no migration file")
+	ErrMissingScript   = errors.New("migration has no up or down script")
+	migrationFileRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+// Migration is a pair of scripts that upgrade and downgrade the schema to Version
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes the state of the migration in the database
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load reads migrations from the root of fsys, sorted by version
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, found := byVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("%d_%s: %w", migration.Version, migration.Name, ErrMissingScript)
+		}
+		migrations = append(migrations, migration)
+	}
+	if len(migrations) == 0 {
+		return nil, ErrNoMigrations
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies migrations to the database. Each migration runs in its own transaction.
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+}
+
+// New loads migrations from fsys. Call Up, Down, Status or Version to work with the schema.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// prepare creates the schema table. If the database was migrated by the migrate/migrate tool,
+// all migrations up to its version are marked as applied. Everything runs under the advisory lock,
+// so concurrent migrators don't race on the table creation and the import.
+func (m *Migrator) prepare(ctx context.Context) error {
+	return m.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, createSchemaTableQuery); err != nil {
+			return err
+		}
+
+		var legacyExists bool
+		if err := tx.QueryRowContext(ctx, legacyTableExistsQuery).Scan(&legacyExists); err != nil || !legacyExists {
+			return err
+		}
+
+		var legacyVersion uint64
+		if err := tx.QueryRowContext(ctx, getLegacyVersionQuery).Scan(&legacyVersion); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > legacyVersion {
+				break
+			}
+			applied, err := isApplied(ctx, tx, migration.Version)
+			if err != nil {
+				return err
+			}
+			if applied {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, insertVersionQuery, migration.Version, migration.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Up applies all pending migrations and returns them
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	if err := m.prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	applied := make([]*Migration, 0)
+	for _, migration := range m.migrations {
+		wasApplied := false
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			isDone, err := isApplied(ctx, tx, migration.Version)
+			if err != nil || isDone {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, insertVersionQuery, migration.Version, migration.Name)
+			wasApplied = err == nil
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if wasApplied {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// Down reverts at most steps latest applied migrations and returns them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	if err := m.prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	reverted := make([]*Migration, 0, steps)
+	for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		migration := m.migrations[i]
+		wasReverted := false
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			isDone, err := isApplied(ctx, tx, migration.Version)
+			if err != nil || !isDone {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, deleteVersionQuery, migration.Version)
+			wasReverted = err == nil
+			return err
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if wasReverted {
+			reverted = append(reverted, migration)
+		}
+	}
+	return reverted, nil
+}
+
+// Status returns all known migrations with their state
+func (m *Migrator) Status(ctx context.Context) ([]*MigrationStatus, error) {
+	if err := m.prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	appliedAt, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: *migration}
+		status.AppliedAt, status.Applied = appliedAt[migration.Version]
+		statuses = append(statuses, &status)
+		delete(appliedAt, migration.Version)
+	}
+
+	// Database is newer than the binary
+	if len(appliedAt) != 0 {
+		return statuses, ErrUnknownVersion
+	}
+	return statuses, nil
+}
+
+// Version returns the latest applied version. Zero means that the schema is empty.
+func (m *Migrator) Version(ctx context.Context) (uint64, error) {
+	if err := m.prepare(ctx); err != nil {
+		return 0, err
+	}
+
+	appliedAt, err := m.appliedVersions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version uint64
+	for applied := range appliedAt {
+		if applied > version {
+			version = applied
+		}
+	}
+	return version, nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[uint64]time.Time, error) {
+	rows, err := m.db.QueryContext(ctx, getAppliedVersionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[uint64]time.Time)
+	for rows.Next() {
+		var version uint64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+	return appliedAt, rows.Err()
+}
+
+// inTx runs fn in the transaction guarded by the advisory lock
+func (m *Migrator) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, lockQuery, lockID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func isApplied(ctx context.Context, tx *sql.Tx, version uint64) (bool, error) {
+	var applied bool
+	return applied, tx.QueryRowContext(ctx, isVersionAppliedQuery, version).Scan(&applied)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -16,6 +17,57 @@ const (
 	PrivilegeReadWrite
 )
 
+var ErrIncorrectPrivilege = errors.New("incorrect permission")
+
+// ParseGrantablePrivilege converts the short name of the privilege ("ro" or "rw"), that can be
+// granted to collaborators. Author privilege belongs to the creator of the board only.
+func ParseGrantablePrivilege(name string) (PrivilegeType, error) {
+	switch name {
+	case "ro":
+		return PrivilegeReadOnly, nil
+	case "rw":
+		return PrivilegeReadWrite, nil
+	}
+	return 0, ErrIncorrectPrivilege
+}
+
+// ParsePrivilege converts the short name of any privilege including "author"
+func ParsePrivilege(name string) (PrivilegeType, error) {
+	if name == PrivilegeAuthor.String() {
+		return PrivilegeAuthor, nil
+	}
+	return ParseGrantablePrivilege(name)
+}
+
+func (p PrivilegeType) String() string {
+	switch p {
+	case PrivilegeAuthor:
+		return "author"
+	case PrivilegeReadOnly:
+		return "ro"
+	case PrivilegeReadWrite:
+		return "rw"
+	}
+	return "none"
+}
+
+func (p PrivilegeType) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *PrivilegeType) UnmarshalText(text []byte) error {
+	if string(text) == PrivilegeType(0).String() {
+		*p = 0
+		return nil
+	}
+	privilege, err := ParsePrivilege(string(text))
+	if err != nil {
+		return err
+	}
+	*p = privilege
+	return nil
+}
+
 type BaseBoard struct {
 	Title     string    `json:"title"`
 	ID        uuid.UUID `json:"id"`
@@ -24,13 +76,25 @@ type BaseBoard struct {
 
 type NestedBoard struct {
 	Base       BaseBoard
-	RootBoard  uuid.UUID
-	RelationID uuid.UUID
+	RootBoard  uuid.UUID `json:"root_board"`
+	RelationID uuid.UUID `json:"relation_id"`
 }
 
+// Board is a root board. Nil WorkspaceID means the personal board outside of workspaces.
+// Privilege is the one of the user, that requested the board.
 type Board struct {
 	Base         BaseBoard
-	U2BRelations []uuid.UUID `json:"relations"`
+	WorkspaceID  uuid.UUID     `json:"workspace_id"`
+	Privilege    PrivilegeType `json:"privilege"`
+	U2BRelations []uuid.UUID   `json:"relations"`
+}
+
+// BoardTree is a node of the boards hierarchy. ChildCount is the real number of nested
+// boards, so it can be greater than len(Children) when the tree is cut by depth.
+type BoardTree struct {
+	Base       BaseBoard
+	ChildCount int          `json:"child_count"`
+	Children   []*BoardTree `json:"children"`
 }
 
 type BoardPermission struct {
@@ -39,6 +103,27 @@ type BoardPermission struct {
 	Privilege PrivilegeType
 }
 
+// Collaborator is a user related to the root board
+type Collaborator struct {
+	RelationID  uuid.UUID     `json:"relation_id"`
+	UserID      uuid.UUID     `json:"user_id"`
+	Username    string        `json:"username"`
+	Privilege   PrivilegeType `json:"privilege"`
+	Description string        `json:"description"`
+	GrantedAt   time.Time     `json:"granted_at"`
+}
+
+// BoardTransfer is an audit record of the board authorship transfer. Previous author either keeps
+// rw access to the board or loses it completely.
+type BoardTransfer struct {
+	ID         uuid.UUID `json:"id"`
+	BoardID    uuid.UUID `json:"board_id"`
+	FromUserID uuid.UUID `json:"from_user_id"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+	KeepAccess bool      `json:"keep_access"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 func NewBoard(title string) *Board {
 	return &Board{
 		U2BRelations: make([]uuid.UUID, 0, 4),
@@ -46,10 +131,40 @@ func NewBoard(title string) *Board {
 	}
 }
 
+func NewBoardTree(base BaseBoard) *BoardTree {
+	return &BoardTree{
+		Base:     base,
+		Children: make([]*BoardTree, 0),
+	}
+}
+
 func (b *Board) AddRelation(uuid uuid.UUID) {
 	b.U2BRelations = append(b.U2BRelations, uuid)
 }
 
+// StrongestPrivilege returns the privilege that allows more actions: author > rw > ro.
+// Zero value means that there is no privilege at all.
+func StrongestPrivilege(a, b PrivilegeType) PrivilegeType {
+	rank := func(p PrivilegeType) int {
+		switch p {
+		case PrivilegeAuthor:
+			return 3
+		case PrivilegeReadWrite:
+			return 2
+		case PrivilegeReadOnly:
+			return 1
+		}
+		return 0
+	}
+
+	if rank(b) > rank(a) {
+		return b
+	}
+	return a
+}
+
 func (b *BaseBoard) Validate() error {
-	return validation.Validate(b.Title, validation.Length(1, 255))
+	titleField := validation.Field(&b.Title, validation.Required, validation.Length(1, 255))
+
+	return validation.ValidateStruct(b, titleField)
 }
@@ -0,0 +1,52 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGrantablePrivilege(t *testing.T) {
+	testCases := []struct {
+		name      string
+		privilege model.PrivilegeType
+		isValid   bool
+	}{
+		{name: "ro", privilege: model.PrivilegeReadOnly, isValid: true},
+		{name: "rw", privilege: model.PrivilegeReadWrite, isValid: true},
+		{name: "author", isValid: false},
+		{name: "", isValid: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			privilege, err := model.ParseGrantablePrivilege(testCase.name)
+			if testCase.isValid {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.privilege, privilege)
+			} else {
+				assert.ErrorIs(t, err, model.ErrIncorrectPrivilege)
+			}
+		})
+	}
+}
+
+func TestPrivilegeType_MarshalText(t *testing.T) {
+	encoded, err := json.Marshal(model.Collaborator{Privilege: model.PrivilegeAuthor})
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), `"privilege":"author"`)
+}
+
+func TestPrivilegeType_UnmarshalText(t *testing.T) {
+	for _, privilege := range []model.PrivilegeType{0, model.PrivilegeAuthor, model.PrivilegeReadOnly, model.PrivilegeReadWrite} {
+		encoded, _ := json.Marshal(model.Board{Privilege: privilege})
+		decoded := model.Board{}
+		assert.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, privilege, decoded.Privilege)
+	}
+
+	var privilege model.PrivilegeType
+	assert.ErrorIs(t, privilege.UnmarshalText([]byte("admin")), model.ErrIncorrectPrivilege)
+}
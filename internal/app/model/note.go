@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+// Note is a piece of content attached to the nested board. Notes are bound to
+// the BoardToBoard relation (board bridge) of the nested board, not to the board itself.
+type Note struct {
+	ID            int       `json:"id"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	ReadOnly      bool      `json:"read_only"`
+	BoardBridgeID uuid.UUID `json:"board_bridge_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Validate checks important fields of Note.
+// **Constrains**
+// Title: required, size(1, 255)
+// Content: size(0, 65535)
+func (n *Note) Validate() error {
+	titleField := validation.Field(&n.Title, validation.Required, validation.Length(1, 255))
+	contentField := validation.Field(&n.Content, validation.Length(0, 65535))
+
+	return validation.ValidateStruct(n, titleField, contentField)
+}
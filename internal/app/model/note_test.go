@@ -0,0 +1,63 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNote_Validate(t *testing.T) {
+	testCases := []struct {
+		isValid       bool
+		testName      string
+		noteGenerator func() *model.Note
+	}{
+		{
+			isValid:  true,
+			testName: "Valid note",
+			noteGenerator: func() *model.Note {
+				return model.TestNote(t)
+			},
+		},
+		{
+			isValid:  true,
+			testName: "Empty content",
+			noteGenerator: func() *model.Note {
+				n := model.TestNote(t)
+				n.Content = ""
+				return n
+			},
+		},
+		{
+			isValid:  false,
+			testName: "Empty title",
+			noteGenerator: func() *model.Note {
+				n := model.TestNote(t)
+				n.Title = ""
+				return n
+			},
+		},
+		{
+			isValid:  false,
+			testName: "Long title",
+			noteGenerator: func() *model.Note {
+				n := model.TestNote(t)
+				n.Title = strings.Repeat("A", 256)
+				return n
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testName, func(t *testing.T) {
+			note := testCase.noteGenerator()
+			if testCase.isValid {
+				assert.NoError(t, note.Validate())
+			} else {
+				assert.Error(t, note.Validate())
+			}
+		})
+	}
+}
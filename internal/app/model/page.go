@@ -0,0 +1,127 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+// SortField is a key of keyset pagination. SortByTitle sorts boards by title and users by username.
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByTitle     SortField = "title"
+)
+
+var (
+	ErrIncorrectCursor = errors.New("incorrect cursor")
+	ErrIncorrectSort   = errors.New("incorrect sort")
+	ErrIncorrectLimit  = errors.New("incorrect limit")
+)
+
+// ParseSortField checks that the name is a known sort key
+func ParseSortField(name string) (SortField, error) {
+	switch field := SortField(name); field {
+	case SortByCreatedAt, SortByTitle:
+		return field, nil
+	}
+	return "", ErrIncorrectSort
+}
+
+// Cursor points to the last item of the previous page. It's passed to clients as an opaque string
+// and is bound to the sort it was issued for.
+type Cursor struct {
+	Sort       SortField `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	Title      string    `json:"t,omitempty"`
+	CreatedAt  time.Time `json:"c"`
+	ID         uuid.UUID `json:"id"`
+}
+
+// Encode returns the opaque representation of the cursor
+func (c *Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses the cursor returned by Cursor.Encode
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrIncorrectCursor
+	}
+	cursor := Cursor{}
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, ErrIncorrectCursor
+	}
+	if _, err := ParseSortField(string(cursor.Sort)); err != nil {
+		return nil, ErrIncorrectCursor
+	}
+	return &cursor, nil
+}
+
+// ListOptions describes the page of a listing. Items are ordered by Sort and ID, so the order is
+// stable even for equal keys.
+type ListOptions struct {
+	Limit      int
+	Cursor     *Cursor
+	Sort       SortField
+	Descending bool
+	// Title is a case-insensitive substring of the board title or username
+	Title string
+	// Privilege filters boards by the privilege of user, zero means any privilege
+	Privilege PrivilegeType
+}
+
+// DefaultListOptions returns the first page of default size sorted by creation time
+func DefaultListOptions() *ListOptions {
+	return &ListOptions{Limit: DefaultPageLimit, Sort: SortByCreatedAt}
+}
+
+// Validate checks the limit and that the cursor was issued for the same sort
+func (o *ListOptions) Validate() error {
+	if o.Limit < 1 || o.Limit > MaxPageLimit {
+		return ErrIncorrectLimit
+	}
+	if _, err := ParseSortField(string(o.Sort)); err != nil {
+		return err
+	}
+	if o.Cursor != nil && (o.Cursor.Sort != o.Sort || o.Cursor.Descending != o.Descending) {
+		return ErrIncorrectCursor
+	}
+	return nil
+}
+
+// CursorFor returns the cursor pointing to the item with the passed keys
+func (o *ListOptions) CursorFor(title string, createdAt time.Time, id uuid.UUID) *Cursor {
+	cursor := Cursor{Sort: o.Sort, Descending: o.Descending, CreatedAt: createdAt, ID: id}
+	if o.Sort == SortByTitle {
+		cursor.Title = title
+	}
+	return &cursor
+}
+
+// Page is a response envelope of listings. Empty NextCursor means that it's the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// NewPage cuts items fetched with one extra row to the limit and sets the cursor of the next page
+func NewPage[T any](items []T, opts *ListOptions, cursorOf func(T) *Cursor) *Page[T] {
+	page := Page[T]{Items: items}
+	if len(items) > opts.Limit {
+		page.Items = items[:opts.Limit]
+		page.NextCursor = cursorOf(page.Items[opts.Limit-1]).Encode()
+	}
+	return &page
+}
@@ -0,0 +1,52 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor_Encode(t *testing.T) {
+	opts := model.DefaultListOptions()
+	opts.Sort = model.SortByTitle
+	cursor := opts.CursorFor("Board", time.Now().UTC(), uuid.New())
+
+	decoded, err := model.DecodeCursor(cursor.Encode())
+	assert.NoError(t, err, "Failed to decode cursor")
+	assert.Equal(t, cursor, decoded)
+
+	_, err = model.DecodeCursor("garbage")
+	assert.ErrorIs(t, err, model.ErrIncorrectCursor)
+}
+
+func TestListOptions_Validate(t *testing.T) {
+	opts := model.DefaultListOptions()
+	assert.NoError(t, opts.Validate())
+
+	opts.Limit = model.MaxPageLimit + 1
+	assert.ErrorIs(t, opts.Validate(), model.ErrIncorrectLimit)
+
+	// Cursor is bound to the sort it was issued for
+	opts = model.DefaultListOptions()
+	opts.Cursor = opts.CursorFor("Board", time.Now(), uuid.New())
+	opts.Sort = model.SortByTitle
+	assert.ErrorIs(t, opts.Validate(), model.ErrIncorrectCursor)
+}
+
+func TestNewPage(t *testing.T) {
+	opts := model.DefaultListOptions()
+	opts.Limit = 2
+	cursorOf := func(item int) *model.Cursor {
+		return &model.Cursor{Sort: opts.Sort, ID: uuid.New()}
+	}
+
+	page := model.NewPage([]int{1, 2, 3}, opts, cursorOf)
+	assert.Equal(t, []int{1, 2}, page.Items)
+	assert.NotEmpty(t, page.NextCursor, "Next page is lost")
+
+	page = model.NewPage([]int{1, 2}, opts, cursorOf)
+	assert.Empty(t, page.NextCursor, "Cursor on the last page")
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"errors"
+	"html"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type SearchKind string
+
+const (
+	SearchKindBoard SearchKind = "board"
+	SearchKindNote  SearchKind = "note"
+
+	DefaultSearchLimit = 20
+
+	// Highlighted parts of snippets are wrapped with these marks
+	SearchHighlightStart = "<mark>"
+	SearchHighlightStop  = "</mark>"
+
+	// Search backends mark matches of raw text with these private use characters,
+	// they are turned into highlight marks by RenderSnippet
+	SearchMatchStart = "\uE000"
+	SearchMatchStop  = "\uE001"
+)
+
+// snippetMarks turns match marks of the escaped snippet into highlight marks
+var snippetMarks = strings.NewReplacer(SearchMatchStart, SearchHighlightStart, SearchMatchStop, SearchHighlightStop)
+
+var ErrEmptySearchQuery = errors.New("search query is empty")
+
+// SearchResult is a board or a note matching the search query. NoteID is set for notes only,
+// BoardID of the note is the nested board it's attached to.
+type SearchResult struct {
+	Kind    SearchKind `json:"kind"`
+	BoardID uuid.UUID  `json:"board_id"`
+	NoteID  int        `json:"note_id,omitempty"`
+	Title   string     `json:"title"`
+	Snippet string     `json:"snippet"`
+	Rank    float64    `json:"rank"`
+}
+
+// RenderSnippet escapes HTML of the raw snippet, which matches are wrapped with SearchMatchStart
+// and SearchMatchStop, and highlights the matches. Result is safe to render as HTML.
+func RenderSnippet(marked string) string {
+	return snippetMarks.Replace(html.EscapeString(marked))
+}
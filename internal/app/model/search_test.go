@@ -0,0 +1,17 @@
+package model_test
+
+import (
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderSnippet(t *testing.T) {
+	marked := `<img src=x onerror="alert(1)"> ` + model.SearchMatchStart + "roadmap" + model.SearchMatchStop
+	assert.Equal(t,
+		`&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>roadmap</mark>`,
+		model.RenderSnippet(marked),
+		"Snippet isn't escaped",
+	)
+}
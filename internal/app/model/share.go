@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+const shareLinkPrefix = "gotcha_share_"
+
+// ShareLink gives unauthenticated read-only access to the board and its nested boards.
+// Like APIToken, only hash of the link token is saved.
+type ShareLink struct {
+	ID        uuid.UUID  `json:"id"`
+	BoardID   uuid.UUID  `json:"board_id"`
+	CreatedBy uuid.UUID  `json:"created_by"`
+	Token     string     `json:"token,omitempty"`
+	Hash      string     `json:"-"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewShareLink generates random link token of the board. Call Validate before saving the entity.
+func NewShareLink(boardID, userID uuid.UUID, expiresAt *time.Time) (*ShareLink, error) {
+	token, err := randomToken(shareLinkPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShareLink{
+		BoardID:   boardID,
+		CreatedBy: userID,
+		Token:     token,
+		Hash:      HashToken(token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Validate checks important fields of ShareLink.
+// **Constrains**
+// BoardID: required
+// ExpiresAt: in the future, if specified
+func (l *ShareLink) Validate() error {
+	boardField := validation.Field(&l.BoardID, validation.Required)
+	expiresField := validation.Field(&l.ExpiresAt, validation.By(func(value interface{}) error {
+		if l.ExpiresAt != nil && !l.ExpiresAt.After(time.Now()) {
+			return errTokenExpired
+		}
+		return nil
+	}))
+
+	return validation.ValidateStruct(l, boardField, expiresField)
+}
+
+// IsExpired reports whether the link can't be used anymore
+func (l *ShareLink) IsExpired() bool {
+	return l.ExpiresAt != nil && !l.ExpiresAt.After(time.Now())
+}
+
+// ClearSensitive hides the link token after it was shown to the author
+func (l *ShareLink) ClearSensitive() {
+	l.Token = ""
+}
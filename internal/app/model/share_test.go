@@ -0,0 +1,28 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewShareLink(t *testing.T) {
+	link, err := model.NewShareLink(uuid.New(), uuid.New(), nil)
+	assert.NoError(t, err, "Failed to generate link")
+	assert.True(t, strings.HasPrefix(link.Token, "gotcha_share_"), "Link token has no prefix")
+	assert.Equal(t, link.Hash, model.HashToken(link.Token), "Hash doesn't match the token")
+	assert.NoError(t, link.Validate())
+	assert.False(t, link.IsExpired())
+
+	past := time.Now().Add(-time.Hour)
+	link.ExpiresAt = &past
+	assert.Error(t, link.Validate(), "Expired link is valid")
+	assert.True(t, link.IsExpired())
+
+	link.ClearSensitive()
+	assert.Empty(t, link.Token, "Token not empty after ClearSensitive call")
+}
@@ -0,0 +1,8 @@
+package model
+
+// Totals is a number of entities stored by the whole instance. Boards include the nested ones.
+type Totals struct {
+	Users  int `json:"users"`
+	Boards int `json:"boards"`
+	Notes  int `json:"notes"`
+}
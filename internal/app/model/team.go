@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+// Team is a group of users, that can be granted access to boards as a whole.
+// Owner is a member of the team and the only one who manages its members.
+type Team struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	OwnerID   uuid.UUID `json:"owner_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TeamMember struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Validate checks important fields of Team.
+// **Constrains**
+// Title: required, size(1, 255)
+func (t *Team) Validate() error {
+	titleField := validation.Field(&t.Title, validation.Required, validation.Length(1, 255))
+
+	return validation.ValidateStruct(t, titleField)
+}
@@ -0,0 +1,20 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeam_Validate(t *testing.T) {
+	team := model.Team{Title: "Backend"}
+	assert.NoError(t, team.Validate())
+
+	team.Title = ""
+	assert.Error(t, team.Validate(), "Team without title is valid")
+
+	team.Title = strings.Repeat("a", 256)
+	assert.Error(t, team.Validate(), "Too long title is valid")
+}
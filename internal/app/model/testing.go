@@ -14,3 +14,13 @@ func TestUser(t *testing.T) *User {
 		Password: "ExamplePassword",
 	}
 }
+
+// TestNote returns note instance filled with dummy values
+func TestNote(t *testing.T) *Note {
+	t.Helper()
+
+	return &Note{
+		Title:   "Note title",
+		Content: "Some content of the note",
+	}
+}
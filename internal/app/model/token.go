@@ -0,0 +1,98 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+type TokenScope string
+
+const (
+	TokenScopeReadOnly  TokenScope = "ro"
+	TokenScopeReadWrite TokenScope = "rw"
+
+	tokenPrefix = "gotcha_"
+	tokenLength = 32 // bytes of entropy
+)
+
+var errTokenExpired = errors.New("expiration time must be in the future")
+
+// APIToken is a personal access token, that authorizes requests as its owner.
+// Only hash of the token is saved, the token itself is shown once on creation.
+type APIToken struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Name      string     `json:"name"`
+	Scope     TokenScope `json:"scope"`
+	Token     string     `json:"token,omitempty"`
+	Hash      string     `json:"-"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewAPIToken generates random token of the user. Call Validate before saving the entity.
+func NewAPIToken(userID uuid.UUID, name string, scope TokenScope, expiresAt *time.Time) (*APIToken, error) {
+	token, err := randomToken(tokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIToken{
+		UserID:    userID,
+		Name:      name,
+		Scope:     scope,
+		Token:     token,
+		Hash:      HashToken(token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// randomToken returns the prefixed hex string with tokenLength bytes of entropy
+func randomToken(prefix string) (string, error) {
+	raw := make([]byte, tokenLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(raw), nil
+}
+
+// HashToken returns hex encoded sha256 of the token. Tokens have enough entropy, so slow hashes
+// like bcrypt are not needed.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Validate checks important fields of APIToken.
+// **Constrains**
+// Name: required, size(1, 64)
+// Scope: ro or rw
+// ExpiresAt: in the future, if specified
+func (t *APIToken) Validate() error {
+	nameField := validation.Field(&t.Name, validation.Required, validation.Length(1, 64))
+	scopeField := validation.Field(&t.Scope, validation.Required, validation.In(TokenScopeReadOnly, TokenScopeReadWrite))
+	expiresField := validation.Field(&t.ExpiresAt, validation.By(func(value interface{}) error {
+		if t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()) {
+			return errTokenExpired
+		}
+		return nil
+	}))
+
+	return validation.ValidateStruct(t, nameField, scopeField, expiresField)
+}
+
+// IsExpired reports whether the token can't be used anymore
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now())
+}
+
+// ClearSensitive hides the token after it was shown to the owner
+func (t *APIToken) ClearSensitive() {
+	t.Token = ""
+}
@@ -0,0 +1,53 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAPIToken(t *testing.T) {
+	token, err := model.NewAPIToken(uuid.New(), "cli", model.TokenScopeReadWrite, nil)
+	assert.NoError(t, err, "Failed to generate token")
+	assert.NotEmpty(t, token.Token)
+	assert.Equal(t, token.Hash, model.HashToken(token.Token), "Hash doesn't match the token")
+
+	anotherToken, _ := model.NewAPIToken(uuid.New(), "cli", model.TokenScopeReadWrite, nil)
+	assert.NotEqual(t, token.Token, anotherToken.Token, "Tokens aren't random")
+
+	token.ClearSensitive()
+	assert.Empty(t, token.Token, "Token not empty after ClearSensitive call")
+}
+
+func TestAPIToken_Validate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		isValid  bool
+		testName string
+		name     string
+		scope    model.TokenScope
+		expires  *time.Time
+	}{
+		{isValid: true, testName: "Valid token", name: "cli", scope: model.TokenScopeReadOnly},
+		{isValid: true, testName: "Expiring token", name: "cli", scope: model.TokenScopeReadWrite, expires: &future},
+		{isValid: false, testName: "Empty name", name: "", scope: model.TokenScopeReadOnly},
+		{isValid: false, testName: "Unknown scope", name: "cli", scope: "admin"},
+		{isValid: false, testName: "Already expired", name: "cli", scope: model.TokenScopeReadOnly, expires: &past},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testName, func(t *testing.T) {
+			token, _ := model.NewAPIToken(uuid.New(), testCase.name, testCase.scope, testCase.expires)
+			if testCase.isValid {
+				assert.NoError(t, token.Validate())
+			} else {
+				assert.Error(t, token.Validate())
+			}
+		})
+	}
+}
@@ -19,6 +19,9 @@ type User struct {
 	Password  string    `json:"password,omitempty"`
 	Hash      string    `json:"-"` // shadow field of Password
 	CreatedAt time.Time `json:"created_at"`
+
+	// SessionsRevokedAt invalidates all sessions issued before it. Zero means never revoked
+	SessionsRevokedAt time.Time `json:"-"`
 }
 
 // ClearSensitive clears sensitive fields like password and...
@@ -52,3 +55,8 @@ func (u *User) BeforeCreate() error {
 func (u *User) IsCorrectPassword(password string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(u.Hash), []byte(password)) == nil
 }
+
+// IsSessionRevoked reports whether the session issued at issuedAt was revoked by "sign out everywhere"
+func (u *User) IsSessionRevoked(issuedAt time.Time) bool {
+	return !u.SessionsRevokedAt.IsZero() && !issuedAt.After(u.SessionsRevokedAt)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"Gotcha/internal/app/model"
 	"github.com/stretchr/testify/assert"
@@ -173,3 +174,13 @@ func TestUser_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_IsSessionRevoked(t *testing.T) {
+	user := model.TestUser(t)
+	issuedAt := time.Now()
+	assert.False(t, user.IsSessionRevoked(issuedAt), "Session revoked without revocation")
+
+	user.SessionsRevokedAt = issuedAt.Add(time.Second)
+	assert.True(t, user.IsSessionRevoked(issuedAt), "Session issued before revocation is valid")
+	assert.False(t, user.IsSessionRevoked(issuedAt.Add(time.Minute)), "Session issued after revocation is invalid")
+}
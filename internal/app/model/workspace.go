@@ -0,0 +1,52 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+// WorkspaceRole is a role of the member inside the workspace. Admins manage membership.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleAdmin  WorkspaceRole = "admin"
+	WorkspaceRoleMember WorkspaceRole = "member"
+)
+
+var ErrIncorrectWorkspaceRole = errors.New("incorrect workspace role")
+
+// ParseWorkspaceRole checks that the name is a known role
+func ParseWorkspaceRole(name string) (WorkspaceRole, error) {
+	switch role := WorkspaceRole(name); role {
+	case WorkspaceRoleAdmin, WorkspaceRoleMember:
+		return role, nil
+	}
+	return "", ErrIncorrectWorkspaceRole
+}
+
+// Workspace is an isolated organisation. Boards of the workspace are visible to its members only,
+// boards without workspace are personal boards of their authors, shared with their colleagues only.
+type Workspace struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorkspaceMember struct {
+	UserID   uuid.UUID     `json:"user_id"`
+	Username string        `json:"username"`
+	Role     WorkspaceRole `json:"role"`
+	JoinedAt time.Time     `json:"joined_at"`
+}
+
+// Validate checks important fields of Workspace.
+// **Constrains**
+// Title: required, size(1, 255)
+func (w *Workspace) Validate() error {
+	titleField := validation.Field(&w.Title, validation.Required, validation.Length(1, 255))
+
+	return validation.ValidateStruct(w, titleField)
+}
@@ -0,0 +1,29 @@
+package model_test
+
+import (
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspace_Validate(t *testing.T) {
+	workspace := model.Workspace{Title: "Acme"}
+	assert.NoError(t, workspace.Validate())
+
+	workspace.Title = ""
+	assert.Error(t, workspace.Validate(), "Workspace without title is valid")
+}
+
+func TestParseWorkspaceRole(t *testing.T) {
+	role, err := model.ParseWorkspaceRole("admin")
+	assert.NoError(t, err)
+	assert.Equal(t, model.WorkspaceRoleAdmin, role)
+
+	role, err = model.ParseWorkspaceRole("member")
+	assert.NoError(t, err)
+	assert.Equal(t, model.WorkspaceRoleMember, role)
+
+	_, err = model.ParseWorkspaceRole("owner")
+	assert.ErrorIs(t, err, model.ErrIncorrectWorkspaceRole)
+}
@@ -1,21 +1,26 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 
+	"Gotcha/internal/app/authorization"
 	"Gotcha/internal/app/model"
 	"Gotcha/internal/app/storage"
+	"Gotcha/internal/app/tracing"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const (
 	InsertBoardQuery = `
-		INSERT INTO "Board"(title) VALUES($1) RETURNING id, created_at;
+		INSERT INTO "Board"(title, workspace_id) VALUES($1, $2) RETURNING id, created_at;
 	`
 	GetRelationsOfBoardQuery = `
-		SELECT utb.id, b.title, b.created_at FROM "Board" b
+		SELECT utb.id, b.title, b.created_at, b.workspace_id FROM "Board" b
 			INNER JOIN "UserToBoard" utb ON utb.board_id = b.id
 		WHERE utb.board_id = $1;
 	`
@@ -23,19 +28,69 @@ const (
 		INSERT INTO "UserToBoard"(board_id, user_id, access_type, description)
 			VALUES($1, $2, $3, $4) RETURNING id
 	`
+	// GetBoardsOfUserQuery is completed with the keyset condition and ORDER BY clause of the page.
+	// Privileges are ranked as in model.StrongestPrivilege: author(1) > rw(3) > ro(2)
 	GetBoardsOfUserQuery = `
-		SELECT b.id, b.title, b.created_at, utb.id FROM "Board" b
-			INNER JOIN "UserToBoard" utb ON utb.board_id = b.id
-		WHERE utb.user_id = $1;
+		SELECT id, title, created_at, workspace_id, privilege, relations FROM (
+			SELECT b.id, b.title, b.created_at, b.workspace_id,
+				CASE WHEN bool_or(p.access_type = 1) THEN 1 WHEN bool_or(p.access_type = 3) THEN 3 ELSE 2 END AS privilege,
+				coalesce(array_agg(p.relation_id) FILTER (WHERE p.relation_id IS NOT NULL), '{}') AS relations
+			FROM "Board" b
+				INNER JOIN (
+					SELECT board_id, id AS relation_id, access_type FROM "UserToBoard" WHERE user_id = $1
+					UNION ALL
+					SELECT ttb.board_id, NULL::uuid, ttb.access_type FROM "TeamToBoard" ttb
+						INNER JOIN "TeamMember" tm ON tm.team_id = ttb.team_id
+					WHERE tm.user_id = $1
+				) p ON p.board_id = b.id
+			WHERE (b.workspace_id IS NULL OR b.workspace_id IN (
+				SELECT workspace_id FROM "WorkspaceMember" WHERE user_id = $1
+			)) AND b.title ILIKE $2
+			GROUP BY b.id
+		) boards
+		WHERE ($3 = 0 OR privilege = $3) AND %s
+		%s;
+	`
+	// CanShareBoardWithQuery checks, that the board of the workspace is shared with its member. Personal board
+	// is shared with the users, who share a workspace with its author ($3 is the author privilege)
+	CanShareBoardWithQuery = `
+		SELECT CASE WHEN b.workspace_id IS NOT NULL THEN EXISTS(
+				SELECT 1 FROM "WorkspaceMember" wm WHERE wm.workspace_id = b.workspace_id AND wm.user_id = $2
+			) ELSE NOT EXISTS(
+				SELECT 1 FROM "UserToBoard" utb WHERE utb.board_id = b.id AND utb.access_type = $3 AND utb.user_id <> $2
+			) OR EXISTS(
+				SELECT 1 FROM "UserToBoard" utb
+					INNER JOIN "WorkspaceMember" author ON author.user_id = utb.user_id
+					INNER JOIN "WorkspaceMember" wm ON wm.workspace_id = author.workspace_id
+				WHERE utb.board_id = b.id AND utb.access_type = $3 AND wm.user_id = $2
+			) END FROM "Board" b WHERE b.id = $1;
 	`
 	GetPermissionOfRelationQuery = `
 		SELECT access_type, board_id, user_id FROM "UserToBoard" WHERE id = $1;
 	`
-	DeleteRelationsByBoardID = `
-		DELETE FROM "UserToBoard" WHERE board_id = $1;
+	// GetSubtreeQuery returns the board and all boards nested in it on any depth
+	GetSubtreeQuery = `
+		WITH RECURSIVE subtree AS (
+			SELECT $1::uuid AS id
+			UNION ALL
+			SELECT b2b.subboard_id FROM "BoardToBoard" b2b
+				INNER JOIN subtree ON b2b.root_board_id = subtree.id
+		)
+		SELECT id FROM subtree;
+	`
+	DeleteNotesOfBoardsQuery = `
+		DELETE FROM "Note" WHERE board_bridge_id IN (
+			SELECT id FROM "BoardToBoard" WHERE subboard_id = ANY($1::uuid[])
+		);
+	`
+	DeleteNestedRelationsOfBoardsQuery = `
+		DELETE FROM "BoardToBoard" WHERE subboard_id = ANY($1::uuid[]);
 	`
-	DeleteBoardByID = `
-		DELETE FROM "Board" WHERE id = $1;
+	DeleteRelationsOfBoardsQuery = `
+		DELETE FROM "UserToBoard" WHERE board_id = ANY($1::uuid[]);
+	`
+	DeleteBoardsQuery = `
+		DELETE FROM "Board" WHERE id = ANY($1::uuid[]);
 	`
 	NewNestedBoardRelation = `
 		INSERT INTO "BoardToBoard"(root_board_id, subboard_id) VALUES ($1, $2) returning id;
@@ -43,14 +98,74 @@ const (
 	GetNestedBoardsQuery = `
 		SELECT b2b.id, b2b.subboard_id, b.created_at, b.title from "BoardToBoard" b2b 
 			inner join "Board" b on b.id = b2b.subboard_id
-		where root_board_id = $1;
-	`
-	DeleteNestedBoardRelation = `
-		DELETE FROM "BoardToBoard" where subboard_id = $1;
+		where root_board_id = $1 ORDER BY b.created_at;
 	`
 	GetRootOfSideBoardQuery = `
-    	SELECT root_board_id from "BoardToBoard" where subboard_id = $1;
-    `
+		WITH RECURSIVE ancestors AS (
+			SELECT $1::uuid AS id, 0 AS depth
+			UNION ALL
+			SELECT b2b.root_board_id, ancestors.depth + 1 FROM "BoardToBoard" b2b
+				INNER JOIN ancestors ON b2b.subboard_id = ancestors.id
+		)
+		SELECT id FROM ancestors ORDER BY depth DESC LIMIT 1;
+	`
+	GetPermissionOfUserQuery = `
+		WITH RECURSIVE ancestors AS (
+			SELECT $1::uuid AS id, 0 AS depth
+			UNION ALL
+			SELECT b2b.root_board_id, ancestors.depth + 1 FROM "BoardToBoard" b2b
+				INNER JOIN ancestors ON b2b.subboard_id = ancestors.id
+		), root AS (
+			SELECT id FROM ancestors ORDER BY depth DESC LIMIT 1
+		)
+		SELECT b.id, b.workspace_id IS NULL OR EXISTS(
+				SELECT 1 FROM "WorkspaceMember" wm WHERE wm.workspace_id = b.workspace_id AND wm.user_id = $2
+			), coalesce(array_agg(p.access_type) FILTER (WHERE p.access_type IS NOT NULL), '{}') FROM root
+			INNER JOIN "Board" b ON b.id = root.id
+			LEFT JOIN (
+				SELECT board_id, access_type FROM "UserToBoard" WHERE user_id = $2
+				UNION ALL
+				SELECT ttb.board_id, ttb.access_type FROM "TeamToBoard" ttb
+					INNER JOIN "TeamMember" tm ON tm.team_id = ttb.team_id
+				WHERE tm.user_id = $2
+			) p ON p.board_id = b.id
+		GROUP BY b.id;
+	`
+	GetCollaboratorsQuery = `
+		SELECT utb.id, u.id, u.username, utb.access_type, utb.description, utb.created_at FROM "UserToBoard" utb
+			INNER JOIN "Users" u ON u.id = utb.user_id
+		WHERE utb.board_id = $1 ORDER BY utb.created_at;
+	`
+	GetPrivilegeOfCollaboratorQuery = `
+		SELECT access_type FROM "UserToBoard" WHERE board_id = $1 AND user_id = $2;
+	`
+	LockCollaboratorQuery = `
+		SELECT access_type FROM "UserToBoard" WHERE board_id = $1 AND user_id = $2 FOR UPDATE;
+	`
+	UpdateCollaboratorQuery = `
+		UPDATE "UserToBoard" SET access_type = $1 WHERE board_id = $2 AND user_id = $3;
+	`
+	DeleteCollaboratorQuery = `
+		DELETE FROM "UserToBoard" WHERE board_id = $1 AND user_id = $2;
+	`
+	InsertBoardTransferQuery = `
+		INSERT INTO "BoardTransfer"(board_id, from_user_id, to_user_id, keep_access)
+			VALUES($1, $2, $3, $4) RETURNING id, created_at;
+	`
+	GetBoardTreeQuery = `
+		WITH RECURSIVE tree AS (
+			SELECT b.id, NULL::uuid AS parent_id, b.title, b.created_at, 0 AS depth FROM "Board" b
+			WHERE b.id = $1
+			UNION ALL
+			SELECT b.id, b2b.root_board_id, b.title, b.created_at, tree.depth + 1 FROM "BoardToBoard" b2b
+				INNER JOIN "Board" b ON b.id = b2b.subboard_id
+				INNER JOIN tree ON tree.id = b2b.root_board_id
+			WHERE tree.depth < $2
+		)
+		SELECT t.id, t.parent_id, t.title, t.created_at,
+			(SELECT count(*) FROM "BoardToBoard" c WHERE c.root_board_id = t.id)
+		FROM tree t ORDER BY t.depth, t.created_at;
+	`
 )
 
 const (
@@ -61,231 +176,559 @@ type BoardRepository struct {
 	store *Store
 }
 
-func (br *BoardRepository) NewRootBoard(user *model.User, title string) (*model.Board, error) {
+// NewRootBoard creates the personal board of user, that doesn't belong to any workspace
+func (br *BoardRepository) NewRootBoard(ctx context.Context, user *model.User, title string) (*model.Board, error) {
+	return br.NewWorkspaceBoard(ctx, uuid.Nil, user, title)
+}
+
+// NewWorkspaceBoard creates the root board inside the workspace. User must be a member of it
+func (br *BoardRepository) NewWorkspaceBoard(ctx context.Context, workspaceID uuid.UUID, user *model.User, title string) (*model.Board, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.NewWorkspaceBoard", tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
 	board := model.NewBoard(title)
+	board.WorkspaceID = workspaceID
+	board.Privilege = model.PrivilegeAuthor
 
 	if err := board.Base.Validate(); err != nil {
 		return nil, err
 	}
-
-	// Save board
-	if err := br.store.db.QueryRow(InsertBoardQuery, title).Scan(&board.Base.ID, &board.Base.CreatedAt); err != nil {
-		return nil, err
+	if workspaceID != uuid.Nil {
+		if _, err := (&WorkspaceRepository{store: br.store}).roleOfUser(ctx, workspaceID, user.ID); err != nil {
+			return nil, err
+		}
 	}
 
-	// Create author relation
-	rel, err := br.CreateRelation(board.Base.ID, user.ID, DescriptionAllGranted, model.PrivilegeAuthor)
+	// Board and its author relation are saved atomically
+	err := br.store.withTx(ctx, func(txStore *Store) error {
+		row := txStore.db.QueryRowContext(ctx, InsertBoardQuery, title, uuid.NullUUID{UUID: workspaceID, Valid: workspaceID != uuid.Nil})
+		if err := row.Scan(&board.Base.ID, &board.Base.CreatedAt); err != nil {
+			return err
+		}
+
+		rel, err := txStore.Board().CreateRelation(ctx, board.Base.ID, user.ID, DescriptionAllGranted, model.PrivilegeAuthor)
+		if err != nil {
+			return err
+		}
+		board.AddRelation(rel)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	board.AddRelation(rel)
 	return board, nil
 }
 
-func (br *BoardRepository) GetRootBoardsOfUser(user *model.User) ([]*model.Board, error) {
-	boardsMap := make(map[uuid.UUID]*model.Board)
+// GetRootBoardsOfUser returns the page of root boards, that user can access directly or via teams
+func (br *BoardRepository) GetRootBoardsOfUser(ctx context.Context, user *model.User, opts *model.ListOptions) (*model.Page[*model.Board], error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.GetRootBoardsOfUser", tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	columns := pageColumns{title: "title", createdAt: "created_at", id: "id"}
+	condition, order, pageArgs := pageClause(opts, columns, 4)
+	args := append([]any{user.ID, likePattern(opts.Title), int(opts.Privilege)}, pageArgs...)
 
 	// Query for boards, close Row on function exit
-	boardRows, err := br.store.db.Query(GetBoardsOfUserQuery, user.ID)
+	boardRows, err := br.store.db.QueryContext(ctx, fmt.Sprintf(GetBoardsOfUserQuery, condition, order), args...)
 	if err != nil {
 		return nil, err
 	}
 	defer boardRows.Close()
 
-	// Iterate over all rows, add
+	boards := make([]*model.Board, 0, opts.Limit+1)
 	for boardRows.Next() {
-		var relationID uuid.UUID
+		var workspaceID uuid.NullUUID
+		var relations pq.StringArray
 		board := model.NewBoard("default")
 
 		// Just scan the row into board instance
-		if err := boardRows.Scan(&board.Base.ID, &board.Base.Title, &board.Base.CreatedAt, &relationID); err != nil {
+		err := boardRows.Scan(&board.Base.ID, &board.Base.Title, &board.Base.CreatedAt, &workspaceID, &board.Privilege, &relations)
+		if err != nil {
 			return nil, err
 		}
+		board.WorkspaceID = workspaceID.UUID
 
-		// Get all relations for boards
-		savedBoard, found := boardsMap[board.Base.ID]
-		if !found {
+		// Boards reachable via teams have no user relation
+		for _, relation := range relations {
+			relationID, err := uuid.Parse(relation)
+			if err != nil {
+				return nil, err
+			}
 			board.AddRelation(relationID)
-			boardsMap[board.Base.ID] = board
-		} else {
-			savedBoard.AddRelation(relationID)
 		}
+		boards = append(boards, board)
+	}
+	if err := boardRows.Err(); err != nil {
+		return nil, err
 	}
 
-	return mapBoardValues(boardsMap), nil
+	return model.NewPage(boards, opts, func(board *model.Board) *model.Cursor {
+		return opts.CursorFor(board.Base.Title, board.Base.CreatedAt, board.Base.ID)
+	}), nil
 }
 
-func (br *BoardRepository) CreateRelation(boardID, userID uuid.UUID, desc string, ac model.PrivilegeType) (uuid.UUID, error) {
+func (br *BoardRepository) CreateRelation(ctx context.Context, boardID, userID uuid.UUID, desc string, ac model.PrivilegeType) (uuid.UUID, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.CreateRelation", tracing.BoardID(boardID), tracing.UserID(userID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	// Boards are shared inside the workspace only
+	if err := br.checkCanShareWith(ctx, boardID, userID); err != nil {
+		return uuid.Nil, err
+	}
+
 	var authorRelation uuid.UUID
-	relationRow := br.store.db.QueryRow(InsertBoardRelationQuery, boardID, userID, ac, desc)
+	relationRow := br.store.db.QueryRowContext(ctx, InsertBoardRelationQuery, boardID, userID, ac, desc)
 	if err := relationRow.Scan(&authorRelation); err != nil {
+		// User can have only one relation with the board
+		if strings.Contains(err.Error(), "duplicate") {
+			return uuid.Nil, storage.ErrEntityDuplicate
+		}
 		return uuid.Nil, err
 	}
 	return authorRelation, nil
 }
 
-func (br *BoardRepository) GetPrivilegeFromRelation(relationID uuid.UUID) (*model.BoardPermission, error) {
+func (br *BoardRepository) GetPrivilegeFromRelation(ctx context.Context, relationID uuid.UUID) (*model.BoardPermission, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.GetPrivilegeFromRelation")
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
 	bp := model.BoardPermission{}
-	return &bp, br.store.db.QueryRow(GetPermissionOfRelationQuery, relationID).Scan(&bp.Privilege, &bp.BoardID, &bp.UserID)
+	return &bp, br.store.db.QueryRowContext(ctx, GetPermissionOfRelationQuery, relationID).Scan(&bp.Privilege, &bp.BoardID, &bp.UserID)
 }
 
-func (br *BoardRepository) DeleteRootBoard(boardID uuid.UUID, relations []uuid.UUID, user *model.User) error {
-	// Security check
-	for _, relation := range relations {
-		bp, err := br.GetPrivilegeFromRelation(relation)
-
-		if boardID != bp.BoardID || bp.UserID != user.ID {
-			return storage.ErrSecurityError
+// GetPermissionOfUser returns the strongest privilege of user on the root of the board in a single query.
+// Zero privilege means that user has no relations with the board.
+func (br *BoardRepository) GetPermissionOfUser(ctx context.Context, boardID, userID uuid.UUID) (*model.BoardPermission, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.GetPermissionOfUser", tracing.BoardID(boardID), tracing.UserID(userID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	bp := model.BoardPermission{UserID: userID}
+	var inWorkspace bool
+	var privileges pq.Int64Array
+	if err := br.store.db.QueryRowContext(ctx, GetPermissionOfUserQuery, boardID, userID).Scan(&bp.BoardID, &inWorkspace, &privileges); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
 		}
+		return nil, err
+	}
 
-		if err != nil {
-			if strings.Contains(err.Error(), "no rows") {
-				return storage.ErrNotFound
-			}
-			return storage.ErrSecurityError
-		}
-		// Then, we have permissions to delete a board
-		if bp.Privilege == model.PrivilegeAuthor {
-			if _, err := br.store.db.Exec(DeleteRelationsByBoardID, boardID); err != nil {
-				return err
-			}
-			_, err := br.store.db.Exec(DeleteBoardByID, boardID)
-			return err
-		}
+	// Relations of former workspace members don't work anymore
+	if !inWorkspace {
+		return &bp, nil
 	}
 
-	// Not an author! Refuse request
-	return storage.ErrSecurityError
+	for _, privilege := range privileges {
+		bp.Privilege = model.StrongestPrivilege(bp.Privilege, model.PrivilegeType(privilege))
+	}
+	return &bp, nil
 }
 
-func (br *BoardRepository) GetBoardInfo(boardID uuid.UUID) (*model.Board, error) {
+func (br *BoardRepository) DeleteRootBoard(ctx context.Context, boardID uuid.UUID, user *model.User) error {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.DeleteRootBoard", tracing.BoardID(boardID), tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	if err := br.permitRootBoardAction(ctx, boardID, authorization.DeleteRootBoard, user); err != nil {
+		return err
+	}
+
+	return br.store.withTx(ctx, func(txStore *Store) error {
+		return deleteSubtree(ctx, txStore, boardID)
+	})
+}
+
+func (br *BoardRepository) GetBoardInfo(ctx context.Context, boardID uuid.UUID) (*model.Board, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.GetBoardInfo", tracing.BoardID(boardID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
 	board := model.NewBoard("default")
 	board.Base.ID = boardID
 
 	// Get relations
-	relationsRows, err := br.store.db.Query(GetRelationsOfBoardQuery, boardID)
+	relationsRows, err := br.store.db.QueryContext(ctx, GetRelationsOfBoardQuery, boardID)
 	if err != nil {
 		return nil, err
 	}
 	for relationsRows.Next() {
 		var relationID uuid.UUID
-		if err := relationsRows.Scan(&relationID, &board.Base.Title, &board.Base.CreatedAt); err != nil {
+		var workspaceID uuid.NullUUID
+		if err := relationsRows.Scan(&relationID, &board.Base.Title, &board.Base.CreatedAt, &workspaceID); err != nil {
 			return nil, err
 		}
+		board.WorkspaceID = workspaceID.UUID
 		board.AddRelation(relationID)
 	}
 	return board, nil
 }
 
-func (br *BoardRepository) GetRootOfNestedBoard(boardID uuid.UUID) (*model.Board, error) {
+// GetRootOfNestedBoard walks up the hierarchy in a single recursive query and returns the root board
+func (br *BoardRepository) GetRootOfNestedBoard(ctx context.Context, boardID uuid.UUID) (*model.Board, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.GetRootOfNestedBoard", tracing.BoardID(boardID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
 	var rootID uuid.UUID
 
-	err := br.store.db.QueryRow(GetRootOfSideBoardQuery, boardID).Scan(&rootID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return br.GetBoardInfo(boardID)
-		}
+	if err := br.store.db.QueryRowContext(ctx, GetRootOfSideBoardQuery, boardID).Scan(&rootID); err != nil {
 		return nil, err
 	}
-	return br.GetRootOfNestedBoard(rootID)
+	return br.GetBoardInfo(ctx, rootID)
 }
 
-func (br *BoardRepository) NewNestedBoard(rootBoardID uuid.UUID, title string, user *model.User) (*model.NestedBoard, error) {
-	rootBoard, err := br.GetRootOfNestedBoard(rootBoardID)
-	if err != nil {
+func (br *BoardRepository) NewNestedBoard(ctx context.Context, rootBoardID uuid.UUID, title string, user *model.User) (*model.NestedBoard, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.NewNestedBoard", tracing.BoardID(rootBoardID), tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	if err := (&model.BaseBoard{Title: title}).Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := authorization.New(br).Can(ctx, user, authorization.CreateNestedBoard, rootBoardID); err != nil {
 		return nil, err
 	}
-	for _, rel := range rootBoard.U2BRelations {
-		bp, err := br.GetPrivilegeFromRelation(rel)
+
+	nestedBoard := model.NestedBoard{
+		Base: model.BaseBoard{
+			Title: title,
+		},
+		RootBoard: rootBoardID,
+	}
+
+	err := br.store.withTx(ctx, func(txStore *Store) error {
+		// Nested boards belong to the workspace of the root
+		err := txStore.db.QueryRowContext(ctx, InsertBoardQuery, title, nil).Scan(&nestedBoard.Base.ID, &nestedBoard.Base.CreatedAt)
 		if err != nil {
-			return nil, storage.ErrSecurityError
+			return err
 		}
+		return txStore.db.QueryRowContext(ctx, NewNestedBoardRelation, rootBoardID, nestedBoard.Base.ID).Scan(&nestedBoard.RelationID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &nestedBoard, nil
+}
 
-		if (bp.Privilege == model.PrivilegeReadWrite || bp.Privilege == model.PrivilegeAuthor) && bp.UserID == user.ID {
-			// Save the board
-			nestedBoard := model.NestedBoard{
-				Base: model.BaseBoard{
-					Title: title,
-				},
-				RootBoard: rootBoardID,
-			}
+func (br *BoardRepository) GetNestedBoards(ctx context.Context, rootBoardID uuid.UUID, user *model.User) ([]*model.NestedBoard, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.GetNestedBoards", tracing.BoardID(rootBoardID), tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
 
-			err := br.store.db.QueryRow(InsertBoardQuery, title).Scan(&nestedBoard.Base.ID, &nestedBoard.Base.CreatedAt)
-			if err != nil {
-				return nil, err
-			}
-			err = br.store.db.QueryRow(NewNestedBoardRelation, rootBoardID, nestedBoard.Base.ID).Scan(&nestedBoard.RelationID)
-			if err != nil {
-				return nil, err
-			}
-			return &nestedBoard, nil
+	if err := authorization.New(br).Can(ctx, user, authorization.ViewBoard, rootBoardID); err != nil {
+		return nil, err
+	}
+
+	nestedBoardsRows, err := br.store.db.QueryContext(ctx, GetNestedBoardsQuery, rootBoardID)
+	if err != nil {
+		return nil, err
+	}
+	defer nestedBoardsRows.Close()
+
+	boards := make([]*model.NestedBoard, 0)
+	for nestedBoardsRows.Next() {
+		board := model.NestedBoard{
+			Base:      model.BaseBoard{},
+			RootBoard: rootBoardID,
+		}
+		if err := nestedBoardsRows.Scan(&board.RelationID, &board.Base.ID, &board.Base.CreatedAt, &board.Base.Title); err != nil {
+			return nil, err
 		}
+		boards = append(boards, &board)
 	}
-	return nil, storage.ErrSecurityError
+	return boards, nestedBoardsRows.Err()
 }
 
-func (br *BoardRepository) GetNestedBoards(rootBoardID uuid.UUID, user *model.User) ([]*model.NestedBoard, error) {
-	rootBoard, err := br.GetRootOfNestedBoard(rootBoardID)
+// GetBoardTree returns the whole hierarchy under the board, limited by maxDepth levels.
+// Any permission on the root board allows user to see the tree.
+func (br *BoardRepository) GetBoardTree(ctx context.Context, boardID uuid.UUID, maxDepth int, user *model.User) (*model.BoardTree, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.GetBoardTree", tracing.BoardID(boardID), tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	if err := authorization.New(br).Can(ctx, user, authorization.ViewBoard, boardID); err != nil {
+		return nil, err
+	}
+	return br.boardTree(ctx, boardID, maxDepth)
+}
+
+// boardTree builds the hierarchy under the board without any permission checks
+func (br *BoardRepository) boardTree(ctx context.Context, boardID uuid.UUID, maxDepth int) (*model.BoardTree, error) {
+	treeRows, err := br.store.db.QueryContext(ctx, GetBoardTreeQuery, boardID, maxDepth)
 	if err != nil {
 		return nil, err
 	}
-	for _, rel := range rootBoard.U2BRelations {
-		bp, err := br.GetPrivilegeFromRelation(rel)
-		if err != nil {
-			return nil, storage.ErrSecurityError
-		}
+	defer treeRows.Close()
 
-		// Any permission allows user to see the nested boards
-		if bp.UserID == user.ID {
-			boards := make([]*model.NestedBoard, 0)
-			nestedBoardsRows, err := br.store.db.Query(GetNestedBoardsQuery, rootBoardID)
-			if err != nil {
-				return nil, err
-			}
+	// Rows are ordered by depth, so parent is always scanned before its children
+	var root *model.BoardTree
+	nodes := make(map[uuid.UUID]*model.BoardTree)
+	for treeRows.Next() {
+		var parentID uuid.NullUUID
+		node := model.NewBoardTree(model.BaseBoard{})
 
-			for nestedBoardsRows.Next() {
-				board := model.NestedBoard{
-					Base:      model.BaseBoard{},
-					RootBoard: rootBoardID,
-				}
-				if err := nestedBoardsRows.Scan(&board.RelationID, &board.Base.ID, &board.Base.CreatedAt, &board.Base.Title); err != nil {
-					return nil, err
-				}
-				boards = append(boards, &board)
+		if err := treeRows.Scan(&node.Base.ID, &parentID, &node.Base.Title, &node.Base.CreatedAt, &node.ChildCount); err != nil {
+			return nil, err
+		}
+		nodes[node.Base.ID] = node
 
-			}
-			return boards, nil
+		if !parentID.Valid {
+			root = node
+		} else if parent, found := nodes[parentID.UUID]; found {
+			parent.Children = append(parent.Children, node)
 		}
 	}
-	return nil, storage.ErrSecurityError
+	if err := treeRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if root == nil {
+		return nil, storage.ErrNotFound
+	}
+	return root, nil
+}
+
+func (br *BoardRepository) DeleteNestedBoard(ctx context.Context, boardID uuid.UUID, user *model.User) error {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.DeleteNestedBoard", tracing.BoardID(boardID), tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	if err := br.permitNestedBoardAction(ctx, boardID, authorization.DeleteNestedBoard, user); err != nil {
+		return err
+	}
+
+	return br.store.withTx(ctx, func(txStore *Store) error {
+		return deleteSubtree(ctx, txStore, boardID)
+	})
 }
 
-func (br *BoardRepository) DeleteNestedBoard(boardID uuid.UUID, user *model.User) error {
-	rootBoard, err := br.GetRootOfNestedBoard(boardID)
+// deleteSubtree removes the board with all nested boards and their notes. Rows are removed
+// from leaves to the root, so foreign keys of the remaining rows are never broken
+func deleteSubtree(ctx context.Context, txStore *Store, boardID uuid.UUID) error {
+	subtreeRows, err := txStore.db.QueryContext(ctx, GetSubtreeQuery, boardID)
 	if err != nil {
 		return err
 	}
-	for _, rel := range rootBoard.U2BRelations {
-		bp, err := br.GetPrivilegeFromRelation(rel)
+	defer subtreeRows.Close()
+
+	var subtree pq.StringArray
+	for subtreeRows.Next() {
+		var id string
+		if err := subtreeRows.Scan(&id); err != nil {
+			return err
+		}
+		subtree = append(subtree, id)
+	}
+	if err := subtreeRows.Err(); err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		DeleteNotesOfBoardsQuery,
+		DeleteNestedRelationsOfBoardsQuery,
+		DeleteRelationsOfBoardsQuery,
+		DeleteBoardsQuery,
+	} {
+		if _, err := txStore.db.ExecContext(ctx, query, subtree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCollaborators returns all users related to the root board, including the author
+func (br *BoardRepository) GetCollaborators(ctx context.Context, boardID uuid.UUID, user *model.User) ([]*model.Collaborator, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.GetCollaborators", tracing.BoardID(boardID), tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	if err := br.permitRootBoardAction(ctx, boardID, authorization.ManageCollaborators, user); err != nil {
+		return nil, err
+	}
+
+	rows, err := br.store.db.QueryContext(ctx, GetCollaboratorsQuery, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collaborators := make([]*model.Collaborator, 0)
+	for rows.Next() {
+		collaborator := model.Collaborator{}
+		err := rows.Scan(&collaborator.RelationID, &collaborator.UserID, &collaborator.Username,
+			&collaborator.Privilege, &collaborator.Description, &collaborator.GrantedAt)
 		if err != nil {
-			return storage.ErrSecurityError
+			return nil, err
 		}
+		collaborators = append(collaborators, &collaborator)
+	}
+	return collaborators, rows.Err()
+}
 
-		if (bp.Privilege == model.PrivilegeAuthor || bp.Privilege == model.PrivilegeReadWrite) && user.ID == bp.UserID {
-			if _, err := br.store.db.Exec(DeleteNestedBoardRelation, boardID); err != nil {
+// UpdateCollaborator changes the privilege of the collaborator. Only ro and rw privileges can be set,
+// the author relation can't be changed.
+func (br *BoardRepository) UpdateCollaborator(ctx context.Context, boardID, collaboratorID uuid.UUID, privilegeType model.PrivilegeType, user *model.User) error {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.UpdateCollaborator", tracing.BoardID(boardID), tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	if privilegeType != model.PrivilegeReadOnly && privilegeType != model.PrivilegeReadWrite {
+		return storage.ErrSecurityError
+	}
+	if err := br.permitRootBoardAction(ctx, boardID, authorization.ManageCollaborators, user); err != nil {
+		return err
+	}
+	if err := br.checkCollaborator(ctx, boardID, collaboratorID); err != nil {
+		return err
+	}
+
+	_, err := br.store.db.ExecContext(ctx, UpdateCollaboratorQuery, privilegeType, boardID, collaboratorID)
+	return err
+}
+
+// RevokeCollaborator removes the relation of the collaborator with the board. The author can't be revoked.
+func (br *BoardRepository) RevokeCollaborator(ctx context.Context, boardID, collaboratorID uuid.UUID, user *model.User) error {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.RevokeCollaborator", tracing.BoardID(boardID), tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	if err := br.permitRootBoardAction(ctx, boardID, authorization.ManageCollaborators, user); err != nil {
+		return err
+	}
+	if err := br.checkCollaborator(ctx, boardID, collaboratorID); err != nil {
+		return err
+	}
+
+	_, err := br.store.db.ExecContext(ctx, DeleteCollaboratorQuery, boardID, collaboratorID)
+	return err
+}
+
+// TransferBoard hands authorship of the root board to one of its collaborators. Previous author is
+// demoted to rw or loses access at all. Transfer is saved to the audit log in the same transaction.
+func (br *BoardRepository) TransferBoard(ctx context.Context, boardID, newAuthorID uuid.UUID, keepAccess bool, user *model.User) (*model.BoardTransfer, error) {
+	ctx, span := br.store.startSpan(ctx, "BoardRepository.TransferBoard", tracing.BoardID(boardID), tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := br.store.queryContext(ctx)
+	defer cancel()
+
+	transfer := model.BoardTransfer{
+		BoardID:    boardID,
+		FromUserID: user.ID,
+		ToUserID:   newAuthorID,
+		KeepAccess: keepAccess,
+	}
+	err := br.store.withTx(ctx, func(txStore *Store) error {
+		// Relation of the author is locked, so concurrent transfers wait for each other and
+		// the next one sees, that user isn't the author anymore
+		if _, err := txStore.db.ExecContext(ctx, LockCollaboratorQuery, boardID, user.ID); err != nil {
+			return err
+		}
+		txBoards := &BoardRepository{store: txStore}
+		if err := txBoards.permitRootBoardAction(ctx, boardID, authorization.TransferBoard, user); err != nil {
+			return err
+		}
+		if err := txBoards.checkCollaborator(ctx, boardID, newAuthorID); err != nil {
+			return err
+		}
+		if err := txBoards.checkCanShareWith(ctx, boardID, newAuthorID); err != nil {
+			return err
+		}
+
+		if _, err := txStore.db.ExecContext(ctx, UpdateCollaboratorQuery, model.PrivilegeAuthor, boardID, newAuthorID); err != nil {
+			return err
+		}
+		if keepAccess {
+			if _, err := txStore.db.ExecContext(ctx, UpdateCollaboratorQuery, model.PrivilegeReadWrite, boardID, user.ID); err != nil {
 				return err
 			}
-			_, err := br.store.db.Exec(DeleteBoardByID, boardID)
+		} else if _, err := txStore.db.ExecContext(ctx, DeleteCollaboratorQuery, boardID, user.ID); err != nil {
 			return err
 		}
+
+		row := txStore.db.QueryRowContext(ctx, InsertBoardTransferQuery, boardID, user.ID, newAuthorID, keepAccess)
+		return row.Scan(&transfer.ID, &transfer.CreatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// checkCanShareWith fails with storage.ErrSecurityError if user isn't a member of the board workspace.
+// Personal boards are shared with users, who share any workspace with the author
+func (br *BoardRepository) checkCanShareWith(ctx context.Context, boardID, userID uuid.UUID) error {
+	var allowed bool
+	if err := br.store.db.QueryRowContext(ctx, CanShareBoardWithQuery, boardID, userID, model.PrivilegeAuthor).Scan(&allowed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		return err
+	}
+	if !allowed {
+		return storage.ErrSecurityError
+	}
+	return nil
+}
+
+// permitRootBoardAction checks that the board is a root one and user is allowed to perform the action
+func (br *BoardRepository) permitRootBoardAction(ctx context.Context, boardID uuid.UUID, action authorization.Action, user *model.User) error {
+	bp, err := authorization.New(br).Permission(ctx, user, boardID)
+	if err != nil {
+		return err
+	}
+	if bp.BoardID != boardID || !authorization.Allowed(bp.Privilege, action) {
+		return storage.ErrSecurityError
+	}
+	return nil
+}
+
+// permitNestedBoardAction checks that the board is a nested one and user is allowed to perform the action
+func (br *BoardRepository) permitNestedBoardAction(ctx context.Context, boardID uuid.UUID, action authorization.Action, user *model.User) error {
+	bp, err := authorization.New(br).Permission(ctx, user, boardID)
+	if err != nil {
+		return err
+	}
+	if bp.BoardID == boardID || !authorization.Allowed(bp.Privilege, action) {
+		return storage.ErrSecurityError
 	}
-	return storage.ErrSecurityError
+	return nil
 }
 
-func mapBoardValues(boards map[uuid.UUID]*model.Board) []*model.Board {
-	boardsSlice := make([]*model.Board, 0, len(boards))
-	for _, val := range boards {
-		boardsSlice = append(boardsSlice, val)
+// checkCollaborator makes sure that the user is related to the board and isn't its author
+func (br *BoardRepository) checkCollaborator(ctx context.Context, boardID, collaboratorID uuid.UUID) error {
+	var privilege model.PrivilegeType
+	if err := br.store.db.QueryRowContext(ctx, GetPrivilegeOfCollaboratorQuery, boardID, collaboratorID).Scan(&privilege); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		return err
+	}
+	if privilege == model.PrivilegeAuthor {
+		return storage.ErrSecurityError
 	}
-	return boardsSlice
+	return nil
 }
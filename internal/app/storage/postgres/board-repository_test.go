@@ -1,6 +1,9 @@
 package postgres_test
 
 import (
+	"context"
+	"strconv"
+	"sync"
 	"testing"
 
 	"Gotcha/internal/app/model"
@@ -11,6 +14,7 @@ import (
 )
 
 func TestBoardRepository_NewRootBoard(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	store := postgres.NewStore(db)
 	userRepo := store.User()
@@ -18,81 +22,106 @@ func TestBoardRepository_NewRootBoard(t *testing.T) {
 	defer sanitize("Users", "UserToBoard", "Board")
 
 	testUser := model.TestUser(t)
-	_ = userRepo.SaveUser(testUser)
+	_ = userRepo.SaveUser(ctx, testUser)
 
-	board, err := boardRepo.NewRootBoard(testUser, "Hello world!")
+	board, err := boardRepo.NewRootBoard(ctx, testUser, "Hello world!")
 	assert.NoError(t, err, "Failed to create root board")
 	assert.False(t, board.Base.CreatedAt.IsZero(), "Board not created: time not added")
 	assert.Len(t, board.U2BRelations, 1, "Board not created: Owner relation not added")
 }
 
 func TestBoardRepository_Relations(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	store := postgres.NewStore(db)
 	userRepo := store.User()
 	boardRepo := store.Board()
-	defer sanitize("Users", "UserToBoard", "Board")
+	defer sanitize("Users", "UserToBoard", "Board", "Workspace")
 
 	testUser := model.TestUser(t)
-	_ = userRepo.SaveUser(testUser)
+	_ = userRepo.SaveUser(ctx, testUser)
+
+	anotherUser := model.TestUser(t)
+	anotherUser.Username += "another"
+	anotherUser.Email += "another"
+	_ = userRepo.SaveUser(ctx, anotherUser)
 
-	testBoard, _ := boardRepo.NewRootBoard(testUser, "Example root board")
-	relationID, err := boardRepo.CreateRelation(testBoard.Base.ID, testUser.ID, postgres.DescriptionAllGranted, model.PrivilegeReadOnly)
+	testBoard, _ := boardRepo.NewRootBoard(ctx, testUser, "Example root board")
+	storage.TestColleagues(t, store, testUser, anotherUser)
+	relationID, err := boardRepo.CreateRelation(ctx, testBoard.Base.ID, anotherUser.ID, postgres.DescriptionAllGranted, model.PrivilegeReadOnly)
 	assert.NoError(t, err, "Failed to create relation")
 
-	bp, err := boardRepo.GetPrivilegeFromRelation(relationID)
+	// Only one relation of user with the board is allowed
+	_, err = boardRepo.CreateRelation(ctx, testBoard.Base.ID, anotherUser.ID, postgres.DescriptionAllGranted, model.PrivilegeReadWrite)
+	assert.ErrorIs(t, err, storage.ErrEntityDuplicate, "Created duplicate relation")
+
+	bp, err := boardRepo.GetPrivilegeFromRelation(ctx, relationID)
 	assert.NoError(t, err, "Failed to get relation privilege")
 	assert.Equal(t, bp.Privilege, model.PrivilegeReadOnly)
 	assert.Equal(t, bp.BoardID, testBoard.Base.ID)
 }
 
 func TestBoardRepository_DeleteRootBoard(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	store := postgres.NewStore(db)
 	userRepo := store.User()
 	boardRepo := store.Board()
-	defer sanitize("Users", "UserToBoard", "Board")
+	defer sanitize("Users", "UserToBoard", "BoardToBoard", "Board", "Note")
 
 	testUser := model.TestUser(t)
-	_ = userRepo.SaveUser(testUser)
+	_ = userRepo.SaveUser(ctx, testUser)
 
-	testBoard, _ := boardRepo.NewRootBoard(testUser, "Example root board")
-	assert.NoError(t, boardRepo.DeleteRootBoard(testBoard.Base.ID, testBoard.U2BRelations, testUser), "Failed to delete board")
-	boards, _ := boardRepo.GetRootBoardsOfUser(testUser)
-	assert.Equal(t, len(boards), 0, "Board still exists in database")
+	testBoard, _ := boardRepo.NewRootBoard(ctx, testUser, "Example root board")
+	assert.NoError(t, boardRepo.DeleteRootBoard(ctx, testBoard.Base.ID, testUser), "Failed to delete board")
+	boards, _ := boardRepo.GetRootBoardsOfUser(ctx, testUser, model.DefaultListOptions())
+	assert.Equal(t, len(boards.Items), 0, "Board still exists in database")
 
-	// Check if we can delete a board with incorrect relations
-	testBoard, _ = boardRepo.NewRootBoard(testUser, "Example root board")
-	anotherBoard, _ := boardRepo.NewRootBoard(testUser, "Example root board")
+	// Check if we can delete a nested board as a root one
+	testBoard, _ = boardRepo.NewRootBoard(ctx, testUser, "Example root board")
+	nestedBoard, _ := boardRepo.NewNestedBoard(ctx, testBoard.Base.ID, "Example nested board", testUser)
 	assert.ErrorIs(t,
-		boardRepo.DeleteRootBoard(testBoard.Base.ID, anotherBoard.U2BRelations, testUser),
-		storage.ErrSecurityError, "Deleted table with fake relations")
+		boardRepo.DeleteRootBoard(ctx, nestedBoard.Base.ID, testUser),
+		storage.ErrSecurityError, "Deleted nested board as a root one")
 
 	// Check if we can delete a board as granted user (not owner)
 	anotherUser := model.TestUser(t)
 	anotherUser.Username += "another"
 	anotherUser.Email += "another"
 
-	newRelation, _ := boardRepo.CreateRelation(testBoard.Base.ID, anotherUser.ID, "RW access for my friend", model.PrivilegeReadWrite)
+	_, _ = boardRepo.CreateRelation(ctx, testBoard.Base.ID, anotherUser.ID, "RW access for my friend", model.PrivilegeReadWrite)
 
 	assert.ErrorIs(t,
-		boardRepo.DeleteRootBoard(testBoard.Base.ID, []uuid.UUID{newRelation}, anotherUser),
+		boardRepo.DeleteRootBoard(ctx, testBoard.Base.ID, anotherUser),
 		storage.ErrSecurityError, "Server allows you to delete a board as a non-owner")
+
+	// Whole tree is deleted with the root
+	totals, _ := store.Stats().GetTotals(ctx)
+	treeRoot, _ := boardRepo.NewRootBoard(ctx, testUser, "Tree root")
+	childBoard, _ := boardRepo.NewNestedBoard(ctx, treeRoot.Base.ID, "Child", testUser)
+	grandchildBoard, _ := boardRepo.NewNestedBoard(ctx, childBoard.Base.ID, "Grandchild", testUser)
+	_ = store.Note().NewNote(ctx, childBoard.Base.ID, &model.Note{Title: "Child note"}, testUser)
+	_ = store.Note().NewNote(ctx, grandchildBoard.Base.ID, &model.Note{Title: "Grandchild note"}, testUser)
+
+	assert.NoError(t, boardRepo.DeleteRootBoard(ctx, treeRoot.Base.ID, testUser), "Failed to delete board with nested boards")
+	totalsAfter, _ := store.Stats().GetTotals(ctx)
+	assert.Equal(t, totals, totalsAfter, "Nested boards or notes are left")
 }
 
 func TestBoardRepository_NewNestedBoard(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	store := postgres.NewStore(db)
 	userRepo := store.User()
 	boardRepo := store.Board()
-	defer sanitize("Users", "UserToBoard", "Board")
+	defer sanitize("Users", "UserToBoard", "Board", "Workspace")
 
 	// Create test assets
 	user := model.TestUser(t)
-	_ = userRepo.SaveUser(user)
-	rootBoard, _ := boardRepo.NewRootBoard(user, "Root")
+	_ = userRepo.SaveUser(ctx, user)
+	rootBoard, _ := boardRepo.NewRootBoard(ctx, user, "Root")
 
-	sideBoard, err := boardRepo.NewNestedBoard(rootBoard.Base.ID, "Nested", user)
+	sideBoard, err := boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", user)
 	assert.NoError(t, err, "Failed to create side board (got error)")
 	assert.NotNil(t, sideBoard, "Failed to create side board (it's nil)")
 	assert.NotEqual(t, sideBoard.RelationID, uuid.Nil, "Relation ID is nil")
@@ -102,17 +131,19 @@ func TestBoardRepository_NewNestedBoard(t *testing.T) {
 	secondUser.Username += "a"
 	secondUser.Email += "a"
 
-	_ = userRepo.SaveUser(secondUser)
-	_, err = boardRepo.NewNestedBoard(rootBoard.Base.ID, "Nested", secondUser)
+	_ = userRepo.SaveUser(ctx, secondUser)
+	_, err = boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", secondUser)
 	assert.Error(t, err, "Somehow created sideboard as user, that doesn't have write permission")
 
 	// Add rw permission
-	boardRepo.CreateRelation(rootBoard.Base.ID, secondUser.ID, "test", model.PrivilegeReadWrite)
-	_, err = boardRepo.NewNestedBoard(rootBoard.Base.ID, "Nested", secondUser)
+	storage.TestColleagues(t, store, user, secondUser)
+	boardRepo.CreateRelation(ctx, rootBoard.Base.ID, secondUser.ID, "test", model.PrivilegeReadWrite)
+	_, err = boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", secondUser)
 	assert.NoError(t, err, "User has a permission, but it's forbidden to create sideboard")
 }
 
 func TestBoardRepository_GetRootOfNestedBoard(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	store := postgres.NewStore(db)
 	userRepo := store.User()
@@ -121,42 +152,44 @@ func TestBoardRepository_GetRootOfNestedBoard(t *testing.T) {
 
 	// Create test assets
 	user := model.TestUser(t)
-	_ = userRepo.SaveUser(user)
-	rootBoard, _ := boardRepo.NewRootBoard(user, "Root")
-	sideBoard, _ := boardRepo.NewNestedBoard(rootBoard.Base.ID, "Nested", user)
-	secondSide, err := boardRepo.NewNestedBoard(sideBoard.Base.ID, "Nested", user)
+	_ = userRepo.SaveUser(ctx, user)
+	rootBoard, _ := boardRepo.NewRootBoard(ctx, user, "Root")
+	sideBoard, _ := boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", user)
+	secondSide, err := boardRepo.NewNestedBoard(ctx, sideBoard.Base.ID, "Nested", user)
 	assert.NoError(t, err, "Failed to create second side")
 
-	rootBoardFound, err := boardRepo.GetRootOfNestedBoard(secondSide.Base.ID)
+	rootBoardFound, err := boardRepo.GetRootOfNestedBoard(ctx, secondSide.Base.ID)
 	assert.NoError(t, err, "Failed to get root of nested board")
 	assert.Equal(t, rootBoardFound.Base.ID, rootBoard.Base.ID)
 }
 
 func TestBoardRepository_GetNestedBoards(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	store := postgres.NewStore(db)
 	userRepo := store.User()
 	boardRepo := store.Board()
-	defer sanitize("Users", "UserToBoard", "Board")
+	defer sanitize("Users", "UserToBoard", "Board", "Workspace")
 
 	// Create test assets
 	user := model.TestUser(t)
 	anotherUser := model.TestUser(t)
 	anotherUser.Username += "a"
 	anotherUser.Email += "a"
-	_ = userRepo.SaveUser(user)
-	_ = userRepo.SaveUser(anotherUser)
+	_ = userRepo.SaveUser(ctx, user)
+	_ = userRepo.SaveUser(ctx, anotherUser)
 
 	// Add two nested boards as owner
-	rootBoard, _ := boardRepo.NewRootBoard(user, "Root")
-	nestedBoardOne, _ := boardRepo.NewNestedBoard(rootBoard.Base.ID, "Nested #one", user)
-	nestedBoardTwo, _ := boardRepo.NewNestedBoard(rootBoard.Base.ID, "Nested #two", user)
+	rootBoard, _ := boardRepo.NewRootBoard(ctx, user, "Root")
+	nestedBoardOne, _ := boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Nested #one", user)
+	nestedBoardTwo, _ := boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Nested #two", user)
 
 	// And one as guest user (rw privilege)
-	boardRepo.CreateRelation(rootBoard.Base.ID, anotherUser.ID, "test", model.PrivilegeReadWrite)
-	_, err := boardRepo.NewNestedBoard(rootBoard.Base.ID, "Nested another", anotherUser)
+	storage.TestColleagues(t, store, user, anotherUser)
+	boardRepo.CreateRelation(ctx, rootBoard.Base.ID, anotherUser.ID, "test", model.PrivilegeReadWrite)
+	_, err := boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Nested another", anotherUser)
 
-	boards, err := boardRepo.GetNestedBoards(rootBoard.Base.ID, user)
+	boards, err := boardRepo.GetNestedBoards(ctx, rootBoard.Base.ID, user)
 	assert.NoError(t, err, "Failed to get nested boards")
 	assert.Equal(t, len(boards), 3)
 	assert.Equal(t, boards[0].RelationID, nestedBoardOne.RelationID)
@@ -164,30 +197,355 @@ func TestBoardRepository_GetNestedBoards(t *testing.T) {
 }
 
 func TestBoardRepository_DeleteNestedBoard(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	store := postgres.NewStore(db)
 	userRepo := store.User()
 	boardRepo := store.Board()
-	defer sanitize("Users", "UserToBoard", "Board")
+	defer sanitize("Users", "UserToBoard", "BoardToBoard", "Board", "Note", "Workspace")
 
 	// Create test assets
 	user := model.TestUser(t)
 	anotherUser := model.TestUser(t)
 	anotherUser.Username += "a"
 	anotherUser.Email += "a"
-	_ = userRepo.SaveUser(user)
-	_ = userRepo.SaveUser(anotherUser)
+	_ = userRepo.SaveUser(ctx, user)
+	_ = userRepo.SaveUser(ctx, anotherUser)
 
 	// Add two nested boards as owner
-	rootBoard, _ := boardRepo.NewRootBoard(user, "Root")
-	nestedBoardOne, _ := boardRepo.NewNestedBoard(rootBoard.Base.ID, "Nested #one", user)
-	nestedBoardTwo, _ := boardRepo.NewNestedBoard(rootBoard.Base.ID, "Nested #two", user)
+	rootBoard, _ := boardRepo.NewRootBoard(ctx, user, "Root")
+	nestedBoardOne, _ := boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Nested #one", user)
+	nestedBoardTwo, _ := boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Nested #two", user)
 
 	// Successful delete (as author)
-	assert.NoError(t, boardRepo.DeleteNestedBoard(nestedBoardTwo.Base.ID, user), "Failed to delete nested board")
-	boards, _ := boardRepo.GetNestedBoards(rootBoard.Base.ID, user)
+	assert.NoError(t, boardRepo.DeleteNestedBoard(ctx, nestedBoardTwo.Base.ID, user), "Failed to delete nested board")
+	boards, _ := boardRepo.GetNestedBoards(ctx, rootBoard.Base.ID, user)
 	assert.Equal(t, len(boards), 1, "Board not deleted!")
 
 	// Attempt to delete board as user without permissions
-	assert.Error(t, boardRepo.DeleteNestedBoard(nestedBoardOne.Base.ID, anotherUser), "Failed to delete nested board")
+	assert.Error(t, boardRepo.DeleteNestedBoard(ctx, nestedBoardOne.Base.ID, anotherUser), "Failed to delete nested board")
+
+	// Root board isn't deleted as a nested one, even by rw collaborator
+	storage.TestColleagues(t, store, user, anotherUser)
+	_, _ = boardRepo.CreateRelation(ctx, rootBoard.Base.ID, anotherUser.ID, "RW access", model.PrivilegeReadWrite)
+	assert.ErrorIs(t,
+		boardRepo.DeleteNestedBoard(ctx, rootBoard.Base.ID, anotherUser),
+		storage.ErrSecurityError, "Root board deleted as a nested one")
+	boards, _ = boardRepo.GetNestedBoards(ctx, rootBoard.Base.ID, user)
+	assert.Equal(t, len(boards), 1, "Nested boards of the root are deleted")
+
+	// Boards nested in the deleted one are deleted too
+	totals, _ := store.Stats().GetTotals(ctx)
+	childBoard, _ := boardRepo.NewNestedBoard(ctx, nestedBoardOne.Base.ID, "Child", user)
+	grandchildBoard, _ := boardRepo.NewNestedBoard(ctx, childBoard.Base.ID, "Grandchild", user)
+	_ = store.Note().NewNote(ctx, grandchildBoard.Base.ID, &model.Note{Title: "Grandchild note"}, user)
+
+	assert.NoError(t, boardRepo.DeleteNestedBoard(ctx, nestedBoardOne.Base.ID, user), "Failed to delete board with nested boards")
+	totalsAfter, _ := store.Stats().GetTotals(ctx)
+	assert.Equal(t, totals.Boards-1, totalsAfter.Boards, "Nested boards are left")
+	assert.Equal(t, totals.Notes, totalsAfter.Notes, "Notes of nested boards are left")
+}
+
+func TestBoardRepository_GetBoardTree(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	userRepo := store.User()
+	boardRepo := store.Board()
+	defer sanitize("Users", "UserToBoard", "Board", "BoardToBoard")
+
+	// Create test assets
+	user := model.TestUser(t)
+	anotherUser := model.TestUser(t)
+	anotherUser.Username += "a"
+	anotherUser.Email += "a"
+	_ = userRepo.SaveUser(ctx, user)
+	_ = userRepo.SaveUser(ctx, anotherUser)
+
+	// root -> (first -> (deep), second)
+	rootBoard, _ := boardRepo.NewRootBoard(ctx, user, "Root")
+	first, _ := boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "First", user)
+	_, _ = boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Second", user)
+	deep, _ := boardRepo.NewNestedBoard(ctx, first.Base.ID, "Deep", user)
+
+	tree, err := boardRepo.GetBoardTree(ctx, rootBoard.Base.ID, 8, user)
+	assert.NoError(t, err, "Failed to get board tree")
+	assert.Equal(t, tree.Base.ID, rootBoard.Base.ID)
+	assert.Equal(t, tree.ChildCount, 2)
+	assert.Len(t, tree.Children, 2)
+	assert.Equal(t, tree.Children[0].Base.ID, first.Base.ID)
+	assert.Equal(t, tree.Children[0].Children[0].Base.ID, deep.Base.ID)
+
+	// Depth limits the tree, but child counts stay real
+	tree, err = boardRepo.GetBoardTree(ctx, rootBoard.Base.ID, 1, user)
+	assert.NoError(t, err, "Failed to get cut board tree")
+	assert.Len(t, tree.Children[0].Children, 0)
+	assert.Equal(t, tree.Children[0].ChildCount, 1)
+
+	// Subtree of nested board
+	tree, err = boardRepo.GetBoardTree(ctx, first.Base.ID, 8, user)
+	assert.NoError(t, err, "Failed to get subtree")
+	assert.Equal(t, tree.Base.ID, first.Base.ID)
+	assert.Len(t, tree.Children, 1)
+
+	_, err = boardRepo.GetBoardTree(ctx, rootBoard.Base.ID, 8, anotherUser)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Stranger got the tree")
+}
+
+func TestBoardRepository_GetPermissionOfUser(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	userRepo := store.User()
+	boardRepo := store.Board()
+	defer sanitize("Users", "UserToBoard", "BoardToBoard", "Board", "Workspace")
+
+	author := model.TestUser(t)
+	_ = userRepo.SaveUser(ctx, author)
+	reader := model.TestUser(t)
+	reader.Username += "reader"
+	reader.Email += "reader"
+	_ = userRepo.SaveUser(ctx, reader)
+
+	rootBoard, _ := boardRepo.NewRootBoard(ctx, author, "Example root board")
+	nestedBoard, _ := boardRepo.NewNestedBoard(ctx, rootBoard.Base.ID, "Example nested board", author)
+
+	// Privileges are inherited from the root board
+	bp, err := boardRepo.GetPermissionOfUser(ctx, nestedBoard.Base.ID, author.ID)
+	assert.NoError(t, err, "Failed to get permission")
+	assert.Equal(t, bp.Privilege, model.PrivilegeAuthor)
+	assert.Equal(t, bp.BoardID, rootBoard.Base.ID, "Permission isn't bound to the root board")
+
+	// No relations: zero privilege
+	bp, err = boardRepo.GetPermissionOfUser(ctx, nestedBoard.Base.ID, reader.ID)
+	assert.NoError(t, err, "Failed to get permission")
+	assert.Equal(t, bp.Privilege, model.PrivilegeType(0))
+
+	storage.TestColleagues(t, store, author, reader)
+	_, _ = boardRepo.CreateRelation(ctx, rootBoard.Base.ID, reader.ID, "RO access", model.PrivilegeReadOnly)
+	bp, _ = boardRepo.GetPermissionOfUser(ctx, nestedBoard.Base.ID, reader.ID)
+	assert.Equal(t, bp.Privilege, model.PrivilegeReadOnly)
+
+	_, err = boardRepo.GetPermissionOfUser(ctx, uuid.New(), author.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "Found permission of unknown board")
+}
+
+func TestBoardRepository_Collaborators(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	userRepo := store.User()
+	boardRepo := store.Board()
+	defer sanitize("Users", "UserToBoard", "Board", "Workspace")
+
+	author := model.TestUser(t)
+	_ = userRepo.SaveUser(ctx, author)
+	collaborator := model.TestUser(t)
+	collaborator.Username += "collaborator"
+	collaborator.Email += "collaborator"
+	_ = userRepo.SaveUser(ctx, collaborator)
+
+	rootBoard, _ := boardRepo.NewRootBoard(ctx, author, "Example root board")
+	storage.TestColleagues(t, store, author, collaborator)
+	_, _ = boardRepo.CreateRelation(ctx, rootBoard.Base.ID, collaborator.ID, "RW access", model.PrivilegeReadWrite)
+
+	collaborators, err := boardRepo.GetCollaborators(ctx, rootBoard.Base.ID, author)
+	assert.NoError(t, err, "Failed to get collaborators")
+	assert.Len(t, collaborators, 2, "Author and collaborator are expected")
+	assert.Equal(t, collaborators[1].Username, collaborator.Username)
+	assert.Equal(t, collaborators[1].Privilege, model.PrivilegeReadWrite)
+
+	// Only the author manages collaborators
+	_, err = boardRepo.GetCollaborators(ctx, rootBoard.Base.ID, collaborator)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Collaborator got the list of collaborators")
+	assert.ErrorIs(t,
+		boardRepo.UpdateCollaborator(ctx, rootBoard.Base.ID, author.ID, model.PrivilegeReadOnly, collaborator),
+		storage.ErrSecurityError, "Collaborator downgraded the author")
+
+	// Downgrade rw to ro
+	assert.NoError(t, boardRepo.UpdateCollaborator(ctx, rootBoard.Base.ID, collaborator.ID, model.PrivilegeReadOnly, author))
+	bp, _ := boardRepo.GetPermissionOfUser(ctx, rootBoard.Base.ID, collaborator.ID)
+	assert.Equal(t, bp.Privilege, model.PrivilegeReadOnly, "Privilege isn't changed")
+
+	assert.ErrorIs(t,
+		boardRepo.UpdateCollaborator(ctx, rootBoard.Base.ID, collaborator.ID, model.PrivilegeAuthor, author),
+		storage.ErrSecurityError, "Author privilege granted")
+	assert.ErrorIs(t,
+		boardRepo.UpdateCollaborator(ctx, rootBoard.Base.ID, author.ID, model.PrivilegeReadOnly, author),
+		storage.ErrSecurityError, "Author relation changed")
+
+	// Revoke access
+	assert.ErrorIs(t, boardRepo.RevokeCollaborator(ctx, rootBoard.Base.ID, author.ID, author), storage.ErrSecurityError, "Author revoked")
+	assert.NoError(t, boardRepo.RevokeCollaborator(ctx, rootBoard.Base.ID, collaborator.ID, author), "Failed to revoke access")
+	assert.ErrorIs(t, boardRepo.RevokeCollaborator(ctx, rootBoard.Base.ID, collaborator.ID, author), storage.ErrNotFound)
+
+	_, err = boardRepo.GetNestedBoards(ctx, rootBoard.Base.ID, collaborator)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Revoked collaborator still has access")
+}
+
+func TestBoardRepository_TransferBoard(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	userRepo := store.User()
+	boardRepo := store.Board()
+	defer sanitize("Users", "UserToBoard", "BoardTransfer", "Board", "Workspace")
+
+	author := model.TestUser(t)
+	_ = userRepo.SaveUser(ctx, author)
+	collaborator := model.TestUser(t)
+	collaborator.Username += "collaborator"
+	collaborator.Email += "collaborator"
+	_ = userRepo.SaveUser(ctx, collaborator)
+	stranger := model.TestUser(t)
+	stranger.Username += "stranger"
+	stranger.Email += "stranger"
+	_ = userRepo.SaveUser(ctx, stranger)
+
+	rootBoard, _ := boardRepo.NewRootBoard(ctx, author, "Example root board")
+	storage.TestColleagues(t, store, author, collaborator)
+	_, _ = boardRepo.CreateRelation(ctx, rootBoard.Base.ID, collaborator.ID, "RO access", model.PrivilegeReadOnly)
+
+	// Only collaborators can become authors, only the author can transfer
+	_, err := boardRepo.TransferBoard(ctx, rootBoard.Base.ID, stranger.ID, true, author)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "Board transferred to stranger")
+	_, err = boardRepo.TransferBoard(ctx, rootBoard.Base.ID, collaborator.ID, true, collaborator)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Board transferred by collaborator")
+
+	transfer, err := boardRepo.TransferBoard(ctx, rootBoard.Base.ID, collaborator.ID, true, author)
+	assert.NoError(t, err, "Failed to transfer board")
+	assert.Equal(t, transfer.FromUserID, author.ID)
+	assert.Equal(t, transfer.ToUserID, collaborator.ID)
+	assert.False(t, transfer.CreatedAt.IsZero(), "Transfer isn't saved")
+
+	bp, _ := boardRepo.GetPermissionOfUser(ctx, rootBoard.Base.ID, collaborator.ID)
+	assert.Equal(t, bp.Privilege, model.PrivilegeAuthor, "Authorship isn't transferred")
+	bp, _ = boardRepo.GetPermissionOfUser(ctx, rootBoard.Base.ID, author.ID)
+	assert.Equal(t, bp.Privilege, model.PrivilegeReadWrite, "Previous author isn't demoted")
+
+	// Transfer back, the previous author leaves the board
+	_, err = boardRepo.TransferBoard(ctx, rootBoard.Base.ID, author.ID, false, collaborator)
+	assert.NoError(t, err, "Failed to transfer board back")
+	bp, _ = boardRepo.GetPermissionOfUser(ctx, rootBoard.Base.ID, collaborator.ID)
+	assert.Equal(t, bp.Privilege, model.PrivilegeType(0), "Previous author still has access")
+
+	// Audit of transfers outlives the board
+	assert.NoError(t, boardRepo.DeleteRootBoard(ctx, rootBoard.Base.ID, author), "Failed to delete board")
+	var transfers int
+	_ = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM "BoardTransfer" WHERE board_id = $1;`, rootBoard.Base.ID).Scan(&transfers)
+	assert.Equal(t, transfers, 2, "Transfers are deleted with the board")
+}
+
+func TestBoardRepository_TransferBoardConcurrently(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	boardRepo := store.Board()
+	defer sanitize("Users", "UserToBoard", "BoardTransfer", "Board", "Workspace")
+
+	author := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, author)
+	collaborators := make([]*model.User, 4)
+	for i := range collaborators {
+		collaborators[i] = model.TestUser(t)
+		collaborators[i].Username += strconv.Itoa(i)
+		collaborators[i].Email += strconv.Itoa(i)
+		_ = store.User().SaveUser(ctx, collaborators[i])
+	}
+	storage.TestColleagues(t, store, append([]*model.User{author}, collaborators...)...)
+	rootBoard, _ := boardRepo.NewRootBoard(ctx, author, "Example root board")
+	for _, collaborator := range collaborators {
+		_, _ = boardRepo.CreateRelation(ctx, rootBoard.Base.ID, collaborator.ID, "RW access", model.PrivilegeReadWrite)
+	}
+
+	// Only one of concurrent transfers by the same author succeeds
+	var wg sync.WaitGroup
+	errs := make([]error, len(collaborators))
+	for i, collaborator := range collaborators {
+		wg.Add(1)
+		go func(i int, collaborator *model.User) {
+			defer wg.Done()
+			_, errs[i] = boardRepo.TransferBoard(ctx, rootBoard.Base.ID, collaborator.ID, true, author)
+		}(i, collaborator)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, storage.ErrSecurityError)
+		}
+	}
+	assert.Equal(t, 1, succeeded, "Board transferred more than once")
+
+	authors := 0
+	for _, user := range append(collaborators, author) {
+		if bp, _ := boardRepo.GetPermissionOfUser(ctx, rootBoard.Base.ID, user.ID); bp.Privilege == model.PrivilegeAuthor {
+			authors++
+		}
+	}
+	assert.Equal(t, 1, authors, "Board has several authors")
+}
+
+func TestBoardRepository_GetRootBoardsOfUserPages(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users", "UserToBoard", "Board", "Workspace")
+	boardRepo := store.Board()
+
+	author := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, author)
+	owner := model.TestUser(t)
+	owner.Username += "owner"
+	owner.Email += "owner"
+	_ = store.User().SaveUser(ctx, owner)
+
+	for _, title := range []string{"Gamma", "Alpha", "Delta", "Beta"} {
+		_, _ = boardRepo.NewRootBoard(ctx, author, title)
+	}
+	sharedBoard, _ := boardRepo.NewRootBoard(ctx, owner, "Shared alpha")
+	storage.TestColleagues(t, store, owner, author)
+	_, _ = boardRepo.CreateRelation(ctx, sharedBoard.Base.ID, author.ID, "RO", model.PrivilegeReadOnly)
+
+	// Walk all pages sorted by title
+	opts := model.DefaultListOptions()
+	opts.Limit = 2
+	opts.Sort = model.SortByTitle
+	titles := make([]string, 0)
+	for {
+		page, err := boardRepo.GetRootBoardsOfUser(ctx, author, opts)
+		assert.NoError(t, err, "Failed to get page")
+		for _, board := range page.Items {
+			titles = append(titles, board.Base.Title)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor, err = model.DecodeCursor(page.NextCursor)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"Alpha", "Beta", "Delta", "Gamma", "Shared alpha"}, titles)
+
+	// Descending order
+	opts = model.DefaultListOptions()
+	opts.Sort = model.SortByTitle
+	opts.Descending = true
+	page, _ := boardRepo.GetRootBoardsOfUser(ctx, author, opts)
+	assert.Equal(t, "Shared alpha", page.Items[0].Base.Title)
+
+	// Filters
+	opts = model.DefaultListOptions()
+	opts.Title = "ALPHA"
+	page, _ = boardRepo.GetRootBoardsOfUser(ctx, author, opts)
+	assert.Len(t, page.Items, 2, "Title filter isn't case-insensitive substring")
+
+	opts.Title = ""
+	opts.Privilege = model.PrivilegeReadOnly
+	page, _ = boardRepo.GetRootBoardsOfUser(ctx, author, opts)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, sharedBoard.Base.ID, page.Items[0].Base.ID)
+	assert.Equal(t, model.PrivilegeReadOnly, page.Items[0].Privilege)
 }
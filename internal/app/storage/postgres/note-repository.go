@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"Gotcha/internal/app/authorization"
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	GetBridgeOfBoardQuery = `
+		SELECT id FROM "BoardToBoard" WHERE subboard_id = $1;
+	`
+	InsertNoteQuery = `
+		INSERT INTO "Note"(read_only, title, content, board_bridge_id)
+			VALUES($1, $2, $3, $4) RETURNING id, created_at;
+	`
+	GetNotesOfBridgeQuery = `
+		SELECT id, read_only, title, content, created_at FROM "Note"
+		WHERE board_bridge_id = $1 ORDER BY id;
+	`
+	GetNoteQuery = `
+		SELECT id, read_only, title, content, created_at FROM "Note"
+		WHERE id = $1 AND board_bridge_id = $2;
+	`
+	UpdateNoteQuery = `
+		UPDATE "Note" SET read_only = $1, title = $2, content = $3
+		WHERE id = $4 AND board_bridge_id = $5;
+	`
+	DeleteNoteQuery = `
+		DELETE FROM "Note" WHERE id = $1 AND board_bridge_id = $2;
+	`
+)
+
+// NoteRepository interface implementation (depends on SQL database)
+type NoteRepository struct {
+	store *Store
+}
+
+func (nr *NoteRepository) authorizer() *authorization.Authorizer {
+	return authorization.New(nr.store.Board())
+}
+
+// getBridge returns id of the BoardToBoard relation, notes are bound to. Root boards have no bridge.
+func (nr *NoteRepository) getBridge(ctx context.Context, boardID uuid.UUID) (uuid.UUID, error) {
+	var bridgeID uuid.UUID
+	if err := nr.store.db.QueryRowContext(ctx, GetBridgeOfBoardQuery, boardID).Scan(&bridgeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, storage.ErrNotFound
+		}
+		return uuid.Nil, err
+	}
+	return bridgeID, nil
+}
+
+// NewNote saves the note on the nested board. Requires rw or author privilege, read-only
+// notes can be created only by the author.
+func (nr *NoteRepository) NewNote(ctx context.Context, boardID uuid.UUID, note *model.Note, user *model.User) error {
+	ctx, cancel := nr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := note.Validate(); err != nil {
+		return err
+	}
+
+	if err := nr.authorizer().Can(ctx, user, authorization.NoteAction(note.ReadOnly), boardID); err != nil {
+		return err
+	}
+
+	bridgeID, err := nr.getBridge(ctx, boardID)
+	if err != nil {
+		return err
+	}
+
+	note.BoardBridgeID = bridgeID
+	row := nr.store.db.QueryRowContext(ctx, InsertNoteQuery, note.ReadOnly, note.Title, note.Content, bridgeID)
+	return row.Scan(&note.ID, &note.CreatedAt)
+}
+
+// GetNotes returns all notes of the nested board. Any privilege allows user to see the notes
+func (nr *NoteRepository) GetNotes(ctx context.Context, boardID uuid.UUID, user *model.User) ([]*model.Note, error) {
+	ctx, cancel := nr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := nr.authorizer().Can(ctx, user, authorization.ViewNote, boardID); err != nil {
+		return nil, err
+	}
+
+	bridgeID, err := nr.getBridge(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	return nr.notesOfBridge(ctx, bridgeID)
+}
+
+// notesOfBridge returns notes bound to the board bridge without any permission checks
+func (nr *NoteRepository) notesOfBridge(ctx context.Context, bridgeID uuid.UUID) ([]*model.Note, error) {
+	noteRows, err := nr.store.db.QueryContext(ctx, GetNotesOfBridgeQuery, bridgeID)
+	if err != nil {
+		return nil, err
+	}
+	defer noteRows.Close()
+
+	notes := make([]*model.Note, 0)
+	for noteRows.Next() {
+		note := model.Note{BoardBridgeID: bridgeID}
+		if err := noteRows.Scan(&note.ID, &note.ReadOnly, &note.Title, &note.Content, &note.CreatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, &note)
+	}
+	return notes, noteRows.Err()
+}
+
+// GetNote returns the note of the nested board. Any privilege allows user to see the note
+func (nr *NoteRepository) GetNote(ctx context.Context, boardID uuid.UUID, noteID int, user *model.User) (*model.Note, error) {
+	ctx, cancel := nr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := nr.authorizer().Can(ctx, user, authorization.ViewNote, boardID); err != nil {
+		return nil, err
+	}
+
+	bridgeID, err := nr.getBridge(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	return nr.findNote(ctx, bridgeID, noteID)
+}
+
+// UpdateNote overwrites title, content and read_only flag of the note. Read-only notes
+// (and the flag itself) can be changed only by the author.
+func (nr *NoteRepository) UpdateNote(ctx context.Context, boardID uuid.UUID, note *model.Note, user *model.User) error {
+	ctx, cancel := nr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := note.Validate(); err != nil {
+		return err
+	}
+
+	permission, err := nr.authorizer().Permission(ctx, user, boardID)
+	if err != nil {
+		return err
+	}
+
+	bridgeID, err := nr.getBridge(ctx, boardID)
+	if err != nil {
+		return err
+	}
+
+	savedNote, err := nr.findNote(ctx, bridgeID, note.ID)
+	if err != nil {
+		return err
+	}
+	if !authorization.Allowed(permission.Privilege, authorization.NoteAction(savedNote.ReadOnly || note.ReadOnly)) {
+		return storage.ErrSecurityError
+	}
+
+	_, err = nr.store.db.ExecContext(ctx, UpdateNoteQuery, note.ReadOnly, note.Title, note.Content, note.ID, bridgeID)
+	if err != nil {
+		return err
+	}
+	note.BoardBridgeID = bridgeID
+	note.CreatedAt = savedNote.CreatedAt
+	return nil
+}
+
+// DeleteNote removes the note from the nested board. Read-only notes can be removed only by the author.
+func (nr *NoteRepository) DeleteNote(ctx context.Context, boardID uuid.UUID, noteID int, user *model.User) error {
+	ctx, cancel := nr.store.queryContext(ctx)
+	defer cancel()
+
+	permission, err := nr.authorizer().Permission(ctx, user, boardID)
+	if err != nil {
+		return err
+	}
+
+	bridgeID, err := nr.getBridge(ctx, boardID)
+	if err != nil {
+		return err
+	}
+
+	savedNote, err := nr.findNote(ctx, bridgeID, noteID)
+	if err != nil {
+		return err
+	}
+	if !authorization.Allowed(permission.Privilege, authorization.NoteAction(savedNote.ReadOnly)) {
+		return storage.ErrSecurityError
+	}
+
+	_, err = nr.store.db.ExecContext(ctx, DeleteNoteQuery, noteID, bridgeID)
+	return err
+}
+
+func (nr *NoteRepository) findNote(ctx context.Context, bridgeID uuid.UUID, noteID int) (*model.Note, error) {
+	note := model.Note{BoardBridgeID: bridgeID}
+	row := nr.store.db.QueryRowContext(ctx, GetNoteQuery, noteID, bridgeID)
+	if err := row.Scan(&note.ID, &note.ReadOnly, &note.Title, &note.Content, &note.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+	return &note, nil
+}
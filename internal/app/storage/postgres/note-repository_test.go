@@ -0,0 +1,151 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"Gotcha/internal/app/storage/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoteRepository_NewNote(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users", "UserToBoard", "Board", "BoardToBoard", "Note", "Workspace")
+	noteRepo := store.Note()
+
+	user := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, user)
+	rootBoard, _ := store.Board().NewRootBoard(ctx, user, "Root")
+	nestedBoard, _ := store.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", user)
+
+	note := model.TestNote(t)
+	assert.NoError(t, noteRepo.NewNote(ctx, nestedBoard.Base.ID, note, user), "Failed to create note")
+	assert.NotZero(t, note.ID, "Note must have an id after save")
+	assert.Equal(t, note.BoardBridgeID, nestedBoard.RelationID, "Note is not bound to the board bridge")
+
+	// Notes live on nested boards only
+	assert.ErrorIs(t, noteRepo.NewNote(ctx, rootBoard.Base.ID, model.TestNote(t), user), storage.ErrNotFound)
+
+	// Read-only user can't write
+	anotherUser := model.TestUser(t)
+	anotherUser.Username += "a"
+	anotherUser.Email += "a"
+	_ = store.User().SaveUser(ctx, anotherUser)
+	storage.TestColleagues(t, store, user, anotherUser)
+	_, _ = store.Board().CreateRelation(ctx, rootBoard.Base.ID, anotherUser.ID, "test", model.PrivilegeReadOnly)
+
+	assert.ErrorIs(t,
+		noteRepo.NewNote(ctx, nestedBoard.Base.ID, model.TestNote(t), anotherUser),
+		storage.ErrSecurityError, "Read-only user created a note")
+}
+
+func TestNoteRepository_GetNotes(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users", "UserToBoard", "Board", "BoardToBoard", "Note")
+	noteRepo := store.Note()
+
+	user := model.TestUser(t)
+	anotherUser := model.TestUser(t)
+	anotherUser.Username += "a"
+	anotherUser.Email += "a"
+	_ = store.User().SaveUser(ctx, user)
+	_ = store.User().SaveUser(ctx, anotherUser)
+
+	rootBoard, _ := store.Board().NewRootBoard(ctx, user, "Root")
+	nestedBoard, _ := store.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", user)
+	first, second := model.TestNote(t), model.TestNote(t)
+	_ = noteRepo.NewNote(ctx, nestedBoard.Base.ID, first, user)
+	_ = noteRepo.NewNote(ctx, nestedBoard.Base.ID, second, user)
+
+	notes, err := noteRepo.GetNotes(ctx, nestedBoard.Base.ID, user)
+	assert.NoError(t, err, "Failed to get notes")
+	assert.Len(t, notes, 2)
+	assert.Equal(t, notes[0].ID, first.ID)
+
+	note, err := noteRepo.GetNote(ctx, nestedBoard.Base.ID, second.ID, user)
+	assert.NoError(t, err, "Failed to get note")
+	assert.Equal(t, note.Title, second.Title)
+
+	_, err = noteRepo.GetNotes(ctx, nestedBoard.Base.ID, anotherUser)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "User without relation got notes")
+
+	_, err = noteRepo.GetNote(ctx, nestedBoard.Base.ID, second.ID+100, user)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestNoteRepository_UpdateNote(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users", "UserToBoard", "Board", "BoardToBoard", "Note", "Workspace")
+	noteRepo := store.Note()
+
+	user := model.TestUser(t)
+	anotherUser := model.TestUser(t)
+	anotherUser.Username += "a"
+	anotherUser.Email += "a"
+	_ = store.User().SaveUser(ctx, user)
+	_ = store.User().SaveUser(ctx, anotherUser)
+
+	rootBoard, _ := store.Board().NewRootBoard(ctx, user, "Root")
+	nestedBoard, _ := store.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", user)
+	storage.TestColleagues(t, store, user, anotherUser)
+	_, _ = store.Board().CreateRelation(ctx, rootBoard.Base.ID, anotherUser.ID, "test", model.PrivilegeReadWrite)
+
+	note := model.TestNote(t)
+	_ = noteRepo.NewNote(ctx, nestedBoard.Base.ID, note, user)
+
+	note.Title = "Updated"
+	assert.NoError(t, noteRepo.UpdateNote(ctx, nestedBoard.Base.ID, note, anotherUser), "rw user failed to update note")
+	saved, _ := noteRepo.GetNote(ctx, nestedBoard.Base.ID, note.ID, user)
+	assert.Equal(t, saved.Title, "Updated")
+
+	// Only author can lock the note
+	note.ReadOnly = true
+	assert.ErrorIs(t, noteRepo.UpdateNote(ctx, nestedBoard.Base.ID, note, anotherUser), storage.ErrSecurityError)
+	assert.NoError(t, noteRepo.UpdateNote(ctx, nestedBoard.Base.ID, note, user))
+
+	note.Title = "Changed by rw"
+	assert.ErrorIs(t, noteRepo.UpdateNote(ctx, nestedBoard.Base.ID, note, anotherUser), storage.ErrSecurityError)
+}
+
+func TestNoteRepository_DeleteNote(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users", "UserToBoard", "Board", "BoardToBoard", "Note", "Workspace")
+	noteRepo := store.Note()
+
+	user := model.TestUser(t)
+	anotherUser := model.TestUser(t)
+	anotherUser.Username += "a"
+	anotherUser.Email += "a"
+	_ = store.User().SaveUser(ctx, user)
+	_ = store.User().SaveUser(ctx, anotherUser)
+
+	rootBoard, _ := store.Board().NewRootBoard(ctx, user, "Root")
+	nestedBoard, _ := store.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", user)
+	storage.TestColleagues(t, store, user, anotherUser)
+	_, _ = store.Board().CreateRelation(ctx, rootBoard.Base.ID, anotherUser.ID, "test", model.PrivilegeReadOnly)
+
+	note := model.TestNote(t)
+	_ = noteRepo.NewNote(ctx, nestedBoard.Base.ID, note, user)
+
+	assert.ErrorIs(t, noteRepo.DeleteNote(ctx, nestedBoard.Base.ID, note.ID, anotherUser), storage.ErrSecurityError)
+	assert.NoError(t, noteRepo.DeleteNote(ctx, nestedBoard.Base.ID, note.ID, user), "Failed to delete note")
+
+	notes, _ := noteRepo.GetNotes(ctx, nestedBoard.Base.ID, user)
+	assert.Len(t, notes, 0, "Note not deleted")
+
+	// Notes are removed with the nested board
+	_ = noteRepo.NewNote(ctx, nestedBoard.Base.ID, model.TestNote(t), user)
+	_ = store.Board().DeleteNestedBoard(ctx, nestedBoard.Base.ID, user)
+	_, err := noteRepo.GetNotes(ctx, nestedBoard.Base.ID, user)
+	assert.Error(t, err, "Notes of deleted board are still reachable")
+}
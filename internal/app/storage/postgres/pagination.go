@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"Gotcha/internal/app/model"
+)
+
+// pageColumns maps sort fields of model.ListOptions to the columns of the listing query
+type pageColumns struct {
+	title     string
+	createdAt string
+	id        string
+}
+
+// pageClause returns the keyset condition and ORDER BY ... LIMIT clause for the listing query.
+// Positional arguments of the clause start from firstArg. One extra row is requested, so
+// model.NewPage knows whether there is the next page.
+func pageClause(opts *model.ListOptions, columns pageColumns, firstArg int) (string, string, []any) {
+	sortColumn := columns.createdAt
+	if opts.Sort == model.SortByTitle {
+		sortColumn = columns.title
+	}
+	direction, comparison := "ASC", ">"
+	if opts.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	condition := "TRUE"
+	args := make([]any, 0, 3)
+	if opts.Cursor != nil {
+		var key any = opts.Cursor.CreatedAt
+		if opts.Sort == model.SortByTitle {
+			key = opts.Cursor.Title
+		}
+		condition = fmt.Sprintf("(%s, %s) %s ($%d, $%d)", sortColumn, columns.id, comparison, firstArg, firstArg+1)
+		args = append(args, key, opts.Cursor.ID)
+		firstArg += 2
+	}
+
+	order := fmt.Sprintf("ORDER BY %s %s, %s %s LIMIT $%d", sortColumn, direction, columns.id, direction, firstArg)
+	args = append(args, opts.Limit+1)
+	return condition, order, args
+}
+
+// likePattern escapes the substring for ILIKE
+func likePattern(substring string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(substring) + "%"
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"Gotcha/internal/app/model"
+)
+
+const (
+	// SearchQuery matches boards and notes of the trees, that user can access either directly,
+	// or through a team. Boards of workspaces, which user isn't a member of, are skipped
+	SearchQuery = `
+		WITH RECURSIVE accessible AS (
+			SELECT b.id FROM "Board" b
+				INNER JOIN (
+					SELECT board_id FROM "UserToBoard" WHERE user_id = $1
+					UNION
+					SELECT ttb.board_id FROM "TeamToBoard" ttb
+						INNER JOIN "TeamMember" tm ON tm.team_id = ttb.team_id
+					WHERE tm.user_id = $1
+				) p ON p.board_id = b.id
+			WHERE b.workspace_id IS NULL OR b.workspace_id IN (
+				SELECT workspace_id FROM "WorkspaceMember" WHERE user_id = $1
+			)
+			UNION
+			SELECT b2b.subboard_id FROM "BoardToBoard" b2b
+				INNER JOIN accessible ON accessible.id = b2b.root_board_id
+		), query AS (
+			SELECT websearch_to_tsquery('simple', $2) AS q
+		)
+		SELECT kind, board_id, note_id, title, snippet, rank FROM (
+			SELECT 'board' AS kind, b.id AS board_id, 0 AS note_id, b.title,
+				ts_headline('simple', b.title, query.q, $4) AS snippet,
+				ts_rank(b.search_vector, query.q) AS rank
+			FROM "Board" b
+				INNER JOIN accessible ON accessible.id = b.id, query
+			WHERE b.search_vector @@ query.q
+			UNION ALL
+			SELECT 'note', b2b.subboard_id, n.id, n.title,
+				ts_headline('simple', n.title || ' ' || n.content, query.q, $4),
+				ts_rank(n.search_vector, query.q)
+			FROM "Note" n
+				INNER JOIN "BoardToBoard" b2b ON b2b.id = n.board_bridge_id
+				INNER JOIN accessible ON accessible.id = b2b.subboard_id, query
+			WHERE n.search_vector @@ query.q
+		) results
+		ORDER BY rank DESC, title, board_id, note_id
+		LIMIT $3;
+	`
+)
+
+// headlineOptions configures ts_headline to wrap matches with model match marks. Headlines are raw
+// text, so they are escaped by model.RenderSnippet before the marks become HTML
+const headlineOptions = "StartSel=" + model.SearchMatchStart + ", StopSel=" + model.SearchMatchStop + ", MaxFragments=2"
+
+// SearchRepository interface implementation (depends on SQL database)
+type SearchRepository struct {
+	store *Store
+}
+
+// Search ranks matching boards and notes with the full-text index, best matches go first
+func (sr *SearchRepository) Search(ctx context.Context, query string, limit int, user *model.User) ([]*model.SearchResult, error) {
+	ctx, cancel := sr.store.queryContext(ctx)
+	defer cancel()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, model.ErrEmptySearchQuery
+	}
+
+	resultRows, err := sr.store.db.QueryContext(ctx, SearchQuery, user.ID, query, limit, headlineOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer resultRows.Close()
+
+	results := make([]*model.SearchResult, 0)
+	for resultRows.Next() {
+		result := model.SearchResult{}
+		if err := resultRows.Scan(&result.Kind, &result.BoardID, &result.NoteID, &result.Title, &result.Snippet, &result.Rank); err != nil {
+			return nil, err
+		}
+		result.Snippet = model.RenderSnippet(result.Snippet)
+		results = append(results, &result)
+	}
+	return results, resultRows.Err()
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"Gotcha/internal/app/authorization"
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	InsertShareLinkQuery = `
+		INSERT INTO "ShareLink"(board_id, created_by, hash, expires_at)
+			VALUES($1, $2, $3, $4) RETURNING id, created_at;
+	`
+	GetShareLinksOfBoardQuery = `
+		SELECT id, board_id, created_by, hash, expires_at, created_at FROM "ShareLink"
+		WHERE board_id = $1 ORDER BY created_at;
+	`
+	FindShareLinkByHashQuery = `
+		SELECT id, board_id, created_by, hash, expires_at, created_at FROM "ShareLink"
+		WHERE hash = $1;
+	`
+	DeleteShareLinkQuery = `
+		DELETE FROM "ShareLink" WHERE id = $1 AND board_id = $2;
+	`
+	IsBoardInTreeQuery = `
+		WITH RECURSIVE ancestors AS (
+			SELECT $1::uuid AS id
+			UNION ALL
+			SELECT b2b.root_board_id FROM "BoardToBoard" b2b
+				INNER JOIN ancestors ON b2b.subboard_id = ancestors.id
+		)
+		SELECT EXISTS(SELECT 1 FROM ancestors WHERE id = $2);
+	`
+)
+
+// ShareRepository interface implementation (depends on SQL database)
+type ShareRepository struct {
+	store *Store
+}
+
+// NewShareLink validates and saves the link. Only the author can share the board
+func (sr *ShareRepository) NewShareLink(ctx context.Context, link *model.ShareLink, user *model.User) error {
+	ctx, cancel := sr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := link.Validate(); err != nil {
+		return err
+	}
+	if err := authorization.New(sr.store.Board()).Can(ctx, user, authorization.ShareBoard, link.BoardID); err != nil {
+		return err
+	}
+
+	link.CreatedBy = user.ID
+	row := sr.store.db.QueryRowContext(ctx, InsertShareLinkQuery, link.BoardID, link.CreatedBy, link.Hash, link.ExpiresAt)
+	err := row.Scan(&link.ID, &link.CreatedAt)
+	if err != nil && strings.Contains(err.Error(), "duplicate") {
+		return storage.ErrEntityDuplicate
+	}
+	return err
+}
+
+func (sr *ShareRepository) GetShareLinks(ctx context.Context, boardID uuid.UUID, user *model.User) ([]*model.ShareLink, error) {
+	ctx, cancel := sr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := authorization.New(sr.store.Board()).Can(ctx, user, authorization.ShareBoard, boardID); err != nil {
+		return nil, err
+	}
+
+	linkRows, err := sr.store.db.QueryContext(ctx, GetShareLinksOfBoardQuery, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer linkRows.Close()
+
+	links := make([]*model.ShareLink, 0)
+	for linkRows.Next() {
+		link, err := scanShareLink(linkRows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, linkRows.Err()
+}
+
+func (sr *ShareRepository) RevokeShareLink(ctx context.Context, boardID, linkID uuid.UUID, user *model.User) error {
+	ctx, cancel := sr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := authorization.New(sr.store.Board()).Can(ctx, user, authorization.ShareBoard, boardID); err != nil {
+		return err
+	}
+
+	result, err := sr.store.db.ExecContext(ctx, DeleteShareLinkQuery, linkID, boardID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// FindShareLinkByHash returns the link with the given hash. Expiration isn't checked here
+func (sr *ShareRepository) FindShareLinkByHash(ctx context.Context, hash string) (*model.ShareLink, error) {
+	ctx, cancel := sr.store.queryContext(ctx)
+	defer cancel()
+
+	link, err := scanShareLink(sr.store.db.QueryRowContext(ctx, FindShareLinkByHashQuery, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+// GetSharedTree returns the hierarchy under the link board
+func (sr *ShareRepository) GetSharedTree(ctx context.Context, link *model.ShareLink, maxDepth int) (*model.BoardTree, error) {
+	ctx, cancel := sr.store.queryContext(ctx)
+	defer cancel()
+
+	return (&BoardRepository{store: sr.store}).boardTree(ctx, link.BoardID, maxDepth)
+}
+
+// GetSharedNotes returns notes of the board, that must be the link board or one of its nested boards
+func (sr *ShareRepository) GetSharedNotes(ctx context.Context, link *model.ShareLink, boardID uuid.UUID) ([]*model.Note, error) {
+	ctx, cancel := sr.store.queryContext(ctx)
+	defer cancel()
+
+	var inTree bool
+	if err := sr.store.db.QueryRowContext(ctx, IsBoardInTreeQuery, boardID, link.BoardID).Scan(&inTree); err != nil {
+		return nil, err
+	}
+	if !inTree {
+		return nil, storage.ErrSecurityError
+	}
+
+	noteRepository := &NoteRepository{store: sr.store}
+	bridgeID, err := noteRepository.getBridge(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	return noteRepository.notesOfBridge(ctx, bridgeID)
+}
+
+func scanShareLink(row rowScanner) (*model.ShareLink, error) {
+	link := model.ShareLink{}
+	var expiresAt sql.NullTime
+
+	err := row.Scan(&link.ID, &link.BoardID, &link.CreatedBy, &link.Hash, &expiresAt, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	return &link, nil
+}
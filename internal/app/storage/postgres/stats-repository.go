@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+
+	"Gotcha/internal/app/model"
+)
+
+const (
+	GetTotalsQuery = `
+		SELECT
+			(SELECT count(*) FROM "Users"),
+			(SELECT count(*) FROM "Board"),
+			(SELECT count(*) FROM "Note")
+	`
+)
+
+type StatsRepository struct {
+	store *Store
+}
+
+func (s *StatsRepository) GetTotals(ctx context.Context) (*model.Totals, error) {
+	ctx, cancel := s.store.queryContext(ctx)
+	defer cancel()
+
+	var totals model.Totals
+	if err := s.store.db.QueryRowContext(ctx, GetTotalsQuery).Scan(
+		&totals.Users, &totals.Boards, &totals.Notes,
+	); err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
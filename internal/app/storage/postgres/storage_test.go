@@ -1,8 +1,17 @@
 package postgres_test
 
 import (
+	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"Gotcha/internal/app/storage/postgres"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -16,3 +25,57 @@ func TestMain(m *testing.M) {
 	}
 	os.Exit(m.Run())
 }
+
+func TestStore_WithTx(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users", "UserToBoard", "Board", "BoardToBoard")
+	errAbort := errors.New("abort")
+
+	user := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, user)
+
+	// Committed transaction
+	var rootBoard *model.Board
+	err := store.WithTx(ctx, func(tx storage.Storage) error {
+		var err error
+		rootBoard, err = tx.Board().NewRootBoard(ctx, user, "Root")
+		return err
+	})
+	assert.NoError(t, err, "Failed to commit transaction")
+
+	// Rolled back transaction
+	err = store.WithTx(ctx, func(tx storage.Storage) error {
+		if _, err := tx.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", user); err != nil {
+			return err
+		}
+		if _, err := tx.Board().NewRootBoard(ctx, user, "Another root"); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	boards, _ := store.Board().GetRootBoardsOfUser(ctx, user, model.DefaultListOptions())
+	assert.Len(t, boards.Items, 1, "Root board of rolled back transaction exists")
+	nestedBoards, _ := store.Board().GetNestedBoards(ctx, rootBoard.Base.ID, user)
+	assert.Len(t, nestedBoards, 0, "Nested board of rolled back transaction exists")
+}
+
+func TestStore_Context(t *testing.T) {
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users")
+
+	// Cancelled request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := store.User().FindUserByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, context.Canceled, "Query wasn't cancelled")
+
+	// Expired per-query deadline
+	store.SetQueryTimeout(time.Nanosecond)
+	_, err = store.User().FindUserByID(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Query deadline wasn't applied")
+}
@@ -1,22 +1,57 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"Gotcha/internal/app/storage"
 )
 
+// executor is a common subset of *sql.DB and *sql.Tx, so repositories don't care
+// whether they're running inside the transaction or not
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 // Store is an SQL(postgresql tested) implementation of gotcha storage
 type Store struct {
-	db              *sql.DB
-	userRepository  *UserRepository
-	boardRepository *BoardRepository
+	db                  executor
+	pool                *sql.DB // nil when store is bound to the transaction
+	queryTimeout        time.Duration
+	userRepository      *UserRepository
+	boardRepository     *BoardRepository
+	noteRepository      *NoteRepository
+	tokenRepository     *TokenRepository
+	shareRepository     *ShareRepository
+	teamRepository      *TeamRepository
+	workspaceRepository *WorkspaceRepository
+	searchRepository    *SearchRepository
+	statsRepository     *StatsRepository
 }
 
 func NewStore(db *sql.DB) *Store {
 	return &Store{
-		db: db,
+		db:   tracedExecutor{db},
+		pool: db,
+	}
+}
+
+// SetQueryTimeout limits the time of every repository call. Zero timeout means no limit
+// except of the deadline of the passed context.
+func (store *Store) SetQueryTimeout(timeout time.Duration) {
+	store.queryTimeout = timeout
+}
+
+// queryContext bounds ctx with the per-query deadline of the store
+func (store *Store) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if store.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, store.queryTimeout)
 }
 
 // User returns UserRepository for related operations. Implementation requirement
@@ -34,8 +69,94 @@ func (store *Store) Board() storage.BoardRepository {
 	return store.boardRepository
 }
 
+func (store *Store) Note() storage.NoteRepository {
+	if store.noteRepository == nil {
+		store.noteRepository = &NoteRepository{store: store}
+	}
+	return store.noteRepository
+}
+
+func (store *Store) Token() storage.TokenRepository {
+	if store.tokenRepository == nil {
+		store.tokenRepository = &TokenRepository{store: store}
+	}
+	return store.tokenRepository
+}
+
+func (store *Store) Share() storage.ShareRepository {
+	if store.shareRepository == nil {
+		store.shareRepository = &ShareRepository{store: store}
+	}
+	return store.shareRepository
+}
+
+func (store *Store) Team() storage.TeamRepository {
+	if store.teamRepository == nil {
+		store.teamRepository = &TeamRepository{store: store}
+	}
+	return store.teamRepository
+}
+
+func (store *Store) Workspace() storage.WorkspaceRepository {
+	if store.workspaceRepository == nil {
+		store.workspaceRepository = &WorkspaceRepository{store: store}
+	}
+	return store.workspaceRepository
+}
+
+func (store *Store) Search() storage.SearchRepository {
+	if store.searchRepository == nil {
+		store.searchRepository = &SearchRepository{store: store}
+	}
+	return store.searchRepository
+}
+
+func (store *Store) Stats() storage.StatsRepository {
+	if store.statsRepository == nil {
+		store.statsRepository = &StatsRepository{store: store}
+	}
+	return store.statsRepository
+}
+
+// WithTx runs fn against the storage bound to a single transaction. Transaction is committed
+// if fn returns nil and rolled back otherwise. Nested calls reuse the outer transaction.
+func (store *Store) WithTx(ctx context.Context, fn func(storage.Storage) error) error {
+	return store.withTx(ctx, func(txStore *Store) error {
+		return fn(txStore)
+	})
+}
+
+func (store *Store) withTx(ctx context.Context, fn func(*Store) error) (err error) {
+	// Already inside the transaction
+	if store.pool == nil {
+		return fn(store)
+	}
+
+	tx, err := store.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&Store{db: tracedExecutor{tx}, queryTimeout: store.queryTimeout}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
 func (store *Store) Close() {
-	// TODO: Add hooks
-	// ...
-	_ = store.db.Close()
+	// Transaction-bound store doesn't own the connection
+	if store.pool == nil {
+		return
+	}
+	_ = store.pool.Close()
 }
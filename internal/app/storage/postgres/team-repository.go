@@ -0,0 +1,284 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"Gotcha/internal/app/authorization"
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	InsertTeamQuery = `
+		INSERT INTO "Team"(title, owner_id) VALUES($1, $2) RETURNING id, created_at;
+	`
+	GetTeamQuery = `
+		SELECT id, title, owner_id, created_at FROM "Team" WHERE id = $1;
+	`
+	GetTeamsOfUserQuery = `
+		SELECT t.id, t.title, t.owner_id, t.created_at FROM "Team" t
+			INNER JOIN "TeamMember" tm ON tm.team_id = t.id
+		WHERE tm.user_id = $1 ORDER BY t.created_at;
+	`
+	InsertTeamMemberQuery = `
+		INSERT INTO "TeamMember"(team_id, user_id) VALUES($1, $2);
+	`
+	IsTeamMemberQuery = `
+		SELECT EXISTS(SELECT 1 FROM "TeamMember" WHERE team_id = $1 AND user_id = $2);
+	`
+	GetTeamMembersQuery = `
+		SELECT u.id, u.username, tm.created_at FROM "TeamMember" tm
+			INNER JOIN "Users" u ON u.id = tm.user_id
+		WHERE tm.team_id = $1 ORDER BY tm.created_at;
+	`
+	GetTeamMemberIDsQuery = `
+		SELECT user_id FROM "TeamMember" WHERE team_id = $1;
+	`
+	GetBoardsOfTeamQuery = `
+		SELECT board_id FROM "TeamToBoard" WHERE team_id = $1;
+	`
+	DeleteTeamMemberQuery = `
+		DELETE FROM "TeamMember" WHERE team_id = $1 AND user_id = $2;
+	`
+	InsertTeamRelationQuery = `
+		INSERT INTO "TeamToBoard"(team_id, board_id, access_type, description)
+			VALUES($1, $2, $3, $4) RETURNING id;
+	`
+	DeleteTeamRelationQuery = `
+		DELETE FROM "TeamToBoard" WHERE board_id = $1 AND team_id = $2;
+	`
+)
+
+// TeamRepository interface implementation (depends on SQL database)
+type TeamRepository struct {
+	store *Store
+}
+
+// NewTeam saves the team, user becomes its owner and the first member
+func (tr *TeamRepository) NewTeam(ctx context.Context, team *model.Team, user *model.User) error {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := team.Validate(); err != nil {
+		return err
+	}
+
+	team.OwnerID = user.ID
+	return tr.store.withTx(ctx, func(txStore *Store) error {
+		if err := txStore.db.QueryRowContext(ctx, InsertTeamQuery, team.Title, team.OwnerID).Scan(&team.ID, &team.CreatedAt); err != nil {
+			return err
+		}
+		_, err := txStore.db.ExecContext(ctx, InsertTeamMemberQuery, team.ID, team.OwnerID)
+		return err
+	})
+}
+
+func (tr *TeamRepository) GetTeamsOfUser(ctx context.Context, user *model.User) ([]*model.Team, error) {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	teamRows, err := tr.store.db.QueryContext(ctx, GetTeamsOfUserQuery, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer teamRows.Close()
+
+	teams := make([]*model.Team, 0)
+	for teamRows.Next() {
+		team, err := scanTeam(teamRows)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, teamRows.Err()
+}
+
+// GetTeamMembers returns members of the team. Only members can see each other
+func (tr *TeamRepository) GetTeamMembers(ctx context.Context, teamID uuid.UUID, user *model.User) ([]*model.TeamMember, error) {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	if _, err := tr.getTeam(ctx, teamID); err != nil {
+		return nil, err
+	}
+	var isMember bool
+	if err := tr.store.db.QueryRowContext(ctx, IsTeamMemberQuery, teamID, user.ID).Scan(&isMember); err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, storage.ErrSecurityError
+	}
+
+	memberRows, err := tr.store.db.QueryContext(ctx, GetTeamMembersQuery, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer memberRows.Close()
+
+	members := make([]*model.TeamMember, 0)
+	for memberRows.Next() {
+		member := model.TeamMember{}
+		if err := memberRows.Scan(&member.UserID, &member.Username, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+	return members, memberRows.Err()
+}
+
+// AddTeamMember adds the user to the team. Only the owner manages members
+func (tr *TeamRepository) AddTeamMember(ctx context.Context, teamID, memberID uuid.UUID, user *model.User) error {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	team, err := tr.getTeam(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	if team.OwnerID != user.ID {
+		return storage.ErrSecurityError
+	}
+	if _, err := tr.store.User().FindUserByID(ctx, memberID); err != nil {
+		return storage.ErrNotFound
+	}
+
+	// Member gets access to the boards of the team, so they must be shareable with him
+	boardIDs, err := tr.queryIDs(ctx, GetBoardsOfTeamQuery, teamID)
+	if err != nil {
+		return err
+	}
+	boards := &BoardRepository{store: tr.store}
+	for _, boardID := range boardIDs {
+		if err := boards.checkCanShareWith(ctx, boardID, memberID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tr.store.db.ExecContext(ctx, InsertTeamMemberQuery, teamID, memberID)
+	if err != nil && strings.Contains(err.Error(), "duplicate") {
+		return storage.ErrEntityDuplicate
+	}
+	return err
+}
+
+// RemoveTeamMember removes the member from the team. The owner removes anyone but himself,
+// other members can only leave the team.
+func (tr *TeamRepository) RemoveTeamMember(ctx context.Context, teamID, memberID uuid.UUID, user *model.User) error {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	team, err := tr.getTeam(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	if memberID == team.OwnerID || (team.OwnerID != user.ID && memberID != user.ID) {
+		return storage.ErrSecurityError
+	}
+
+	result, err := tr.store.db.ExecContext(ctx, DeleteTeamMemberQuery, teamID, memberID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// GrantTeam relates the team with the root board. Like collaborators, teams can be granted ro or rw only
+func (tr *TeamRepository) GrantTeam(ctx context.Context, boardID, teamID uuid.UUID, desc string, privilegeType model.PrivilegeType, user *model.User) (uuid.UUID, error) {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	if privilegeType != model.PrivilegeReadOnly && privilegeType != model.PrivilegeReadWrite {
+		return uuid.Nil, storage.ErrSecurityError
+	}
+	if err := (&BoardRepository{store: tr.store}).permitRootBoardAction(ctx, boardID, authorization.ShareBoard, user); err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := tr.getTeam(ctx, teamID); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Like collaborators, every member must be allowed to access the board
+	memberIDs, err := tr.queryIDs(ctx, GetTeamMemberIDsQuery, teamID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, memberID := range memberIDs {
+		if err := (&BoardRepository{store: tr.store}).checkCanShareWith(ctx, boardID, memberID); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	var relationID uuid.UUID
+	err = tr.store.db.QueryRowContext(ctx, InsertTeamRelationQuery, teamID, boardID, privilegeType, desc).Scan(&relationID)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return uuid.Nil, storage.ErrEntityDuplicate
+		}
+		return uuid.Nil, err
+	}
+	return relationID, nil
+}
+
+func (tr *TeamRepository) RevokeTeam(ctx context.Context, boardID, teamID uuid.UUID, user *model.User) error {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := (&BoardRepository{store: tr.store}).permitRootBoardAction(ctx, boardID, authorization.ManageCollaborators, user); err != nil {
+		return err
+	}
+
+	result, err := tr.store.db.ExecContext(ctx, DeleteTeamRelationQuery, boardID, teamID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (tr *TeamRepository) getTeam(ctx context.Context, teamID uuid.UUID) (*model.Team, error) {
+	team, err := scanTeam(tr.store.db.QueryRowContext(ctx, GetTeamQuery, teamID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+	return team, nil
+}
+
+// queryIDs returns the single uuid column of the query rows
+func (tr *TeamRepository) queryIDs(ctx context.Context, query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := tr.store.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func scanTeam(row rowScanner) (*model.Team, error) {
+	team := model.Team{}
+	if err := row.Scan(&team.ID, &team.Title, &team.OwnerID, &team.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
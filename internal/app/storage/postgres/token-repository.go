@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	InsertTokenQuery = `
+		INSERT INTO "ApiToken"(user_id, name, hash, scope, expires_at)
+			VALUES($1, $2, $3, $4, $5) RETURNING id, created_at;
+	`
+	GetTokensOfUserQuery = `
+		SELECT id, user_id, name, hash, scope, expires_at, created_at FROM "ApiToken"
+		WHERE user_id = $1 ORDER BY created_at;
+	`
+	FindTokenByHashQuery = `
+		SELECT id, user_id, name, hash, scope, expires_at, created_at FROM "ApiToken"
+		WHERE hash = $1;
+	`
+	DeleteTokenQuery = `
+		DELETE FROM "ApiToken" WHERE id = $1 AND user_id = $2;
+	`
+)
+
+// TokenRepository interface implementation (depends on SQL database)
+type TokenRepository struct {
+	store *Store
+}
+
+// NewToken validates and saves the token. Only hash of the token is stored
+func (tr *TokenRepository) NewToken(ctx context.Context, token *model.APIToken) error {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := token.Validate(); err != nil {
+		return err
+	}
+
+	row := tr.store.db.QueryRowContext(ctx, InsertTokenQuery, token.UserID, token.Name, token.Hash, token.Scope, token.ExpiresAt)
+	err := row.Scan(&token.ID, &token.CreatedAt)
+	if err != nil && strings.Contains(err.Error(), "duplicate") {
+		return storage.ErrEntityDuplicate
+	}
+	return err
+}
+
+func (tr *TokenRepository) GetTokensOfUser(ctx context.Context, user *model.User) ([]*model.APIToken, error) {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	tokenRows, err := tr.store.db.QueryContext(ctx, GetTokensOfUserQuery, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer tokenRows.Close()
+
+	tokens := make([]*model.APIToken, 0)
+	for tokenRows.Next() {
+		token, err := scanToken(tokenRows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, tokenRows.Err()
+}
+
+// FindTokenByHash returns the token with the given hash. Expiration isn't checked here
+func (tr *TokenRepository) FindTokenByHash(ctx context.Context, hash string) (*model.APIToken, error) {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	token, err := scanToken(tr.store.db.QueryRowContext(ctx, FindTokenByHashQuery, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+// RevokeToken deletes the token. Users can revoke only their own tokens
+func (tr *TokenRepository) RevokeToken(ctx context.Context, tokenID uuid.UUID, user *model.User) error {
+	ctx, cancel := tr.store.queryContext(ctx)
+	defer cancel()
+
+	result, err := tr.store.db.ExecContext(ctx, DeleteTokenQuery, tokenID, user.ID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// rowScanner is a common subset of *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanToken(row rowScanner) (*model.APIToken, error) {
+	token := model.APIToken{}
+	var expiresAt sql.NullTime
+
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Hash, &token.Scope, &expiresAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	return &token, nil
+}
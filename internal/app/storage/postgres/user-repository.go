@@ -1,11 +1,15 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"strings"
+	"time"
 
 	"Gotcha/internal/app/model"
 	"Gotcha/internal/app/storage"
+	"Gotcha/internal/app/tracing"
 	"github.com/google/uuid"
 )
 
@@ -15,13 +19,24 @@ const (
 			VALUES($1, $2, $3) RETURNING id, created_at;
 	`
 	findUserByQuery = `
-		SELECT id, username, email, hash, created_at FROM "Users" where username = $1 or email = $1;
+		SELECT id, username, email, hash, created_at, sessions_revoked_at FROM "Users"
+		where username = $1 or email = $1;
 	`
 	findUserByIDQuery = `
-		SELECT id, username, email, hash, created_at FROM "Users" where id = $1;
+		SELECT id, username, email, hash, created_at, sessions_revoked_at FROM "Users" where id = $1;
 	`
-	getAllUsers = `
-		SELECT id, username, created_at FROM "Users";
+	// getColleaguesQuery is completed with the keyset condition and ORDER BY clause of the page
+	getColleaguesQuery = `
+		SELECT u.id, u.username, u.created_at FROM "Users" u
+		WHERE u.id <> $1 AND u.id IN (
+			SELECT wm.user_id FROM "WorkspaceMember" wm
+				INNER JOIN "WorkspaceMember" own ON own.workspace_id = wm.workspace_id
+			WHERE own.user_id = $1
+		) AND u.username ILIKE $2 AND %s
+		%s;
+	`
+	revokeSessionsQuery = `
+		UPDATE "Users" SET sessions_revoked_at = $1 WHERE id = $2;
 	`
 )
 
@@ -32,20 +47,29 @@ type UserRepository struct {
 
 // FindUserBySobriquet performs a simple search query by email and username.
 // Returns error if user not found
-func (repo *UserRepository) FindUserBySobriquet(sobriquet string) (*model.User, error) {
-	u := model.User{}
-	userRow := repo.store.db.QueryRow(findUserByQuery, sobriquet)
-	if err := userRow.Scan(&u.ID, &u.Username, &u.Email, &u.Hash, &u.CreatedAt); err != nil {
+func (repo *UserRepository) FindUserBySobriquet(ctx context.Context, sobriquet string) (*model.User, error) {
+	ctx, span := repo.store.startSpan(ctx, "UserRepository.FindUserBySobriquet")
+	defer span.End()
+	ctx, cancel := repo.store.queryContext(ctx)
+	defer cancel()
+
+	u, err := scanUser(repo.store.db.QueryRowContext(ctx, findUserByQuery, sobriquet))
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, storage.ErrNotFound
 		}
 		return nil, err
 	}
-	return &u, nil
+	return u, nil
 }
 
 // SaveUser performs validation check, gets hash of password and then saves the user
-func (repo *UserRepository) SaveUser(user *model.User) error {
+func (repo *UserRepository) SaveUser(ctx context.Context, user *model.User) error {
+	ctx, span := repo.store.startSpan(ctx, "UserRepository.SaveUser")
+	defer span.End()
+	ctx, cancel := repo.store.queryContext(ctx)
+	defer cancel()
+
 	if err := user.Validate(); err != nil {
 		return err
 	}
@@ -53,7 +77,7 @@ func (repo *UserRepository) SaveUser(user *model.User) error {
 		return err
 	}
 
-	resultRow := repo.store.db.QueryRow(saveUserQuery, user.Username, user.Email, user.Hash)
+	resultRow := repo.store.db.QueryRowContext(ctx, saveUserQuery, user.Username, user.Email, user.Hash)
 	err := resultRow.Scan(&user.ID, &user.CreatedAt)
 	if err != nil && strings.Contains(err.Error(), "duplicate") {
 		return storage.ErrEntityDuplicate
@@ -61,24 +85,44 @@ func (repo *UserRepository) SaveUser(user *model.User) error {
 	return err
 }
 
-func (repo *UserRepository) FindUserByID(userID uuid.UUID) (*model.User, error) {
-	u := model.User{}
-	userRow := repo.store.db.QueryRow(findUserByIDQuery, userID)
-	if err := userRow.Scan(&u.ID, &u.Username, &u.Email, &u.Hash, &u.CreatedAt); err != nil {
+func (repo *UserRepository) FindUserByID(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	ctx, span := repo.store.startSpan(ctx, "UserRepository.FindUserByID", tracing.UserID(userID))
+	defer span.End()
+	ctx, cancel := repo.store.queryContext(ctx)
+	defer cancel()
+
+	u, err := scanUser(repo.store.db.QueryRowContext(ctx, findUserByIDQuery, userID))
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, storage.ErrNotFound
 		}
 		return nil, err
 	}
-	return &u, nil
+	return u, nil
 }
 
-func (repo *UserRepository) GetAllUsers(currUser *model.User) ([]*model.User, error) {
-	users := make([]*model.User, 0)
-	usrRows, err := repo.store.db.Query(getAllUsers)
+// GetColleagues returns users, that share at least one workspace with currUser.
+// Current user isn't included.
+func (repo *UserRepository) GetColleagues(ctx context.Context, currUser *model.User, opts *model.ListOptions) (*model.Page[*model.User], error) {
+	ctx, span := repo.store.startSpan(ctx, "UserRepository.GetColleagues", tracing.UserID(currUser.ID))
+	defer span.End()
+	ctx, cancel := repo.store.queryContext(ctx)
+	defer cancel()
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	columns := pageColumns{title: "u.username", createdAt: "u.created_at", id: "u.id"}
+	condition, order, pageArgs := pageClause(opts, columns, 3)
+	args := append([]any{currUser.ID, likePattern(opts.Title)}, pageArgs...)
+
+	users := make([]*model.User, 0, opts.Limit+1)
+	usrRows, err := repo.store.db.QueryContext(ctx, fmt.Sprintf(getColleaguesQuery, condition, order), args...)
 	if err != nil {
 		return nil, err
 	}
+	defer usrRows.Close()
 
 	for usrRows.Next() {
 		user := model.User{}
@@ -86,12 +130,47 @@ func (repo *UserRepository) GetAllUsers(currUser *model.User) ([]*model.User, er
 		if err != nil {
 			return nil, err
 		}
-
-		// Don't include current user
-		if user.ID == currUser.ID {
-			continue
-		}
 		users = append(users, &user)
 	}
-	return users, nil
+	if err := usrRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return model.NewPage(users, opts, func(user *model.User) *model.Cursor {
+		return opts.CursorFor(user.Username, user.CreatedAt, user.ID)
+	}), nil
+}
+
+// RevokeSessions invalidates all sessions of the user issued till now
+func (repo *UserRepository) RevokeSessions(ctx context.Context, user *model.User) error {
+	ctx, span := repo.store.startSpan(ctx, "UserRepository.RevokeSessions", tracing.UserID(user.ID))
+	defer span.End()
+	ctx, cancel := repo.store.queryContext(ctx)
+	defer cancel()
+
+	// Postgres keeps microseconds only
+	revokedAt := time.Now().Truncate(time.Microsecond)
+	result, err := repo.store.db.ExecContext(ctx, revokeSessionsQuery, revokedAt, user.ID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	user.SessionsRevokedAt = revokedAt
+	return nil
+}
+
+func scanUser(row rowScanner) (*model.User, error) {
+	u := model.User{}
+	var revokedAt sql.NullTime
+
+	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Hash, &u.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		u.SessionsRevokedAt = revokedAt.Time
+	}
+	return &u, nil
 }
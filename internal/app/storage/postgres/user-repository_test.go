@@ -1,6 +1,7 @@
 package postgres_test
 
 import (
+	"context"
 	"testing"
 
 	"Gotcha/internal/app/model"
@@ -10,81 +11,120 @@ import (
 )
 
 func TestUserRepository_NewUser(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	repository := postgres.NewStore(db).User()
 	defer sanitize("Users")
 
 	testUser := model.TestUser(t)
 
-	assert.NoError(t, repository.SaveUser(testUser), "Failed to save valid user")
+	assert.NoError(t, repository.SaveUser(ctx, testUser), "Failed to save valid user")
 	assert.NotEqual(t, testUser.ID, uuid.Nil, "User must have an uuid after save")
 	assert.False(t, testUser.CreatedAt.IsZero(), "User must have a creation time")
 
-	assert.Error(t, repository.SaveUser(testUser), "Duplicate user was created successfully!")
+	assert.Error(t, repository.SaveUser(ctx, testUser), "Duplicate user was created successfully!")
 }
 
 func TestUserRepository_FindUserBySobriquet(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	repository := postgres.NewStore(db).User()
 	defer sanitize("Users")
 
 	testUser := model.TestUser(t)
-	_ = repository.SaveUser(testUser) // Suppose that TestUserRepository_NewUser was passed
+	_ = repository.SaveUser(ctx, testUser) // Suppose that TestUserRepository_NewUser was passed
 
-	sameUser1, err := repository.FindUserBySobriquet(testUser.Username)
+	sameUser1, err := repository.FindUserBySobriquet(ctx, testUser.Username)
 	assert.NoError(t, err, "Failed to get user by username")
 
-	sameUser2, err := repository.FindUserBySobriquet(testUser.Email)
+	sameUser2, err := repository.FindUserBySobriquet(ctx, testUser.Email)
 	assert.NoError(t, err, "Failed to get user by email")
 	assert.Equal(t, sameUser1, sameUser2, "Repository returned different users")
 
-	_, err = repository.FindUserBySobriquet("qwerty123")
+	_, err = repository.FindUserBySobriquet(ctx, "qwerty123")
 	assert.Error(t, err, "Repository returned user of unknown nickname")
 }
 
 func TestUserRepository_FindUserByID(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
 	repository := postgres.NewStore(db).User()
 	defer sanitize("Users")
 
 	testUser := model.TestUser(t)
-	_ = repository.SaveUser(testUser)
+	_ = repository.SaveUser(ctx, testUser)
 
-	foundUser, err := repository.FindUserByID(testUser.ID)
+	foundUser, err := repository.FindUserByID(ctx, testUser.ID)
 	assert.NoError(t, err, "Failed to get user by id")
 	testUser.ClearSensitive()
 	assert.Equal(t, testUser, foundUser)
 
-	_, err = repository.FindUserByID(uuid.Nil)
+	_, err = repository.FindUserByID(ctx, uuid.Nil)
 	assert.Error(t, err, "Repository returned user for nil UUID")
 }
 
-func TestUserRepository_GetAllUsers(t *testing.T) {
+func TestUserRepository_GetColleagues(t *testing.T) {
+	ctx := context.Background()
 	db, sanitize := postgres.TestDB(t, databaseConnectionString)
-	repository := postgres.NewStore(db).User()
-	defer sanitize("Users")
+	store := postgres.NewStore(db)
+	repository := store.User()
+	defer sanitize("Users", "WorkspaceMember", "Workspace")
 
 	viewer := model.TestUser(t)
 	viewer.Email += "v"
 	viewer.Username += "v"
-	_ = repository.SaveUser(viewer)
+	_ = repository.SaveUser(ctx, viewer)
 
 	testUser := model.TestUser(t)
-	_ = repository.SaveUser(testUser)
+	_ = repository.SaveUser(ctx, testUser)
+
+	// Users without common workspaces don't see each other
+	allUsers, err := repository.GetColleagues(ctx, viewer, model.DefaultListOptions())
+	assert.NoError(t, err, "Got error while collecting users")
+	assert.Len(t, allUsers.Items, 0)
 
 	// First case
-	allUsers, err := repository.GetAllUsers(viewer)
+	workspace := model.Workspace{Title: "Acme"}
+	_ = store.Workspace().NewWorkspace(ctx, &workspace, viewer)
+	_ = store.Workspace().AddWorkspaceMember(ctx, workspace.ID, testUser.ID, model.WorkspaceRoleMember, viewer)
+
+	allUsers, err = repository.GetColleagues(ctx, viewer, model.DefaultListOptions())
 	assert.NoError(t, err, "Got error while collecting users")
-	assert.Equal(t, len(allUsers), 1)
-	assert.Equal(t, allUsers[0].ID, testUser.ID)
+	assert.Equal(t, len(allUsers.Items), 1)
+	assert.Equal(t, allUsers.Items[0].ID, testUser.ID)
 
 	// Second case
 	secondUser := model.TestUser(t)
 	secondUser.Username += "s"
 	secondUser.Email += "s"
-	_ = repository.SaveUser(secondUser)
+	_ = repository.SaveUser(ctx, secondUser)
 
-	allUsers, err = repository.GetAllUsers(viewer)
+	allUsers, err = repository.GetColleagues(ctx, viewer, model.DefaultListOptions())
 	assert.NoError(t, err, "Got error while collecting users (second testcase)")
-	assert.Equal(t, len(allUsers), 2)
+	assert.Equal(t, len(allUsers.Items), 1, "User outside of workspace is listed")
+
+	_ = store.Workspace().AddWorkspaceMember(ctx, workspace.ID, secondUser.ID, model.WorkspaceRoleMember, viewer)
+	allUsers, err = repository.GetColleagues(ctx, viewer, model.DefaultListOptions())
+	assert.NoError(t, err, "Got error while collecting users (second testcase)")
+	assert.Equal(t, len(allUsers.Items), 2)
+}
+
+func TestUserRepository_RevokeSessions(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	repository := postgres.NewStore(db).User()
+	defer sanitize("Users")
+
+	testUser := model.TestUser(t)
+	_ = repository.SaveUser(ctx, testUser)
+
+	assert.NoError(t, repository.RevokeSessions(ctx, testUser), "Failed to revoke sessions")
+	assert.False(t, testUser.SessionsRevokedAt.IsZero(), "Revocation time isn't set")
+
+	foundUser, _ := repository.FindUserByID(ctx, testUser.ID)
+	assert.True(t, foundUser.SessionsRevokedAt.Equal(testUser.SessionsRevokedAt), "Revocation isn't saved")
+
+	unknownUser := model.TestUser(t)
+	unknownUser.ID = uuid.New()
+	assert.Error(t, repository.RevokeSessions(ctx, unknownUser), "Revoked sessions of unknown user")
 }
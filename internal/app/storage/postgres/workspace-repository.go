@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	InsertWorkspaceQuery = `
+		INSERT INTO "Workspace"(title) VALUES($1) RETURNING id, created_at;
+	`
+	GetWorkspacesOfUserQuery = `
+		SELECT w.id, w.title, w.created_at FROM "Workspace" w
+			INNER JOIN "WorkspaceMember" wm ON wm.workspace_id = w.id
+		WHERE wm.user_id = $1 ORDER BY w.created_at;
+	`
+	InsertWorkspaceMemberQuery = `
+		INSERT INTO "WorkspaceMember"(workspace_id, user_id, role) VALUES($1, $2, $3);
+	`
+	GetWorkspaceRoleQuery = `
+		SELECT role FROM "WorkspaceMember" WHERE workspace_id = $1 AND user_id = $2;
+	`
+	IsWorkspaceExistsQuery = `
+		SELECT EXISTS(SELECT 1 FROM "Workspace" WHERE id = $1);
+	`
+	GetWorkspaceMembersQuery = `
+		SELECT u.id, u.username, wm.role, wm.created_at FROM "WorkspaceMember" wm
+			INNER JOIN "Users" u ON u.id = wm.user_id
+		WHERE wm.workspace_id = $1 ORDER BY wm.created_at;
+	`
+	LockWorkspaceAdminsQuery = `
+		SELECT user_id FROM "WorkspaceMember" WHERE workspace_id = $1 AND role = 'admin' FOR UPDATE;
+	`
+	UpdateWorkspaceMemberQuery = `
+		UPDATE "WorkspaceMember" SET role = $1 WHERE workspace_id = $2 AND user_id = $3;
+	`
+	DeleteWorkspaceMemberQuery = `
+		DELETE FROM "WorkspaceMember" WHERE workspace_id = $1 AND user_id = $2;
+	`
+)
+
+// WorkspaceRepository interface implementation (depends on SQL database)
+type WorkspaceRepository struct {
+	store *Store
+}
+
+// NewWorkspace saves the workspace, user becomes its first admin
+func (wr *WorkspaceRepository) NewWorkspace(ctx context.Context, workspace *model.Workspace, user *model.User) error {
+	ctx, cancel := wr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := workspace.Validate(); err != nil {
+		return err
+	}
+
+	return wr.store.withTx(ctx, func(txStore *Store) error {
+		if err := txStore.db.QueryRowContext(ctx, InsertWorkspaceQuery, workspace.Title).Scan(&workspace.ID, &workspace.CreatedAt); err != nil {
+			return err
+		}
+		_, err := txStore.db.ExecContext(ctx, InsertWorkspaceMemberQuery, workspace.ID, user.ID, model.WorkspaceRoleAdmin)
+		return err
+	})
+}
+
+func (wr *WorkspaceRepository) GetWorkspacesOfUser(ctx context.Context, user *model.User) ([]*model.Workspace, error) {
+	ctx, cancel := wr.store.queryContext(ctx)
+	defer cancel()
+
+	workspaceRows, err := wr.store.db.QueryContext(ctx, GetWorkspacesOfUserQuery, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer workspaceRows.Close()
+
+	workspaces := make([]*model.Workspace, 0)
+	for workspaceRows.Next() {
+		workspace := model.Workspace{}
+		if err := workspaceRows.Scan(&workspace.ID, &workspace.Title, &workspace.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, &workspace)
+	}
+	return workspaces, workspaceRows.Err()
+}
+
+// GetWorkspaceMembers returns members of the workspace. Only members can see each other
+func (wr *WorkspaceRepository) GetWorkspaceMembers(ctx context.Context, workspaceID uuid.UUID, user *model.User) ([]*model.WorkspaceMember, error) {
+	ctx, cancel := wr.store.queryContext(ctx)
+	defer cancel()
+
+	if _, err := wr.roleOfUser(ctx, workspaceID, user.ID); err != nil {
+		return nil, err
+	}
+
+	memberRows, err := wr.store.db.QueryContext(ctx, GetWorkspaceMembersQuery, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer memberRows.Close()
+
+	members := make([]*model.WorkspaceMember, 0)
+	for memberRows.Next() {
+		member := model.WorkspaceMember{}
+		if err := memberRows.Scan(&member.UserID, &member.Username, &member.Role, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+	return members, memberRows.Err()
+}
+
+// AddWorkspaceMember adds the user to the workspace. Only admins manage members
+func (wr *WorkspaceRepository) AddWorkspaceMember(ctx context.Context, workspaceID, memberID uuid.UUID, role model.WorkspaceRole, user *model.User) error {
+	ctx, cancel := wr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := wr.permitAdmin(ctx, workspaceID, user); err != nil {
+		return err
+	}
+	if _, err := wr.store.User().FindUserByID(ctx, memberID); err != nil {
+		return storage.ErrNotFound
+	}
+
+	_, err := wr.store.db.ExecContext(ctx, InsertWorkspaceMemberQuery, workspaceID, memberID, role)
+	if err != nil && strings.Contains(err.Error(), "duplicate") {
+		return storage.ErrEntityDuplicate
+	}
+	return err
+}
+
+// UpdateWorkspaceMember changes the role of the member. The last admin can't be demoted
+func (wr *WorkspaceRepository) UpdateWorkspaceMember(ctx context.Context, workspaceID, memberID uuid.UUID, role model.WorkspaceRole, user *model.User) error {
+	ctx, cancel := wr.store.queryContext(ctx)
+	defer cancel()
+
+	if err := wr.permitAdmin(ctx, workspaceID, user); err != nil {
+		return err
+	}
+
+	return wr.store.withTx(ctx, func(txStore *Store) error {
+		txRepo := WorkspaceRepository{store: txStore}
+		if err := txRepo.keepLastAdmin(ctx, workspaceID, memberID, role); err != nil {
+			return err
+		}
+		_, err := txStore.db.ExecContext(ctx, UpdateWorkspaceMemberQuery, role, workspaceID, memberID)
+		return err
+	})
+}
+
+// RemoveWorkspaceMember removes the member from the workspace. Admins remove anyone, other members
+// can only leave the workspace. The last admin can't leave.
+func (wr *WorkspaceRepository) RemoveWorkspaceMember(ctx context.Context, workspaceID, memberID uuid.UUID, user *model.User) error {
+	ctx, cancel := wr.store.queryContext(ctx)
+	defer cancel()
+
+	if memberID != user.ID {
+		if err := wr.permitAdmin(ctx, workspaceID, user); err != nil {
+			return err
+		}
+	}
+
+	return wr.store.withTx(ctx, func(txStore *Store) error {
+		txRepo := WorkspaceRepository{store: txStore}
+		if err := txRepo.keepLastAdmin(ctx, workspaceID, memberID, ""); err != nil {
+			return err
+		}
+		_, err := txStore.db.ExecContext(ctx, DeleteWorkspaceMemberQuery, workspaceID, memberID)
+		return err
+	})
+}
+
+// roleOfUser returns storage.ErrNotFound for unknown workspaces and storage.ErrSecurityError
+// if user isn't a member of the workspace
+func (wr *WorkspaceRepository) roleOfUser(ctx context.Context, workspaceID, userID uuid.UUID) (model.WorkspaceRole, error) {
+	var role model.WorkspaceRole
+	err := wr.store.db.QueryRowContext(ctx, GetWorkspaceRoleQuery, workspaceID, userID).Scan(&role)
+	if err == nil {
+		return role, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	var exists bool
+	if err := wr.store.db.QueryRowContext(ctx, IsWorkspaceExistsQuery, workspaceID).Scan(&exists); err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", storage.ErrNotFound
+	}
+	return "", storage.ErrSecurityError
+}
+
+func (wr *WorkspaceRepository) permitAdmin(ctx context.Context, workspaceID uuid.UUID, user *model.User) error {
+	role, err := wr.roleOfUser(ctx, workspaceID, user.ID)
+	if err != nil {
+		return err
+	}
+	if role != model.WorkspaceRoleAdmin {
+		return storage.ErrSecurityError
+	}
+	return nil
+}
+
+// keepLastAdmin fails if the member is the last admin and is going to lose the role.
+// Empty role means that the member leaves the workspace. Must be called inside the transaction.
+func (wr *WorkspaceRepository) keepLastAdmin(ctx context.Context, workspaceID, memberID uuid.UUID, role model.WorkspaceRole) error {
+	current, err := wr.roleOfUser(ctx, workspaceID, memberID)
+	if errors.Is(err, storage.ErrSecurityError) {
+		return storage.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current != model.WorkspaceRoleAdmin || role == model.WorkspaceRoleAdmin {
+		return nil
+	}
+
+	// Rows of admins are locked, so concurrent demotions wait for each other and
+	// the second one sees that only one admin is left
+	adminRows, err := wr.store.db.QueryContext(ctx, LockWorkspaceAdminsQuery, workspaceID)
+	if err != nil {
+		return err
+	}
+	defer adminRows.Close()
+
+	admins, isAdmin := 0, false
+	for adminRows.Next() {
+		var adminID uuid.UUID
+		if err := adminRows.Scan(&adminID); err != nil {
+			return err
+		}
+		admins++
+		isAdmin = isAdmin || adminID == memberID
+	}
+	if err := adminRows.Err(); err != nil {
+		return err
+	}
+	if !isAdmin {
+		// Member was demoted by the concurrent request
+		return nil
+	}
+	if admins <= 1 {
+		return storage.ErrSecurityError
+	}
+	return nil
+}
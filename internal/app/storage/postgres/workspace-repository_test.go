@@ -0,0 +1,152 @@
+package postgres_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"Gotcha/internal/app/storage/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspaceRepository_Members(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users", "UserToBoard", "WorkspaceMember", "Workspace", "Board")
+	workspaceRepo := store.Workspace()
+
+	admin := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, admin)
+	member := model.TestUser(t)
+	member.Username += "member"
+	member.Email += "member"
+	_ = store.User().SaveUser(ctx, member)
+
+	workspace := model.Workspace{Title: "Acme"}
+	assert.NoError(t, workspaceRepo.NewWorkspace(ctx, &workspace, admin), "Failed to create workspace")
+
+	// Only admins manage members
+	assert.ErrorIs(t, workspaceRepo.AddWorkspaceMember(ctx, workspace.ID, member.ID, model.WorkspaceRoleMember, member), storage.ErrSecurityError)
+	assert.NoError(t, workspaceRepo.AddWorkspaceMember(ctx, workspace.ID, member.ID, model.WorkspaceRoleMember, admin), "Failed to add member")
+	assert.ErrorIs(t, workspaceRepo.AddWorkspaceMember(ctx, workspace.ID, member.ID, model.WorkspaceRoleAdmin, admin), storage.ErrEntityDuplicate)
+
+	members, err := workspaceRepo.GetWorkspaceMembers(ctx, workspace.ID, member)
+	assert.NoError(t, err, "Member can't list members")
+	assert.Len(t, members, 2)
+	assert.Equal(t, model.WorkspaceRoleAdmin, members[0].Role)
+
+	workspaces, err := workspaceRepo.GetWorkspacesOfUser(ctx, member)
+	assert.NoError(t, err)
+	assert.Len(t, workspaces, 1)
+
+	// The last admin stays
+	assert.ErrorIs(t, workspaceRepo.UpdateWorkspaceMember(ctx, workspace.ID, admin.ID, model.WorkspaceRoleMember, admin), storage.ErrSecurityError)
+	assert.ErrorIs(t, workspaceRepo.RemoveWorkspaceMember(ctx, workspace.ID, admin.ID, admin), storage.ErrSecurityError)
+
+	assert.ErrorIs(t, workspaceRepo.UpdateWorkspaceMember(ctx, workspace.ID, member.ID, model.WorkspaceRoleAdmin, member), storage.ErrSecurityError)
+	assert.NoError(t, workspaceRepo.UpdateWorkspaceMember(ctx, workspace.ID, member.ID, model.WorkspaceRoleAdmin, admin), "Failed to promote member")
+	assert.NoError(t, workspaceRepo.RemoveWorkspaceMember(ctx, workspace.ID, admin.ID, admin), "Admin can't leave")
+
+	_, err = workspaceRepo.GetWorkspaceMembers(ctx, workspace.ID, admin)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Former member lists members")
+}
+
+func TestWorkspaceRepository_LastAdminConcurrently(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users", "WorkspaceMember", "Workspace")
+	workspaceRepo := store.Workspace()
+
+	admins := make([]*model.User, 4)
+	for i := range admins {
+		admins[i] = model.TestUser(t)
+		admins[i].Username += strconv.Itoa(i)
+		admins[i].Email += strconv.Itoa(i)
+		_ = store.User().SaveUser(ctx, admins[i])
+	}
+	workspace := model.Workspace{Title: "Acme"}
+	_ = workspaceRepo.NewWorkspace(ctx, &workspace, admins[0])
+	for _, admin := range admins[1:] {
+		_ = workspaceRepo.AddWorkspaceMember(ctx, workspace.ID, admin.ID, model.WorkspaceRoleAdmin, admins[0])
+	}
+
+	// All admins leave at once, but one of them stays
+	var wg sync.WaitGroup
+	for _, admin := range admins {
+		wg.Add(1)
+		go func(admin *model.User) {
+			defer wg.Done()
+			_ = workspaceRepo.RemoveWorkspaceMember(ctx, workspace.ID, admin.ID, admin)
+		}(admin)
+	}
+	wg.Wait()
+
+	left := 0
+	for _, admin := range admins {
+		if _, err := workspaceRepo.GetWorkspaceMembers(ctx, workspace.ID, admin); err == nil {
+			left++
+		}
+	}
+	assert.Equal(t, 1, left, "Workspace lost all admins")
+}
+
+func TestWorkspaceRepository_Boards(t *testing.T) {
+	ctx := context.Background()
+	db, sanitize := postgres.TestDB(t, databaseConnectionString)
+	store := postgres.NewStore(db)
+	defer sanitize("Users", "UserToBoard", "WorkspaceMember", "Workspace", "Board")
+	workspaceRepo := store.Workspace()
+
+	admin := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, admin)
+	member := model.TestUser(t)
+	member.Username += "member"
+	member.Email += "member"
+	_ = store.User().SaveUser(ctx, member)
+	stranger := model.TestUser(t)
+	stranger.Username += "stranger"
+	stranger.Email += "stranger"
+	_ = store.User().SaveUser(ctx, stranger)
+
+	workspace := model.Workspace{Title: "Acme"}
+	_ = workspaceRepo.NewWorkspace(ctx, &workspace, admin)
+	_ = workspaceRepo.AddWorkspaceMember(ctx, workspace.ID, member.ID, model.WorkspaceRoleMember, admin)
+
+	_, err := store.Board().NewWorkspaceBoard(ctx, workspace.ID, stranger, "Intrusion")
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Stranger created board in workspace")
+
+	board, err := store.Board().NewWorkspaceBoard(ctx, workspace.ID, admin, "Roadmap")
+	assert.NoError(t, err, "Failed to create workspace board")
+	assert.Equal(t, workspace.ID, board.WorkspaceID)
+
+	// Sharing is scoped to members
+	_, err = store.Board().CreateRelation(ctx, board.Base.ID, stranger.ID, "RO", model.PrivilegeReadOnly)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Board shared outside of workspace")
+	_, err = store.Board().CreateRelation(ctx, board.Base.ID, member.ID, "RW", model.PrivilegeReadWrite)
+	assert.NoError(t, err, "Failed to share board with member")
+
+	boards, _ := store.Board().GetRootBoardsOfUser(ctx, member, model.DefaultListOptions())
+	assert.Len(t, boards.Items, 1)
+
+	// Access ends with membership
+	_ = workspaceRepo.RemoveWorkspaceMember(ctx, workspace.ID, member.ID, member)
+	bp, err := store.Board().GetPermissionOfUser(ctx, board.Base.ID, member.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PrivilegeType(0), bp.Privilege, "Former member has access")
+
+	boards, _ = store.Board().GetRootBoardsOfUser(ctx, member, model.DefaultListOptions())
+	assert.Len(t, boards.Items, 0, "Former member lists workspace boards")
+
+	// Personal boards are shared with colleagues of the author only
+	personalBoard, _ := store.Board().NewRootBoard(ctx, admin, "Personal")
+	_, err = store.Board().CreateRelation(ctx, personalBoard.Base.ID, stranger.ID, "RO", model.PrivilegeReadOnly)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Personal board shared outside of workspaces")
+	_ = workspaceRepo.AddWorkspaceMember(ctx, workspace.ID, stranger.ID, model.WorkspaceRoleMember, admin)
+	_, err = store.Board().CreateRelation(ctx, personalBoard.Base.ID, stranger.ID, "RO", model.PrivilegeReadOnly)
+	assert.NoError(t, err, "Failed to share personal board with colleague")
+}
@@ -1,26 +1,95 @@
 package storage
 
 import (
+	"context"
+
 	"Gotcha/internal/app/model"
 	"github.com/google/uuid"
 )
 
 type UserRepository interface {
-	FindUserBySobriquet(sobriquet string) (*model.User, error)
-	FindUserByID(userID uuid.UUID) (*model.User, error)
-	SaveUser(user *model.User) error
-	GetAllUsers(user *model.User) ([]*model.User, error)
+	FindUserBySobriquet(ctx context.Context, sobriquet string) (*model.User, error)
+	FindUserByID(ctx context.Context, userID uuid.UUID) (*model.User, error)
+	SaveUser(ctx context.Context, user *model.User) error
+	GetColleagues(ctx context.Context, user *model.User, opts *model.ListOptions) (*model.Page[*model.User], error)
+	RevokeSessions(ctx context.Context, user *model.User) error
 }
 
 type BoardRepository interface {
-	NewRootBoard(user *model.User, title string) (*model.Board, error)
-	GetRootBoardsOfUser(user *model.User) ([]*model.Board, error)
-	GetPrivilegeFromRelation(relationID uuid.UUID) (*model.BoardPermission, error)
-	DeleteRootBoard(boardID uuid.UUID, relations []uuid.UUID, user *model.User) error
-	GetRootOfNestedBoard(boardID uuid.UUID) (*model.Board, error)
-	NewNestedBoard(rootBoardID uuid.UUID, title string, user *model.User) (*model.NestedBoard, error)
-	GetNestedBoards(rootBoardID uuid.UUID, user *model.User) ([]*model.NestedBoard, error)
-	DeleteNestedBoard(boardID uuid.UUID, user *model.User) error
-	GetBoardInfo(boardID uuid.UUID) (*model.Board, error)
-	CreateRelation(boardID, userID uuid.UUID, desc string, privilegeType model.PrivilegeType) (uuid.UUID, error)
+	NewRootBoard(ctx context.Context, user *model.User, title string) (*model.Board, error)
+	NewWorkspaceBoard(ctx context.Context, workspaceID uuid.UUID, user *model.User, title string) (*model.Board, error)
+	GetRootBoardsOfUser(ctx context.Context, user *model.User, opts *model.ListOptions) (*model.Page[*model.Board], error)
+	GetPrivilegeFromRelation(ctx context.Context, relationID uuid.UUID) (*model.BoardPermission, error)
+	GetPermissionOfUser(ctx context.Context, boardID, userID uuid.UUID) (*model.BoardPermission, error)
+	DeleteRootBoard(ctx context.Context, boardID uuid.UUID, user *model.User) error
+	GetRootOfNestedBoard(ctx context.Context, boardID uuid.UUID) (*model.Board, error)
+	NewNestedBoard(ctx context.Context, rootBoardID uuid.UUID, title string, user *model.User) (*model.NestedBoard, error)
+	GetNestedBoards(ctx context.Context, rootBoardID uuid.UUID, user *model.User) ([]*model.NestedBoard, error)
+	GetBoardTree(ctx context.Context, boardID uuid.UUID, maxDepth int, user *model.User) (*model.BoardTree, error)
+	DeleteNestedBoard(ctx context.Context, boardID uuid.UUID, user *model.User) error
+	GetBoardInfo(ctx context.Context, boardID uuid.UUID) (*model.Board, error)
+	CreateRelation(ctx context.Context, boardID, userID uuid.UUID, desc string, privilegeType model.PrivilegeType) (uuid.UUID, error)
+	GetCollaborators(ctx context.Context, boardID uuid.UUID, user *model.User) ([]*model.Collaborator, error)
+	UpdateCollaborator(ctx context.Context, boardID, collaboratorID uuid.UUID, privilegeType model.PrivilegeType, user *model.User) error
+	RevokeCollaborator(ctx context.Context, boardID, collaboratorID uuid.UUID, user *model.User) error
+	TransferBoard(ctx context.Context, boardID, newAuthorID uuid.UUID, keepAccess bool, user *model.User) (*model.BoardTransfer, error)
+}
+
+type NoteRepository interface {
+	NewNote(ctx context.Context, boardID uuid.UUID, note *model.Note, user *model.User) error
+	GetNotes(ctx context.Context, boardID uuid.UUID, user *model.User) ([]*model.Note, error)
+	GetNote(ctx context.Context, boardID uuid.UUID, noteID int, user *model.User) (*model.Note, error)
+	UpdateNote(ctx context.Context, boardID uuid.UUID, note *model.Note, user *model.User) error
+	DeleteNote(ctx context.Context, boardID uuid.UUID, noteID int, user *model.User) error
+}
+
+type TokenRepository interface {
+	NewToken(ctx context.Context, token *model.APIToken) error
+	GetTokensOfUser(ctx context.Context, user *model.User) ([]*model.APIToken, error)
+	FindTokenByHash(ctx context.Context, hash string) (*model.APIToken, error)
+	RevokeToken(ctx context.Context, tokenID uuid.UUID, user *model.User) error
+}
+
+// ShareRepository manages public read-only links of boards. Shared reads are scoped to the
+// tree of the link board and don't require user at all.
+type ShareRepository interface {
+	NewShareLink(ctx context.Context, link *model.ShareLink, user *model.User) error
+	GetShareLinks(ctx context.Context, boardID uuid.UUID, user *model.User) ([]*model.ShareLink, error)
+	RevokeShareLink(ctx context.Context, boardID, linkID uuid.UUID, user *model.User) error
+	FindShareLinkByHash(ctx context.Context, hash string) (*model.ShareLink, error)
+	GetSharedTree(ctx context.Context, link *model.ShareLink, maxDepth int) (*model.BoardTree, error)
+	GetSharedNotes(ctx context.Context, link *model.ShareLink, boardID uuid.UUID) ([]*model.Note, error)
+}
+
+// TeamRepository manages teams and their relations with root boards. Privileges granted to
+// the team are shared by all of its members.
+type TeamRepository interface {
+	NewTeam(ctx context.Context, team *model.Team, user *model.User) error
+	GetTeamsOfUser(ctx context.Context, user *model.User) ([]*model.Team, error)
+	GetTeamMembers(ctx context.Context, teamID uuid.UUID, user *model.User) ([]*model.TeamMember, error)
+	AddTeamMember(ctx context.Context, teamID, memberID uuid.UUID, user *model.User) error
+	RemoveTeamMember(ctx context.Context, teamID, memberID uuid.UUID, user *model.User) error
+	GrantTeam(ctx context.Context, boardID, teamID uuid.UUID, desc string, privilegeType model.PrivilegeType, user *model.User) (uuid.UUID, error)
+	RevokeTeam(ctx context.Context, boardID, teamID uuid.UUID, user *model.User) error
+}
+
+// WorkspaceRepository manages workspaces and their members. Boards of the workspace, sharing and
+// user listing are scoped to members of the workspace.
+type WorkspaceRepository interface {
+	NewWorkspace(ctx context.Context, workspace *model.Workspace, user *model.User) error
+	GetWorkspacesOfUser(ctx context.Context, user *model.User) ([]*model.Workspace, error)
+	GetWorkspaceMembers(ctx context.Context, workspaceID uuid.UUID, user *model.User) ([]*model.WorkspaceMember, error)
+	AddWorkspaceMember(ctx context.Context, workspaceID, memberID uuid.UUID, role model.WorkspaceRole, user *model.User) error
+	UpdateWorkspaceMember(ctx context.Context, workspaceID, memberID uuid.UUID, role model.WorkspaceRole, user *model.User) error
+	RemoveWorkspaceMember(ctx context.Context, workspaceID, memberID uuid.UUID, user *model.User) error
+}
+
+// SearchRepository finds boards and notes, that user can access, by the text query
+type SearchRepository interface {
+	Search(ctx context.Context, query string, limit int, user *model.User) ([]*model.SearchResult, error)
+}
+
+// StatsRepository provides instance-wide figures for monitoring
+type StatsRepository interface {
+	GetTotals(ctx context.Context) (*model.Totals, error)
 }
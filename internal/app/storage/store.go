@@ -1,12 +1,22 @@
 package storage
 
 import (
+	"context"
 	"errors"
 )
 
 type Storage interface {
 	Board() BoardRepository
 	User() UserRepository
+	Note() NoteRepository
+	Token() TokenRepository
+	Share() ShareRepository
+	Team() TeamRepository
+	Workspace() WorkspaceRepository
+	Search() SearchRepository
+	Stats() StatsRepository
+	// WithTx runs all operations of fn atomically. Storage passed to fn must not leak out of it.
+	WithTx(ctx context.Context, fn func(Storage) error) error
 	Close()
 }
 
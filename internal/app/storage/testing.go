@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"Gotcha/internal/app/model"
+)
+
+// TestColleagues adds users to the new workspace of the first one, so personal boards
+// can be shared between them
+func TestColleagues(t *testing.T, store Storage, users ...*model.User) *model.Workspace {
+	t.Helper()
+
+	ctx := context.Background()
+	workspace := model.Workspace{Title: "Colleagues"}
+	if err := store.Workspace().NewWorkspace(ctx, &workspace, users[0]); err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range users[1:] {
+		if err := store.Workspace().AddWorkspaceMember(ctx, workspace.ID, user.ID, model.WorkspaceRoleMember, users[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &workspace
+}
@@ -3,9 +3,12 @@
 package teststore
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"time"
 
+	"Gotcha/internal/app/authorization"
 	"Gotcha/internal/app/model"
 	"Gotcha/internal/app/storage"
 	"github.com/google/uuid"
@@ -16,6 +19,7 @@ type Relation struct {
 	BoardID       uuid.UUID
 	UserID        uuid.UUID
 	Description   string
+	CreatedAt     time.Time
 	privilegeType model.PrivilegeType
 }
 
@@ -31,38 +35,86 @@ type BoardRepository struct {
 	NestedRelations map[uuid.UUID]*NestedRelation // root board: relation
 	Boards          map[uuid.UUID]*model.Board
 	NestedBoards    map[uuid.UUID]*model.NestedBoard
+	Transfers       []*model.BoardTransfer
 }
 
-func (b *BoardRepository) NewRootBoard(user *model.User, title string) (*model.Board, error) {
+func (b *BoardRepository) NewRootBoard(ctx context.Context, user *model.User, title string) (*model.Board, error) {
+	return b.NewWorkspaceBoard(ctx, uuid.Nil, user, title)
+}
 
+func (b *BoardRepository) NewWorkspaceBoard(ctx context.Context, workspaceID uuid.UUID, user *model.User, title string) (*model.Board, error) {
 	board := model.NewBoard(title)
+	board.WorkspaceID = workspaceID
+	board.Privilege = model.PrivilegeAuthor
 	if err := board.Base.Validate(); err != nil {
 		return nil, err
 	}
+	if workspaceID != uuid.Nil {
+		if _, err := b.workspaces().roleOfUser(workspaceID, user.ID); err != nil {
+			return nil, err
+		}
+	}
 
-	board.Base.CreatedAt = time.Now()
-	board.Base.ID = uuid.New()
-	b.Boards[board.Base.ID] = board
+	// Board and its author relation are saved atomically
+	err := b.storage.WithTx(ctx, func(storage.Storage) error {
+		board.Base.CreatedAt = time.Now()
+		board.Base.ID = uuid.New()
+		b.Boards[board.Base.ID] = board
 
-	_, err := b.CreateRelation(board.Base.ID, user.ID, "Root board", model.PrivilegeAuthor)
+		_, err := b.CreateRelation(ctx, board.Base.ID, user.ID, "Root board", model.PrivilegeAuthor)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-
 	return board, nil
 }
 
-func (b *BoardRepository) GetRootBoardsOfUser(user *model.User) ([]*model.Board, error) {
-	boards := make([]*model.Board, 0, 2)
+func (b *BoardRepository) GetRootBoardsOfUser(ctx context.Context, user *model.User, opts *model.ListOptions) (*model.Page[*model.Board], error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	boardIDs := make([]uuid.UUID, 0, 2)
 	for _, relation := range b.Relations {
 		if relation.UserID == user.ID {
-			boards = append(boards, b.Boards[relation.BoardID])
+			boardIDs = append(boardIDs, relation.BoardID)
 		}
 	}
-	return boards, nil
+	// Boards reachable via teams
+	if b.storage.teamRepository != nil {
+		boardIDs = append(boardIDs, b.storage.teamRepository.boardsOfUser(user.ID)...)
+	}
+
+	boards := make([]*model.Board, 0, len(boardIDs))
+	seen := make(map[uuid.UUID]bool)
+	for _, boardID := range boardIDs {
+		if seen[boardID] {
+			continue
+		}
+		seen[boardID] = true
+
+		// Zero privilege on boards of workspaces user has left
+		bp, err := b.GetPermissionOfUser(ctx, boardID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if bp.Privilege == 0 || (opts.Privilege != 0 && bp.Privilege != opts.Privilege) {
+			continue
+		}
+
+		boardCopy := *b.Boards[boardID]
+		boardCopy.U2BRelations = append([]uuid.UUID{}, boardCopy.U2BRelations...)
+		boardCopy.Privilege = bp.Privilege
+		boards = append(boards, &boardCopy)
+	}
+
+	return paginate(boards, opts, func(board *model.Board) pageKeys {
+		return pageKeys{title: board.Base.Title, createdAt: board.Base.CreatedAt, id: board.Base.ID}
+	}), nil
 }
 
-func (b *BoardRepository) GetPrivilegeFromRelation(relationID uuid.UUID) (*model.BoardPermission, error) {
+func (b *BoardRepository) GetPrivilegeFromRelation(ctx context.Context, relationID uuid.UUID) (*model.BoardPermission, error) {
 	for _, relation := range b.Relations {
 		if relation.ID == relationID {
 			return &model.BoardPermission{
@@ -75,11 +127,23 @@ func (b *BoardRepository) GetPrivilegeFromRelation(relationID uuid.UUID) (*model
 	return nil, storage.ErrNotFound
 }
 
-func (b *BoardRepository) CreateRelation(boardID, userID uuid.UUID, desc string, privilegeType model.PrivilegeType) (uuid.UUID, error) {
+func (b *BoardRepository) CreateRelation(ctx context.Context, boardID, userID uuid.UUID, desc string, privilegeType model.PrivilegeType) (uuid.UUID, error) {
+	if _, found := b.Boards[boardID]; !found {
+		return uuid.Nil, storage.ErrNotFound
+	}
+	// Boards are shared inside the workspace only
+	if !b.canShareWith(boardID, userID) {
+		return uuid.Nil, storage.ErrSecurityError
+	}
+	if b.findRelation(boardID, userID) != nil {
+		return uuid.Nil, storage.ErrEntityDuplicate
+	}
+
 	rel := Relation{
 		ID:            uuid.New(),
 		BoardID:       boardID,
 		Description:   desc,
+		CreatedAt:     time.Now(),
 		privilegeType: privilegeType,
 		UserID:        userID,
 	}
@@ -90,109 +154,340 @@ func (b *BoardRepository) CreateRelation(boardID, userID uuid.UUID, desc string,
 	return rel.ID, nil
 }
 
-func (b *BoardRepository) DeleteRootBoard(boardID uuid.UUID, relations []uuid.UUID, user *model.User) error {
-	for _, givenRelation := range relations {
-		currBoardPermission, err := b.GetPrivilegeFromRelation(givenRelation)
+func (b *BoardRepository) GetPermissionOfUser(ctx context.Context, boardID, userID uuid.UUID) (*model.BoardPermission, error) {
+	rootBoard, err := b.GetRootOfNestedBoard(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
 
-		// User attempted to bypass mitigations
-		if currBoardPermission.BoardID != boardID || currBoardPermission.UserID != user.ID || err != nil {
-			return storage.ErrSecurityError
+	bp := model.BoardPermission{BoardID: rootBoard.Base.ID, UserID: userID}
+	// Relations of former workspace members don't work anymore
+	if !b.workspaces().isMember(b.Boards[rootBoard.Base.ID].WorkspaceID, userID) {
+		return &bp, nil
+	}
+	for _, relation := range b.Relations {
+		if relation.BoardID == rootBoard.Base.ID && relation.UserID == userID {
+			bp.Privilege = model.StrongestPrivilege(bp.Privilege, relation.privilegeType)
 		}
-		if currBoardPermission.Privilege == model.PrivilegeAuthor {
-			for i, rel := range b.Relations {
-				if rel.BoardID == boardID {
-					b.Relations = append(b.Relations[:i], b.Relations[i+1:]...)
-				}
-			}
-			delete(b.Boards, boardID)
-			return nil
+	}
+	if b.storage.teamRepository != nil {
+		for _, privilege := range b.storage.teamRepository.privilegesOfUser(rootBoard.Base.ID, userID) {
+			bp.Privilege = model.StrongestPrivilege(bp.Privilege, privilege)
 		}
 	}
-	return storage.ErrSecurityError
+	return &bp, nil
 }
 
-func (b *BoardRepository) GetRootOfNestedBoard(nestedBoardID uuid.UUID) (*model.Board, error) {
+func (b *BoardRepository) DeleteRootBoard(ctx context.Context, boardID uuid.UUID, user *model.User) error {
+	if err := b.permitRootBoardAction(ctx, boardID, authorization.DeleteRootBoard, user); err != nil {
+		return err
+	}
+
+	relations := make([]*Relation, 0, len(b.Relations))
+	for _, rel := range b.Relations {
+		if rel.BoardID != boardID {
+			relations = append(relations, rel)
+		}
+	}
+	b.Relations = relations
+	if b.storage.teamRepository != nil {
+		b.storage.teamRepository.deleteRelations(func(rel *TeamRelation) bool { return rel.BoardID == boardID })
+	}
+	b.deleteSubtree(boardID)
+	delete(b.Boards, boardID)
+	return nil
+}
+
+func (b *BoardRepository) GetRootOfNestedBoard(ctx context.Context, nestedBoardID uuid.UUID) (*model.Board, error) {
 	currRelation, found := b.NestedRelations[nestedBoardID]
 	if !found {
 		// If not found, then it's a root board
-		return b.GetBoardInfo(nestedBoardID)
+		return b.GetBoardInfo(ctx, nestedBoardID)
+	}
+	return b.GetRootOfNestedBoard(ctx, currRelation.BoardID)
+}
+
+func (b *BoardRepository) NewNestedBoard(ctx context.Context, rootBoardID uuid.UUID, title string, user *model.User) (*model.NestedBoard, error) {
+	if err := (&model.BaseBoard{Title: title}).Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := authorization.New(b).Can(ctx, user, authorization.CreateNestedBoard, rootBoardID); err != nil {
+		return nil, err
+	}
+
+	// Create board
+	nestedBoard := model.NestedBoard{
+		Base: model.BaseBoard{
+			Title:     title,
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+		},
+		RootBoard: rootBoardID,
+	}
+	b.NestedBoards[nestedBoard.Base.ID] = &nestedBoard
+
+	// Create relation
+	rel := NestedRelation{
+		RelationID:    uuid.New(),
+		BoardID:       rootBoardID,
+		NestedBoardID: nestedBoard.Base.ID,
+	}
+	b.NestedRelations[nestedBoard.Base.ID] = &rel
+	nestedBoard.RelationID = rel.RelationID
+	return &nestedBoard, nil
+}
+
+func (b *BoardRepository) GetNestedBoards(ctx context.Context, rootBoardID uuid.UUID, user *model.User) ([]*model.NestedBoard, error) {
+	if err := authorization.New(b).Can(ctx, user, authorization.ViewBoard, rootBoardID); err != nil {
+		return nil, err
+	}
+
+	boards := make([]*model.NestedBoard, 0)
+	for _, rel := range b.NestedRelations {
+		if rel.BoardID == rootBoardID {
+			boards = append(boards, b.NestedBoards[rel.NestedBoardID])
+		}
+	}
+	sort.Slice(boards, func(i, j int) bool {
+		return boards[i].Base.CreatedAt.Before(boards[j].Base.CreatedAt)
+	})
+	return boards, nil
+}
+
+func (b *BoardRepository) GetBoardTree(ctx context.Context, boardID uuid.UUID, maxDepth int, user *model.User) (*model.BoardTree, error) {
+	if err := authorization.New(b).Can(ctx, user, authorization.ViewBoard, boardID); err != nil {
+		return nil, err
+	}
+	return b.boardTree(boardID, maxDepth)
+}
+
+func (b *BoardRepository) boardTree(boardID uuid.UUID, maxDepth int) (*model.BoardTree, error) {
+	var base model.BaseBoard
+	if board, found := b.Boards[boardID]; found {
+		base = board.Base
+	} else if board, found := b.NestedBoards[boardID]; found {
+		base = board.Base
+	} else {
+		return nil, storage.ErrNotFound
+	}
+	return b.walkTree(base, maxDepth), nil
+}
+
+// walkTree is a recursive equivalent of the CTE used by postgres implementation
+func (b *BoardRepository) walkTree(base model.BaseBoard, depth int) *model.BoardTree {
+	node := model.NewBoardTree(base)
+	for _, rel := range b.NestedRelations {
+		if rel.BoardID != base.ID {
+			continue
+		}
+		node.ChildCount++
+		if depth > 0 {
+			node.Children = append(node.Children, b.walkTree(b.NestedBoards[rel.NestedBoardID].Base, depth-1))
+		}
+	}
+
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Base.CreatedAt.Before(node.Children[j].Base.CreatedAt)
+	})
+	return node
+}
+
+func (b *BoardRepository) DeleteNestedBoard(ctx context.Context, boardID uuid.UUID, user *model.User) error {
+	if err := b.permitNestedBoardAction(ctx, boardID, authorization.DeleteNestedBoard, user); err != nil {
+		return err
+	}
+
+	b.deleteSubtree(boardID)
+	return nil
+}
+
+// deleteSubtree removes the nested boards of the board on any depth with their notes and share links.
+// The board itself loses its notes, share links and the relation with the parent.
+func (b *BoardRepository) deleteSubtree(boardID uuid.UUID) {
+	for nestedBoardID, rel := range b.NestedRelations {
+		if rel.BoardID == boardID {
+			b.deleteSubtree(nestedBoardID)
+		}
+	}
+
+	if rel, found := b.NestedRelations[boardID]; found && b.storage.noteRepository != nil {
+		b.storage.noteRepository.deleteNotesOfBridge(rel.RelationID)
+	}
+	if b.storage.shareRepository != nil {
+		b.storage.shareRepository.deleteLinksOfBoard(boardID)
+	}
+	delete(b.NestedRelations, boardID)
+	delete(b.NestedBoards, boardID)
+}
+
+func (b *BoardRepository) GetCollaborators(ctx context.Context, boardID uuid.UUID, user *model.User) ([]*model.Collaborator, error) {
+	if err := b.permitRootBoardAction(ctx, boardID, authorization.ManageCollaborators, user); err != nil {
+		return nil, err
+	}
+
+	collaborators := make([]*model.Collaborator, 0)
+	for _, rel := range b.Relations {
+		if rel.BoardID != boardID {
+			continue
+		}
+		collaborator, err := b.storage.User().FindUserByID(ctx, rel.UserID)
+		if err != nil {
+			return nil, err
+		}
+		collaborators = append(collaborators, &model.Collaborator{
+			RelationID:  rel.ID,
+			UserID:      rel.UserID,
+			Username:    collaborator.Username,
+			Privilege:   rel.privilegeType,
+			Description: rel.Description,
+			GrantedAt:   rel.CreatedAt,
+		})
 	}
-	return b.GetRootOfNestedBoard(currRelation.BoardID)
+	return collaborators, nil
 }
 
-func (b *BoardRepository) NewNestedBoard(rootBoardID uuid.UUID, title string, user *model.User) (*model.NestedBoard, error) {
-	rootBoard, err := b.GetRootOfNestedBoard(rootBoardID)
+func (b *BoardRepository) UpdateCollaborator(ctx context.Context, boardID, collaboratorID uuid.UUID, privilegeType model.PrivilegeType, user *model.User) error {
+	if privilegeType != model.PrivilegeReadOnly && privilegeType != model.PrivilegeReadWrite {
+		return storage.ErrSecurityError
+	}
+	if err := b.permitRootBoardAction(ctx, boardID, authorization.ManageCollaborators, user); err != nil {
+		return err
+	}
+	rel, err := b.findCollaborator(boardID, collaboratorID)
 	if err != nil {
+		return err
+	}
+
+	rel.privilegeType = privilegeType
+	return nil
+}
+
+func (b *BoardRepository) RevokeCollaborator(ctx context.Context, boardID, collaboratorID uuid.UUID, user *model.User) error {
+	if err := b.permitRootBoardAction(ctx, boardID, authorization.ManageCollaborators, user); err != nil {
+		return err
+	}
+	revoked, err := b.findCollaborator(boardID, collaboratorID)
+	if err != nil {
+		return err
+	}
+	b.removeRelation(revoked)
+	return nil
+}
+
+func (b *BoardRepository) TransferBoard(ctx context.Context, boardID, newAuthorID uuid.UUID, keepAccess bool, user *model.User) (*model.BoardTransfer, error) {
+	if err := b.permitRootBoardAction(ctx, boardID, authorization.TransferBoard, user); err != nil {
 		return nil, err
 	}
-	for _, rel := range rootBoard.U2BRelations {
-		bp, _ := b.GetPrivilegeFromRelation(rel)
-		if (bp.Privilege == model.PrivilegeReadWrite || bp.Privilege == model.PrivilegeAuthor) && bp.UserID == user.ID {
-			// Create board
-			nestedBoard := model.NestedBoard{
-				Base: model.BaseBoard{
-					Title:     title,
-					ID:        uuid.New(),
-					CreatedAt: time.Now(),
-				},
-				RootBoard: rootBoardID,
-			}
-			b.NestedBoards[nestedBoard.Base.ID] = &nestedBoard
-
-			// Create relation
-			rel := NestedRelation{
-				RelationID:    uuid.New(),
-				BoardID:       rootBoardID,
-				NestedBoardID: nestedBoard.Base.ID,
-			}
-			b.NestedRelations[nestedBoard.Base.ID] = &rel
-			nestedBoard.RelationID = rel.RelationID
-			return &nestedBoard, nil
-		}
-	}
-
-	return nil, storage.ErrSecurityError
-}
-
-func (b *BoardRepository) GetNestedBoards(rootBoardID uuid.UUID, user *model.User) ([]*model.NestedBoard, error) {
-	rootBoard, err := b.GetRootOfNestedBoard(rootBoardID)
+	newAuthor, err := b.findCollaborator(boardID, newAuthorID)
 	if err != nil {
 		return nil, err
 	}
-	for _, rel := range rootBoard.U2BRelations {
-		bp, _ := b.GetPrivilegeFromRelation(rel)
+	if !b.canShareWith(boardID, newAuthorID) {
+		return nil, storage.ErrSecurityError
+	}
+
+	newAuthor.privilegeType = model.PrivilegeAuthor
+	if keepAccess {
+		b.findRelation(boardID, user.ID).privilegeType = model.PrivilegeReadWrite
+	} else {
+		b.removeRelation(b.findRelation(boardID, user.ID))
+	}
+
+	transfer := model.BoardTransfer{
+		ID:         uuid.New(),
+		BoardID:    boardID,
+		FromUserID: user.ID,
+		ToUserID:   newAuthorID,
+		KeepAccess: keepAccess,
+		CreatedAt:  time.Now(),
+	}
+	b.Transfers = append(b.Transfers, &transfer)
+	return &transfer, nil
+}
 
-		// Any permission allows user to see the nested boards
-		if bp.UserID == user.ID {
-			boards := make([]*model.NestedBoard, 0)
-			for _, rel := range b.NestedRelations {
-				if rel.BoardID == rootBoardID {
-					boards = append(boards, b.NestedBoards[rel.NestedBoardID])
-				}
-			}
-			return boards, nil
+func (b *BoardRepository) workspaces() *WorkspaceRepository {
+	b.storage.Workspace()
+	return b.storage.workspaceRepository
+}
+
+// canShareWith checks, that the board of the workspace is shared with its member. Personal board
+// is shared with the users, who share a workspace with its author
+func (b *BoardRepository) canShareWith(boardID, userID uuid.UUID) bool {
+	if workspaceID := b.Boards[boardID].WorkspaceID; workspaceID != uuid.Nil {
+		return b.workspaces().isMember(workspaceID, userID)
+	}
+	for _, rel := range b.Relations {
+		if rel.BoardID == boardID && rel.privilegeType == model.PrivilegeAuthor && rel.UserID != userID {
+			return b.workspaces().areColleagues(rel.UserID, userID)
 		}
 	}
-	return nil, storage.ErrSecurityError
+	return true
 }
 
-func (b *BoardRepository) DeleteNestedBoard(boardID uuid.UUID, user *model.User) error {
-	rootBoard, err := b.GetRootOfNestedBoard(boardID)
+func (b *BoardRepository) permitRootBoardAction(ctx context.Context, boardID uuid.UUID, action authorization.Action, user *model.User) error {
+	bp, err := authorization.New(b).Permission(ctx, user, boardID)
 	if err != nil {
 		return err
 	}
-	for _, rel := range rootBoard.U2BRelations {
-		bp, _ := b.GetPrivilegeFromRelation(rel)
-		if (bp.Privilege == model.PrivilegeAuthor || bp.Privilege == model.PrivilegeReadWrite) && user.ID == bp.UserID {
-			delete(b.NestedRelations, boardID)
-			delete(b.NestedBoards, boardID)
-			return nil
+	if bp.BoardID != boardID || !authorization.Allowed(bp.Privilege, action) {
+		return storage.ErrSecurityError
+	}
+	return nil
+}
+
+// permitNestedBoardAction checks that the board is a nested one and user is allowed to perform the action
+func (b *BoardRepository) permitNestedBoardAction(ctx context.Context, boardID uuid.UUID, action authorization.Action, user *model.User) error {
+	bp, err := authorization.New(b).Permission(ctx, user, boardID)
+	if err != nil {
+		return err
+	}
+	if bp.BoardID == boardID || !authorization.Allowed(bp.Privilege, action) {
+		return storage.ErrSecurityError
+	}
+	return nil
+}
+
+// findCollaborator returns relation of the user with the board. The author relation is untouchable.
+func (b *BoardRepository) findCollaborator(boardID, collaboratorID uuid.UUID) (*Relation, error) {
+	rel := b.findRelation(boardID, collaboratorID)
+	if rel == nil {
+		return nil, storage.ErrNotFound
+	}
+	if rel.privilegeType == model.PrivilegeAuthor {
+		return nil, storage.ErrSecurityError
+	}
+	return rel, nil
+}
+
+func (b *BoardRepository) removeRelation(removed *Relation) {
+	relations := make([]*Relation, 0, len(b.Relations))
+	for _, rel := range b.Relations {
+		if rel != removed {
+			relations = append(relations, rel)
+		}
+	}
+	b.Relations = relations
+
+	board := b.Boards[removed.BoardID]
+	for i, id := range board.U2BRelations {
+		if id == removed.ID {
+			board.U2BRelations = append(board.U2BRelations[:i:i], board.U2BRelations[i+1:]...)
+			break
+		}
+	}
+}
+
+func (b *BoardRepository) findRelation(boardID, userID uuid.UUID) *Relation {
+	for _, rel := range b.Relations {
+		if rel.BoardID == boardID && rel.UserID == userID {
+			return rel
 		}
 	}
-	return storage.ErrSecurityError
+	return nil
 }
 
-func (b *BoardRepository) GetBoardInfo(boardID uuid.UUID) (*model.Board, error) {
+func (b *BoardRepository) GetBoardInfo(ctx context.Context, boardID uuid.UUID) (*model.Board, error) {
 	board, found := b.Boards[boardID]
 	if !found {
 		return nil, storage.ErrNotFound
@@ -0,0 +1,171 @@
+package teststore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"Gotcha/internal/app/authorization"
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+type NoteRepository struct {
+	storage *Storage
+	lastID  int
+	Notes   map[int]*model.Note
+}
+
+func (n *NoteRepository) authorizer() *authorization.Authorizer {
+	return authorization.New(n.storage.Board())
+}
+
+func (n *NoteRepository) getBridge(ctx context.Context, boardID uuid.UUID) (uuid.UUID, error) {
+	n.storage.Board()
+	relation, found := n.storage.boardRepository.NestedRelations[boardID]
+	if !found {
+		return uuid.Nil, storage.ErrNotFound
+	}
+	return relation.RelationID, nil
+}
+
+func (n *NoteRepository) findNote(ctx context.Context, bridgeID uuid.UUID, noteID int) (*model.Note, error) {
+	note, found := n.Notes[noteID]
+	if !found || note.BoardBridgeID != bridgeID {
+		return nil, storage.ErrNotFound
+	}
+	return note, nil
+}
+
+func (n *NoteRepository) NewNote(ctx context.Context, boardID uuid.UUID, note *model.Note, user *model.User) error {
+	if err := note.Validate(); err != nil {
+		return err
+	}
+
+	if err := n.authorizer().Can(ctx, user, authorization.NoteAction(note.ReadOnly), boardID); err != nil {
+		return err
+	}
+
+	bridgeID, err := n.getBridge(ctx, boardID)
+	if err != nil {
+		return err
+	}
+
+	n.lastID++
+	note.ID = n.lastID
+	note.BoardBridgeID = bridgeID
+	note.CreatedAt = time.Now()
+
+	savedNote := *note
+	n.Notes[note.ID] = &savedNote
+	return nil
+}
+
+func (n *NoteRepository) GetNotes(ctx context.Context, boardID uuid.UUID, user *model.User) ([]*model.Note, error) {
+	if err := n.authorizer().Can(ctx, user, authorization.ViewNote, boardID); err != nil {
+		return nil, err
+	}
+
+	bridgeID, err := n.getBridge(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	return n.notesOfBridge(bridgeID), nil
+}
+
+func (n *NoteRepository) notesOfBridge(bridgeID uuid.UUID) []*model.Note {
+	notes := make([]*model.Note, 0)
+	for _, note := range n.Notes {
+		if note.BoardBridgeID == bridgeID {
+			noteCopy := *note
+			notes = append(notes, &noteCopy)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].ID < notes[j].ID
+	})
+	return notes
+}
+
+func (n *NoteRepository) GetNote(ctx context.Context, boardID uuid.UUID, noteID int, user *model.User) (*model.Note, error) {
+	if err := n.authorizer().Can(ctx, user, authorization.ViewNote, boardID); err != nil {
+		return nil, err
+	}
+
+	bridgeID, err := n.getBridge(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := n.findNote(ctx, bridgeID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	noteCopy := *note
+	return &noteCopy, nil
+}
+
+func (n *NoteRepository) UpdateNote(ctx context.Context, boardID uuid.UUID, note *model.Note, user *model.User) error {
+	if err := note.Validate(); err != nil {
+		return err
+	}
+
+	permission, err := n.authorizer().Permission(ctx, user, boardID)
+	if err != nil {
+		return err
+	}
+
+	bridgeID, err := n.getBridge(ctx, boardID)
+	if err != nil {
+		return err
+	}
+
+	savedNote, err := n.findNote(ctx, bridgeID, note.ID)
+	if err != nil {
+		return err
+	}
+	if !authorization.Allowed(permission.Privilege, authorization.NoteAction(savedNote.ReadOnly || note.ReadOnly)) {
+		return storage.ErrSecurityError
+	}
+
+	savedNote.Title = note.Title
+	savedNote.Content = note.Content
+	savedNote.ReadOnly = note.ReadOnly
+
+	note.BoardBridgeID = bridgeID
+	note.CreatedAt = savedNote.CreatedAt
+	return nil
+}
+
+func (n *NoteRepository) DeleteNote(ctx context.Context, boardID uuid.UUID, noteID int, user *model.User) error {
+	permission, err := n.authorizer().Permission(ctx, user, boardID)
+	if err != nil {
+		return err
+	}
+
+	bridgeID, err := n.getBridge(ctx, boardID)
+	if err != nil {
+		return err
+	}
+
+	savedNote, err := n.findNote(ctx, bridgeID, noteID)
+	if err != nil {
+		return err
+	}
+	if !authorization.Allowed(permission.Privilege, authorization.NoteAction(savedNote.ReadOnly)) {
+		return storage.ErrSecurityError
+	}
+
+	delete(n.Notes, noteID)
+	return nil
+}
+
+// deleteNotesOfBridge is a cascade helper for nested boards removal
+func (n *NoteRepository) deleteNotesOfBridge(bridgeID uuid.UUID) {
+	for id, note := range n.Notes {
+		if note.BoardBridgeID == bridgeID {
+			delete(n.Notes, id)
+		}
+	}
+}
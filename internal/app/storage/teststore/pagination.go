@@ -0,0 +1,73 @@
+package teststore
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"github.com/google/uuid"
+)
+
+// pageKeys are the values of the item used for sort, filter and cursor
+type pageKeys struct {
+	title     string
+	createdAt time.Time
+	id        uuid.UUID
+}
+
+// compare orders keys by the sort field of opts and then by id
+func (k pageKeys) compare(other pageKeys, sortField model.SortField) int {
+	result := 0
+	switch sortField {
+	case model.SortByTitle:
+		result = strings.Compare(k.title, other.title)
+	default:
+		if k.createdAt.Before(other.createdAt) {
+			result = -1
+		} else if k.createdAt.After(other.createdAt) {
+			result = 1
+		}
+	}
+	if result == 0 {
+		result = bytes.Compare(k.id[:], other.id[:])
+	}
+	return result
+}
+
+// paginate emulates keyset pagination of postgres: filters items by title, orders them and
+// returns the page after the cursor
+func paginate[T any](items []T, opts *model.ListOptions, keysOf func(T) pageKeys) *model.Page[T] {
+	direction := 1
+	if opts.Descending {
+		direction = -1
+	}
+
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		keys := keysOf(item)
+		if !strings.Contains(strings.ToLower(keys.title), strings.ToLower(opts.Title)) {
+			continue
+		}
+		if opts.Cursor != nil {
+			cursorKeys := pageKeys{title: opts.Cursor.Title, createdAt: opts.Cursor.CreatedAt, id: opts.Cursor.ID}
+			if keys.compare(cursorKeys, opts.Sort)*direction <= 0 {
+				continue
+			}
+		}
+		filtered = append(filtered, item)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return keysOf(filtered[i]).compare(keysOf(filtered[j]), opts.Sort)*direction < 0
+	})
+	if len(filtered) > opts.Limit+1 {
+		filtered = filtered[:opts.Limit+1]
+	}
+
+	return model.NewPage(filtered, opts, func(item T) *model.Cursor {
+		keys := keysOf(item)
+		return opts.CursorFor(keys.title, keys.createdAt, keys.id)
+	})
+}
@@ -0,0 +1,97 @@
+package teststore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"Gotcha/internal/app/model"
+	"github.com/google/uuid"
+)
+
+const (
+	titleMatchRank   = 1
+	contentMatchRank = 0.5
+)
+
+// SearchRepository falls back to case-insensitive substring matching instead of the full-text index
+type SearchRepository struct {
+	storage *Storage
+}
+
+func (s *SearchRepository) Search(ctx context.Context, query string, limit int, user *model.User) ([]*model.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, model.ErrEmptySearchQuery
+	}
+
+	boards := s.storage.Board().(*BoardRepository)
+	notes := s.storage.Note().(*NoteRepository)
+	accessible := func(boardID uuid.UUID) bool {
+		permission, err := boards.GetPermissionOfUser(ctx, boardID, user.ID)
+		return err == nil && permission.Privilege != 0
+	}
+
+	results := make([]*model.SearchResult, 0)
+	addBoard := func(base model.BaseBoard) {
+		if snippet, found := highlight(base.Title, query); found && accessible(base.ID) {
+			results = append(results, &model.SearchResult{
+				Kind: model.SearchKindBoard, BoardID: base.ID, Title: base.Title, Snippet: snippet, Rank: titleMatchRank,
+			})
+		}
+	}
+	for _, board := range boards.Boards {
+		addBoard(board.Base)
+	}
+	for _, board := range boards.NestedBoards {
+		addBoard(board.Base)
+	}
+
+	boardOfBridge := make(map[uuid.UUID]uuid.UUID, len(boards.NestedRelations))
+	for _, relation := range boards.NestedRelations {
+		boardOfBridge[relation.RelationID] = relation.NestedBoardID
+	}
+	for _, note := range notes.Notes {
+		boardID, found := boardOfBridge[note.BoardBridgeID]
+		if !found || !accessible(boardID) {
+			continue
+		}
+		result := &model.SearchResult{Kind: model.SearchKindNote, BoardID: boardID, NoteID: note.ID, Title: note.Title}
+		if snippet, found := highlight(note.Title, query); found {
+			result.Snippet, result.Rank = snippet, titleMatchRank
+		} else if snippet, found := highlight(note.Content, query); found {
+			result.Snippet, result.Rank = snippet, contentMatchRank
+		} else {
+			continue
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		if results[i].Title != results[j].Title {
+			return results[i].Title < results[j].Title
+		}
+		return results[i].NoteID < results[j].NoteID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// highlight marks the first case-insensitive occurrence of query in text. Text is compared by runes,
+// so multibyte characters aren't split
+func highlight(text, query string) (string, bool) {
+	runes, queryLength := []rune(text), utf8.RuneCountInString(query)
+	for start, end := 0, queryLength; end <= len(runes); start, end = start+1, end+1 {
+		if strings.EqualFold(string(runes[start:end]), query) {
+			marked := string(runes[:start]) + model.SearchMatchStart + string(runes[start:end]) + model.SearchMatchStop + string(runes[end:])
+			return model.RenderSnippet(marked), true
+		}
+	}
+	return "", false
+}
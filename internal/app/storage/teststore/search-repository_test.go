@@ -0,0 +1,64 @@
+package teststore_test
+
+import (
+	"context"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchRepository_Search(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+	searchRepo := store.Search()
+
+	owner := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, owner)
+	stranger := model.TestUser(t)
+	stranger.Username += "stranger"
+	stranger.Email += "stranger"
+	_ = store.User().SaveUser(ctx, stranger)
+
+	rootBoard, _ := store.Board().NewRootBoard(ctx, owner, "Roadmap")
+	nestedBoard, _ := store.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Planning", owner)
+	note := &model.Note{Title: "Release", Content: "Ship the roadmap draft"}
+	_ = store.Note().NewNote(ctx, nestedBoard.Base.ID, note, owner)
+	_, _ = store.Board().NewRootBoard(ctx, stranger, "Secret roadmap")
+	unsafeNote := &model.Note{Title: "Straße", Content: `<img src=x onerror="alert(1)"> Über café`}
+	_ = store.Note().NewNote(ctx, nestedBoard.Base.ID, unsafeNote, owner)
+
+	_, err := searchRepo.Search(ctx, " ", model.DefaultSearchLimit, owner)
+	assert.ErrorIs(t, err, model.ErrEmptySearchQuery)
+
+	results, err := searchRepo.Search(ctx, "roadmap", model.DefaultSearchLimit, owner)
+	assert.NoError(t, err, "Failed to search")
+	if assert.Len(t, results, 2, "Boards of other users found") {
+		// Title matches rank higher than content matches
+		assert.Equal(t, model.SearchKindBoard, results[0].Kind)
+		assert.Equal(t, rootBoard.Base.ID, results[0].BoardID)
+		assert.Equal(t, model.SearchKindNote, results[1].Kind)
+		assert.Equal(t, nestedBoard.Base.ID, results[1].BoardID)
+		assert.Equal(t, note.ID, results[1].NoteID)
+		assert.Contains(t, results[1].Snippet, model.SearchHighlightStart+"roadmap"+model.SearchHighlightStop)
+	}
+
+	results, err = searchRepo.Search(ctx, "roadmap", 1, owner)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1, "Limit isn't applied")
+
+	// Snippets are escaped, highlighting doesn't split multibyte characters
+	results, err = searchRepo.Search(ctx, "café", model.DefaultSearchLimit, owner)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, unsafeNote.ID, results[0].NoteID)
+		assert.NotContains(t, results[0].Snippet, "<img", "Snippet isn't escaped")
+		assert.Contains(t, results[0].Snippet, "&lt;img")
+		assert.Contains(t, results[0].Snippet, model.SearchHighlightStart+"café"+model.SearchHighlightStop)
+	}
+
+	results, err = searchRepo.Search(ctx, "release", model.DefaultSearchLimit, stranger)
+	assert.NoError(t, err)
+	assert.Empty(t, results, "Stranger found notes of foreign board")
+}
@@ -0,0 +1,122 @@
+package teststore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"Gotcha/internal/app/authorization"
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+type ShareRepository struct {
+	storage *Storage
+	Links   map[uuid.UUID]*model.ShareLink
+}
+
+func (sr *ShareRepository) authorizer() *authorization.Authorizer {
+	return authorization.New(sr.storage.Board())
+}
+
+func (sr *ShareRepository) NewShareLink(ctx context.Context, link *model.ShareLink, user *model.User) error {
+	if err := link.Validate(); err != nil {
+		return err
+	}
+	if err := sr.authorizer().Can(ctx, user, authorization.ShareBoard, link.BoardID); err != nil {
+		return err
+	}
+	if _, err := sr.FindShareLinkByHash(ctx, link.Hash); err == nil {
+		return storage.ErrEntityDuplicate
+	}
+
+	link.ID = uuid.New()
+	link.CreatedBy = user.ID
+	link.CreatedAt = time.Now()
+
+	savedLink := *link
+	savedLink.ClearSensitive()
+	sr.Links[link.ID] = &savedLink
+	return nil
+}
+
+func (sr *ShareRepository) GetShareLinks(ctx context.Context, boardID uuid.UUID, user *model.User) ([]*model.ShareLink, error) {
+	if err := sr.authorizer().Can(ctx, user, authorization.ShareBoard, boardID); err != nil {
+		return nil, err
+	}
+
+	links := make([]*model.ShareLink, 0)
+	for _, link := range sr.Links {
+		if link.BoardID == boardID {
+			linkCopy := *link
+			links = append(links, &linkCopy)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].CreatedAt.Before(links[j].CreatedAt)
+	})
+	return links, nil
+}
+
+func (sr *ShareRepository) RevokeShareLink(ctx context.Context, boardID, linkID uuid.UUID, user *model.User) error {
+	if err := sr.authorizer().Can(ctx, user, authorization.ShareBoard, boardID); err != nil {
+		return err
+	}
+
+	link, found := sr.Links[linkID]
+	if !found || link.BoardID != boardID {
+		return storage.ErrNotFound
+	}
+	delete(sr.Links, linkID)
+	return nil
+}
+
+func (sr *ShareRepository) FindShareLinkByHash(ctx context.Context, hash string) (*model.ShareLink, error) {
+	for _, link := range sr.Links {
+		if link.Hash == hash {
+			linkCopy := *link
+			return &linkCopy, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (sr *ShareRepository) GetSharedTree(ctx context.Context, link *model.ShareLink, maxDepth int) (*model.BoardTree, error) {
+	sr.storage.Board()
+	return sr.storage.boardRepository.boardTree(link.BoardID, maxDepth)
+}
+
+func (sr *ShareRepository) GetSharedNotes(ctx context.Context, link *model.ShareLink, boardID uuid.UUID) ([]*model.Note, error) {
+	sr.storage.Board()
+	sr.storage.Note()
+
+	// Walk up from the board until the link board is met
+	inTree := boardID == link.BoardID
+	for currentID := boardID; !inTree; {
+		relation, found := sr.storage.boardRepository.NestedRelations[currentID]
+		if !found {
+			break
+		}
+		currentID = relation.BoardID
+		inTree = currentID == link.BoardID
+	}
+	if !inTree {
+		return nil, storage.ErrSecurityError
+	}
+
+	bridgeID, err := sr.storage.noteRepository.getBridge(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	return sr.storage.noteRepository.notesOfBridge(bridgeID), nil
+}
+
+// deleteLinksOfBoard is a cascade helper for boards removal
+func (sr *ShareRepository) deleteLinksOfBoard(boardID uuid.UUID) {
+	for id, link := range sr.Links {
+		if link.BoardID == boardID {
+			delete(sr.Links, id)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package teststore_test
+
+import (
+	"context"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"Gotcha/internal/app/storage/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareRepository_ShareLinks(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+	shareRepo := store.Share()
+
+	author := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, author)
+	reader := model.TestUser(t)
+	reader.Username += "reader"
+	reader.Email += "reader"
+	_ = store.User().SaveUser(ctx, reader)
+
+	rootBoard, _ := store.Board().NewRootBoard(ctx, author, "Example root board")
+	storage.TestColleagues(t, store, author, reader)
+	_, _ = store.Board().CreateRelation(ctx, rootBoard.Base.ID, reader.ID, "RO access", model.PrivilegeReadOnly)
+
+	// Only the author shares the board
+	link, _ := model.NewShareLink(rootBoard.Base.ID, reader.ID, nil)
+	assert.ErrorIs(t, shareRepo.NewShareLink(ctx, link, reader), storage.ErrSecurityError, "Reader shared the board")
+
+	link, _ = model.NewShareLink(rootBoard.Base.ID, author.ID, nil)
+	assert.NoError(t, shareRepo.NewShareLink(ctx, link, author), "Failed to save link")
+	assert.NotEmpty(t, link.Token, "Token must be shown after creation")
+
+	found, err := shareRepo.FindShareLinkByHash(ctx, model.HashToken(link.Token))
+	assert.NoError(t, err, "Failed to find link by hash")
+	assert.Equal(t, found.ID, link.ID)
+	assert.Empty(t, found.Token, "Raw token is saved")
+
+	links, err := shareRepo.GetShareLinks(ctx, rootBoard.Base.ID, author)
+	assert.NoError(t, err, "Failed to get links")
+	assert.Len(t, links, 1)
+
+	assert.ErrorIs(t, shareRepo.RevokeShareLink(ctx, rootBoard.Base.ID, link.ID, reader), storage.ErrSecurityError)
+	assert.NoError(t, shareRepo.RevokeShareLink(ctx, rootBoard.Base.ID, link.ID, author), "Failed to revoke link")
+	_, err = shareRepo.FindShareLinkByHash(ctx, model.HashToken(link.Token))
+	assert.ErrorIs(t, err, storage.ErrNotFound, "Revoked link is found")
+}
+
+func TestShareRepository_SharedReads(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+	shareRepo := store.Share()
+
+	author := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, author)
+
+	// root -> shared -> child, root -> sibling
+	rootBoard, _ := store.Board().NewRootBoard(ctx, author, "Root")
+	sharedBoard, _ := store.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Shared", author)
+	childBoard, _ := store.Board().NewNestedBoard(ctx, sharedBoard.Base.ID, "Child", author)
+	siblingBoard, _ := store.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Sibling", author)
+	_ = store.Note().NewNote(ctx, childBoard.Base.ID, &model.Note{Title: "Child note"}, author)
+	_ = store.Note().NewNote(ctx, siblingBoard.Base.ID, &model.Note{Title: "Sibling note"}, author)
+
+	link, _ := model.NewShareLink(sharedBoard.Base.ID, author.ID, nil)
+	_ = shareRepo.NewShareLink(ctx, link, author)
+
+	tree, err := shareRepo.GetSharedTree(ctx, link, 8)
+	assert.NoError(t, err, "Failed to get shared tree")
+	assert.Equal(t, tree.Base.ID, sharedBoard.Base.ID, "Tree isn't scoped to the shared board")
+	assert.Len(t, tree.Children, 1)
+
+	notes, err := shareRepo.GetSharedNotes(ctx, link, childBoard.Base.ID)
+	assert.NoError(t, err, "Failed to get shared notes")
+	assert.Len(t, notes, 1)
+
+	_, err = shareRepo.GetSharedNotes(ctx, link, siblingBoard.Base.ID)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Notes outside the shared tree are visible")
+	_, err = shareRepo.GetSharedNotes(ctx, link, rootBoard.Base.ID)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Notes of the parent board are visible")
+}
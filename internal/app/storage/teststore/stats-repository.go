@@ -0,0 +1,20 @@
+package teststore
+
+import (
+	"context"
+
+	"Gotcha/internal/app/model"
+)
+
+type StatsRepository struct {
+	storage *Storage
+}
+
+func (s *StatsRepository) GetTotals(ctx context.Context) (*model.Totals, error) {
+	boards := s.storage.Board().(*BoardRepository)
+	return &model.Totals{
+		Users:  len(s.storage.User().(*UserRepository).users),
+		Boards: len(boards.Boards) + len(boards.NestedBoards),
+		Notes:  len(s.storage.Note().(*NoteRepository).Notes),
+	}, nil
+}
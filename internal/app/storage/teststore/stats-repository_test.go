@@ -0,0 +1,29 @@
+package teststore_test
+
+import (
+	"context"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsRepository_GetTotals(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+
+	totals, err := store.Stats().GetTotals(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Totals{}, totals)
+
+	user := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, user)
+	rootBoard, _ := store.Board().NewRootBoard(ctx, user, "Root")
+	nestedBoard, _ := store.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", user)
+	_ = store.Note().NewNote(ctx, nestedBoard.Base.ID, &model.Note{Title: "Note"}, user)
+
+	totals, err = store.Stats().GetTotals(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Totals{Users: 1, Boards: 2, Notes: 1}, totals)
+}
@@ -1,6 +1,8 @@
 package teststore
 
 import (
+	"context"
+
 	"Gotcha/internal/app/model"
 	"Gotcha/internal/app/storage"
 	"github.com/google/uuid"
@@ -8,8 +10,15 @@ import (
 
 type Storage struct {
 	// Repositories
-	userRepository  *UserRepository
-	boardRepository *BoardRepository
+	userRepository      *UserRepository
+	boardRepository     *BoardRepository
+	noteRepository      *NoteRepository
+	tokenRepository     *TokenRepository
+	shareRepository     *ShareRepository
+	teamRepository      *TeamRepository
+	workspaceRepository *WorkspaceRepository
+	searchRepository    *SearchRepository
+	statsRepository     *StatsRepository
 }
 
 // New ...
@@ -40,6 +49,203 @@ func (storage *Storage) Board() storage.BoardRepository {
 	return storage.boardRepository
 }
 
+func (storage *Storage) Note() storage.NoteRepository {
+	if storage.noteRepository == nil {
+		storage.noteRepository = &NoteRepository{
+			storage: storage,
+			Notes:   make(map[int]*model.Note),
+		}
+	}
+	return storage.noteRepository
+}
+
+func (storage *Storage) Token() storage.TokenRepository {
+	if storage.tokenRepository == nil {
+		storage.tokenRepository = &TokenRepository{
+			storage: storage,
+			Tokens:  make(map[uuid.UUID]*model.APIToken),
+		}
+	}
+	return storage.tokenRepository
+}
+
+func (storage *Storage) Share() storage.ShareRepository {
+	if storage.shareRepository == nil {
+		storage.shareRepository = &ShareRepository{
+			storage: storage,
+			Links:   make(map[uuid.UUID]*model.ShareLink),
+		}
+	}
+	return storage.shareRepository
+}
+
+func (storage *Storage) Team() storage.TeamRepository {
+	if storage.teamRepository == nil {
+		storage.teamRepository = &TeamRepository{
+			storage:   storage,
+			Teams:     make(map[uuid.UUID]*model.Team),
+			Members:   make([]*TeamMembership, 0),
+			Relations: make([]*TeamRelation, 0),
+		}
+	}
+	return storage.teamRepository
+}
+
+func (storage *Storage) Workspace() storage.WorkspaceRepository {
+	if storage.workspaceRepository == nil {
+		storage.workspaceRepository = &WorkspaceRepository{
+			storage:    storage,
+			Workspaces: make(map[uuid.UUID]*model.Workspace),
+			Members:    make([]*WorkspaceMembership, 0),
+		}
+	}
+	return storage.workspaceRepository
+}
+
+func (storage *Storage) Search() storage.SearchRepository {
+	if storage.searchRepository == nil {
+		storage.searchRepository = &SearchRepository{storage: storage}
+	}
+	return storage.searchRepository
+}
+
+func (storage *Storage) Stats() storage.StatsRepository {
+	if storage.statsRepository == nil {
+		storage.statsRepository = &StatsRepository{storage: storage}
+	}
+	return storage.statsRepository
+}
+
+// WithTx emulates the transaction: state of repositories is restored if fn fails
+func (storage *Storage) WithTx(ctx context.Context, fn func(storage.Storage) error) error {
+	state := storage.snapshot()
+	if err := fn(storage); err != nil {
+		storage.restore(state)
+		return err
+	}
+	return nil
+}
+
+// snapshot is a deep enough copy of repositories to roll back any change of WithTx
+type snapshot struct {
+	users            map[uuid.UUID]*model.User
+	relations        []*Relation
+	nestedRelations  map[uuid.UUID]*NestedRelation
+	boards           map[uuid.UUID]*model.Board
+	nestedBoards     map[uuid.UUID]*model.NestedBoard
+	transfers        []*model.BoardTransfer
+	notes            map[int]*model.Note
+	lastNoteID       int
+	tokens           map[uuid.UUID]*model.APIToken
+	shareLinks       map[uuid.UUID]*model.ShareLink
+	teams            map[uuid.UUID]*model.Team
+	teamMembers      []*TeamMembership
+	teamRelations    []*TeamRelation
+	workspaces       map[uuid.UUID]*model.Workspace
+	workspaceMembers []*WorkspaceMembership
+}
+
+func (storage *Storage) snapshot() *snapshot {
+	storage.User()
+	storage.Board()
+	storage.Note()
+	storage.Token()
+	storage.Share()
+	storage.Team()
+	storage.Workspace()
+
+	state := snapshot{
+		users:            make(map[uuid.UUID]*model.User),
+		relations:        make([]*Relation, 0, len(storage.boardRepository.Relations)),
+		nestedRelations:  make(map[uuid.UUID]*NestedRelation),
+		boards:           make(map[uuid.UUID]*model.Board),
+		nestedBoards:     make(map[uuid.UUID]*model.NestedBoard),
+		transfers:        append([]*model.BoardTransfer{}, storage.boardRepository.Transfers...),
+		notes:            make(map[int]*model.Note),
+		lastNoteID:       storage.noteRepository.lastID,
+		tokens:           make(map[uuid.UUID]*model.APIToken),
+		shareLinks:       make(map[uuid.UUID]*model.ShareLink),
+		teams:            make(map[uuid.UUID]*model.Team),
+		teamMembers:      make([]*TeamMembership, 0, len(storage.teamRepository.Members)),
+		teamRelations:    make([]*TeamRelation, 0, len(storage.teamRepository.Relations)),
+		workspaces:       make(map[uuid.UUID]*model.Workspace),
+		workspaceMembers: make([]*WorkspaceMembership, 0, len(storage.workspaceRepository.Members)),
+	}
+
+	for id, user := range storage.userRepository.users {
+		userCopy := *user
+		state.users[id] = &userCopy
+	}
+	for _, rel := range storage.boardRepository.Relations {
+		relCopy := *rel
+		state.relations = append(state.relations, &relCopy)
+	}
+	for id, rel := range storage.boardRepository.NestedRelations {
+		relCopy := *rel
+		state.nestedRelations[id] = &relCopy
+	}
+	for id, board := range storage.boardRepository.Boards {
+		boardCopy := *board
+		boardCopy.U2BRelations = append([]uuid.UUID{}, board.U2BRelations...)
+		state.boards[id] = &boardCopy
+	}
+	for id, board := range storage.boardRepository.NestedBoards {
+		boardCopy := *board
+		state.nestedBoards[id] = &boardCopy
+	}
+	for id, note := range storage.noteRepository.Notes {
+		noteCopy := *note
+		state.notes[id] = &noteCopy
+	}
+	for id, token := range storage.tokenRepository.Tokens {
+		tokenCopy := *token
+		state.tokens[id] = &tokenCopy
+	}
+	for id, link := range storage.shareRepository.Links {
+		linkCopy := *link
+		state.shareLinks[id] = &linkCopy
+	}
+	for id, team := range storage.teamRepository.Teams {
+		teamCopy := *team
+		state.teams[id] = &teamCopy
+	}
+	for _, membership := range storage.teamRepository.Members {
+		membershipCopy := *membership
+		state.teamMembers = append(state.teamMembers, &membershipCopy)
+	}
+	for _, rel := range storage.teamRepository.Relations {
+		relCopy := *rel
+		state.teamRelations = append(state.teamRelations, &relCopy)
+	}
+	for id, workspace := range storage.workspaceRepository.Workspaces {
+		workspaceCopy := *workspace
+		state.workspaces[id] = &workspaceCopy
+	}
+	for _, membership := range storage.workspaceRepository.Members {
+		membershipCopy := *membership
+		state.workspaceMembers = append(state.workspaceMembers, &membershipCopy)
+	}
+	return &state
+}
+
+func (storage *Storage) restore(state *snapshot) {
+	storage.userRepository.users = state.users
+	storage.boardRepository.Relations = state.relations
+	storage.boardRepository.NestedRelations = state.nestedRelations
+	storage.boardRepository.Boards = state.boards
+	storage.boardRepository.NestedBoards = state.nestedBoards
+	storage.boardRepository.Transfers = state.transfers
+	storage.noteRepository.Notes = state.notes
+	storage.noteRepository.lastID = state.lastNoteID
+	storage.tokenRepository.Tokens = state.tokens
+	storage.shareRepository.Links = state.shareLinks
+	storage.teamRepository.Teams = state.teams
+	storage.teamRepository.Members = state.teamMembers
+	storage.teamRepository.Relations = state.teamRelations
+	storage.workspaceRepository.Workspaces = state.workspaces
+	storage.workspaceRepository.Members = state.workspaceMembers
+}
+
 func (storage *Storage) Close() {
 	// ... implementation requirement
 }
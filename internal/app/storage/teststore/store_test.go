@@ -0,0 +1,47 @@
+package teststore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"Gotcha/internal/app/storage/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_WithTx(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+	errAbort := errors.New("abort")
+
+	user := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, user)
+
+	// Committed transaction
+	var rootBoard *model.Board
+	err := store.WithTx(ctx, func(tx storage.Storage) error {
+		var err error
+		rootBoard, err = tx.Board().NewRootBoard(ctx, user, "Root")
+		return err
+	})
+	assert.NoError(t, err, "Failed to commit transaction")
+
+	// Rolled back transaction
+	err = store.WithTx(ctx, func(tx storage.Storage) error {
+		if _, err := tx.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", user); err != nil {
+			return err
+		}
+		if _, err := tx.Board().NewRootBoard(ctx, user, "Another root"); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	boards, _ := store.Board().GetRootBoardsOfUser(ctx, user, model.DefaultListOptions())
+	assert.Len(t, boards.Items, 1, "Root board of rolled back transaction exists")
+	nestedBoards, _ := store.Board().GetNestedBoards(ctx, rootBoard.Base.ID, user)
+	assert.Len(t, nestedBoards, 0, "Nested board of rolled back transaction exists")
+}
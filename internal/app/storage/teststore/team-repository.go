@@ -0,0 +1,236 @@
+package teststore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"Gotcha/internal/app/authorization"
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+type TeamMembership struct {
+	TeamID   uuid.UUID
+	UserID   uuid.UUID
+	JoinedAt time.Time
+}
+
+type TeamRelation struct {
+	ID            uuid.UUID
+	TeamID        uuid.UUID
+	BoardID       uuid.UUID
+	Description   string
+	CreatedAt     time.Time
+	privilegeType model.PrivilegeType
+}
+
+type TeamRepository struct {
+	storage   *Storage
+	Teams     map[uuid.UUID]*model.Team
+	Members   []*TeamMembership
+	Relations []*TeamRelation
+}
+
+func (tr *TeamRepository) NewTeam(ctx context.Context, team *model.Team, user *model.User) error {
+	if err := team.Validate(); err != nil {
+		return err
+	}
+
+	team.ID = uuid.New()
+	team.OwnerID = user.ID
+	team.CreatedAt = time.Now()
+
+	savedTeam := *team
+	tr.Teams[team.ID] = &savedTeam
+	tr.Members = append(tr.Members, &TeamMembership{TeamID: team.ID, UserID: user.ID, JoinedAt: team.CreatedAt})
+	return nil
+}
+
+func (tr *TeamRepository) GetTeamsOfUser(ctx context.Context, user *model.User) ([]*model.Team, error) {
+	teams := make([]*model.Team, 0)
+	for _, membership := range tr.Members {
+		if membership.UserID == user.ID {
+			teamCopy := *tr.Teams[membership.TeamID]
+			teams = append(teams, &teamCopy)
+		}
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].CreatedAt.Before(teams[j].CreatedAt)
+	})
+	return teams, nil
+}
+
+func (tr *TeamRepository) GetTeamMembers(ctx context.Context, teamID uuid.UUID, user *model.User) ([]*model.TeamMember, error) {
+	if _, found := tr.Teams[teamID]; !found {
+		return nil, storage.ErrNotFound
+	}
+	if tr.findMembership(teamID, user.ID) == nil {
+		return nil, storage.ErrSecurityError
+	}
+
+	members := make([]*model.TeamMember, 0)
+	for _, membership := range tr.Members {
+		if membership.TeamID != teamID {
+			continue
+		}
+		member, err := tr.storage.User().FindUserByID(ctx, membership.UserID)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, &model.TeamMember{
+			UserID:   member.ID,
+			Username: member.Username,
+			JoinedAt: membership.JoinedAt,
+		})
+	}
+	return members, nil
+}
+
+func (tr *TeamRepository) AddTeamMember(ctx context.Context, teamID, memberID uuid.UUID, user *model.User) error {
+	team, found := tr.Teams[teamID]
+	if !found {
+		return storage.ErrNotFound
+	}
+	if team.OwnerID != user.ID {
+		return storage.ErrSecurityError
+	}
+	if _, err := tr.storage.User().FindUserByID(ctx, memberID); err != nil {
+		return storage.ErrNotFound
+	}
+	if tr.findMembership(teamID, memberID) != nil {
+		return storage.ErrEntityDuplicate
+	}
+	// Member gets access to the boards of the team, so they must be shareable with him
+	boards := tr.storage.Board().(*BoardRepository)
+	for _, rel := range tr.Relations {
+		if rel.TeamID == teamID && !boards.canShareWith(rel.BoardID, memberID) {
+			return storage.ErrSecurityError
+		}
+	}
+
+	tr.Members = append(tr.Members, &TeamMembership{TeamID: teamID, UserID: memberID, JoinedAt: time.Now()})
+	return nil
+}
+
+func (tr *TeamRepository) RemoveTeamMember(ctx context.Context, teamID, memberID uuid.UUID, user *model.User) error {
+	team, found := tr.Teams[teamID]
+	if !found {
+		return storage.ErrNotFound
+	}
+	if memberID == team.OwnerID || (team.OwnerID != user.ID && memberID != user.ID) {
+		return storage.ErrSecurityError
+	}
+
+	removed := tr.findMembership(teamID, memberID)
+	if removed == nil {
+		return storage.ErrNotFound
+	}
+	members := make([]*TeamMembership, 0, len(tr.Members))
+	for _, membership := range tr.Members {
+		if membership != removed {
+			members = append(members, membership)
+		}
+	}
+	tr.Members = members
+	return nil
+}
+
+func (tr *TeamRepository) GrantTeam(ctx context.Context, boardID, teamID uuid.UUID, desc string, privilegeType model.PrivilegeType, user *model.User) (uuid.UUID, error) {
+	if privilegeType != model.PrivilegeReadOnly && privilegeType != model.PrivilegeReadWrite {
+		return uuid.Nil, storage.ErrSecurityError
+	}
+	tr.storage.Board()
+	if err := tr.storage.boardRepository.permitRootBoardAction(ctx, boardID, authorization.ShareBoard, user); err != nil {
+		return uuid.Nil, err
+	}
+	if _, found := tr.Teams[teamID]; !found {
+		return uuid.Nil, storage.ErrNotFound
+	}
+	if tr.findRelation(boardID, teamID) != nil {
+		return uuid.Nil, storage.ErrEntityDuplicate
+	}
+	// Like collaborators, every member must be allowed to access the board
+	for _, membership := range tr.Members {
+		if membership.TeamID == teamID && !tr.storage.boardRepository.canShareWith(boardID, membership.UserID) {
+			return uuid.Nil, storage.ErrSecurityError
+		}
+	}
+
+	rel := TeamRelation{
+		ID:            uuid.New(),
+		TeamID:        teamID,
+		BoardID:       boardID,
+		Description:   desc,
+		CreatedAt:     time.Now(),
+		privilegeType: privilegeType,
+	}
+	tr.Relations = append(tr.Relations, &rel)
+	return rel.ID, nil
+}
+
+func (tr *TeamRepository) RevokeTeam(ctx context.Context, boardID, teamID uuid.UUID, user *model.User) error {
+	tr.storage.Board()
+	if err := tr.storage.boardRepository.permitRootBoardAction(ctx, boardID, authorization.ManageCollaborators, user); err != nil {
+		return err
+	}
+
+	revoked := tr.findRelation(boardID, teamID)
+	if revoked == nil {
+		return storage.ErrNotFound
+	}
+	tr.deleteRelations(func(rel *TeamRelation) bool { return rel == revoked })
+	return nil
+}
+
+// privilegesOfUser returns privileges granted to the teams of user on the board
+func (tr *TeamRepository) privilegesOfUser(boardID, userID uuid.UUID) []model.PrivilegeType {
+	privileges := make([]model.PrivilegeType, 0)
+	for _, rel := range tr.Relations {
+		if rel.BoardID == boardID && tr.findMembership(rel.TeamID, userID) != nil {
+			privileges = append(privileges, rel.privilegeType)
+		}
+	}
+	return privileges
+}
+
+// boardsOfUser returns ids of boards reachable via the teams of user
+func (tr *TeamRepository) boardsOfUser(userID uuid.UUID) []uuid.UUID {
+	boards := make([]uuid.UUID, 0)
+	for _, rel := range tr.Relations {
+		if tr.findMembership(rel.TeamID, userID) != nil {
+			boards = append(boards, rel.BoardID)
+		}
+	}
+	return boards
+}
+
+// deleteRelations removes team relations matching the predicate, it's a cascade helper for boards removal
+func (tr *TeamRepository) deleteRelations(match func(*TeamRelation) bool) {
+	relations := make([]*TeamRelation, 0, len(tr.Relations))
+	for _, rel := range tr.Relations {
+		if !match(rel) {
+			relations = append(relations, rel)
+		}
+	}
+	tr.Relations = relations
+}
+
+func (tr *TeamRepository) findMembership(teamID, userID uuid.UUID) *TeamMembership {
+	for _, membership := range tr.Members {
+		if membership.TeamID == teamID && membership.UserID == userID {
+			return membership
+		}
+	}
+	return nil
+}
+
+func (tr *TeamRepository) findRelation(boardID, teamID uuid.UUID) *TeamRelation {
+	for _, rel := range tr.Relations {
+		if rel.BoardID == boardID && rel.TeamID == teamID {
+			return rel
+		}
+	}
+	return nil
+}
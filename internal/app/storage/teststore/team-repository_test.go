@@ -0,0 +1,111 @@
+package teststore_test
+
+import (
+	"context"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"Gotcha/internal/app/storage/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamRepository_Members(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+	teamRepo := store.Team()
+
+	owner := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, owner)
+	member := model.TestUser(t)
+	member.Username += "member"
+	member.Email += "member"
+	_ = store.User().SaveUser(ctx, member)
+
+	team := model.Team{Title: "Backend"}
+	assert.NoError(t, teamRepo.NewTeam(ctx, &team, owner), "Failed to create team")
+	assert.Equal(t, team.OwnerID, owner.ID)
+
+	// Only the owner manages members
+	assert.ErrorIs(t, teamRepo.AddTeamMember(ctx, team.ID, member.ID, member), storage.ErrSecurityError)
+	assert.NoError(t, teamRepo.AddTeamMember(ctx, team.ID, member.ID, owner), "Failed to add member")
+	assert.ErrorIs(t, teamRepo.AddTeamMember(ctx, team.ID, member.ID, owner), storage.ErrEntityDuplicate)
+
+	members, err := teamRepo.GetTeamMembers(ctx, team.ID, member)
+	assert.NoError(t, err, "Member can't list members")
+	assert.Len(t, members, 2)
+
+	teams, err := teamRepo.GetTeamsOfUser(ctx, member)
+	assert.NoError(t, err)
+	assert.Len(t, teams, 1)
+
+	// Owner can't leave, members can
+	assert.ErrorIs(t, teamRepo.RemoveTeamMember(ctx, team.ID, owner.ID, owner), storage.ErrSecurityError)
+	assert.NoError(t, teamRepo.RemoveTeamMember(ctx, team.ID, member.ID, member), "Member can't leave the team")
+
+	_, err = teamRepo.GetTeamMembers(ctx, team.ID, member)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Former member lists members")
+}
+
+func TestTeamRepository_BoardAccess(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+	teamRepo := store.Team()
+
+	owner := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, owner)
+	member := model.TestUser(t)
+	member.Username += "member"
+	member.Email += "member"
+	_ = store.User().SaveUser(ctx, member)
+	stranger := model.TestUser(t)
+	stranger.Username += "stranger"
+	stranger.Email += "stranger"
+	_ = store.User().SaveUser(ctx, stranger)
+	storage.TestColleagues(t, store, owner, member)
+
+	team := model.Team{Title: "Backend"}
+	_ = teamRepo.NewTeam(ctx, &team, owner)
+	_ = teamRepo.AddTeamMember(ctx, team.ID, member.ID, owner)
+
+	rootBoard, _ := store.Board().NewRootBoard(ctx, owner, "Root")
+	nestedBoard, _ := store.Board().NewNestedBoard(ctx, rootBoard.Base.ID, "Nested", owner)
+
+	_, err := teamRepo.GrantTeam(ctx, rootBoard.Base.ID, team.ID, "Team", model.PrivilegeAuthor, owner)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Team granted authorship")
+	_, err = teamRepo.GrantTeam(ctx, nestedBoard.Base.ID, team.ID, "Team", model.PrivilegeReadOnly, owner)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Team granted nested board")
+
+	_, err = teamRepo.GrantTeam(ctx, rootBoard.Base.ID, team.ID, "Team", model.PrivilegeReadWrite, owner)
+	assert.NoError(t, err, "Failed to grant team")
+	_, err = teamRepo.GrantTeam(ctx, rootBoard.Base.ID, team.ID, "Team", model.PrivilegeReadOnly, owner)
+	assert.ErrorIs(t, err, storage.ErrEntityDuplicate)
+
+	// Teams don't share boards outside of the author workspaces
+	assert.ErrorIs(t, teamRepo.AddTeamMember(ctx, team.ID, stranger.ID, owner), storage.ErrSecurityError, "Stranger joined team with personal board")
+	strangerTeam := model.Team{Title: "Strangers"}
+	_ = teamRepo.NewTeam(ctx, &strangerTeam, owner)
+	_ = teamRepo.AddTeamMember(ctx, strangerTeam.ID, stranger.ID, owner)
+	_, err = teamRepo.GrantTeam(ctx, rootBoard.Base.ID, strangerTeam.ID, "Strangers", model.PrivilegeReadWrite, owner)
+	assert.ErrorIs(t, err, storage.ErrSecurityError, "Personal board granted to team of strangers")
+	bp, _ := store.Board().GetPermissionOfUser(ctx, rootBoard.Base.ID, stranger.ID)
+	assert.Equal(t, model.PrivilegeType(0), bp.Privilege, "Stranger has access through team")
+
+	// Member inherits team privilege on the whole tree
+	bp, err = store.Board().GetPermissionOfUser(ctx, nestedBoard.Base.ID, member.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PrivilegeReadWrite, bp.Privilege)
+
+	boards, err := store.Board().GetRootBoardsOfUser(ctx, member, model.DefaultListOptions())
+	assert.NoError(t, err)
+	assert.Len(t, boards.Items, 1, "Team board isn't listed")
+
+	// Membership ends, access ends
+	_ = teamRepo.RemoveTeamMember(ctx, team.ID, member.ID, owner)
+	bp, _ = store.Board().GetPermissionOfUser(ctx, rootBoard.Base.ID, member.ID)
+	assert.Equal(t, model.PrivilegeType(0), bp.Privilege, "Former member has access")
+
+	assert.ErrorIs(t, teamRepo.RevokeTeam(ctx, rootBoard.Base.ID, team.ID, member), storage.ErrSecurityError)
+	assert.NoError(t, teamRepo.RevokeTeam(ctx, rootBoard.Base.ID, team.ID, owner), "Failed to revoke team")
+	assert.ErrorIs(t, teamRepo.RevokeTeam(ctx, rootBoard.Base.ID, team.ID, owner), storage.ErrNotFound)
+}
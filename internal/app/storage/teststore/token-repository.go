@@ -0,0 +1,66 @@
+package teststore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+type TokenRepository struct {
+	storage *Storage
+	Tokens  map[uuid.UUID]*model.APIToken
+}
+
+func (tr *TokenRepository) NewToken(ctx context.Context, token *model.APIToken) error {
+	if err := token.Validate(); err != nil {
+		return err
+	}
+	if _, err := tr.FindTokenByHash(ctx, token.Hash); err == nil {
+		return storage.ErrEntityDuplicate
+	}
+
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+
+	savedToken := *token
+	savedToken.ClearSensitive()
+	tr.Tokens[token.ID] = &savedToken
+	return nil
+}
+
+func (tr *TokenRepository) GetTokensOfUser(ctx context.Context, user *model.User) ([]*model.APIToken, error) {
+	tokens := make([]*model.APIToken, 0)
+	for _, token := range tr.Tokens {
+		if token.UserID == user.ID {
+			tokenCopy := *token
+			tokens = append(tokens, &tokenCopy)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+	})
+	return tokens, nil
+}
+
+func (tr *TokenRepository) FindTokenByHash(ctx context.Context, hash string) (*model.APIToken, error) {
+	for _, token := range tr.Tokens {
+		if token.Hash == hash {
+			tokenCopy := *token
+			return &tokenCopy, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (tr *TokenRepository) RevokeToken(ctx context.Context, tokenID uuid.UUID, user *model.User) error {
+	token, found := tr.Tokens[tokenID]
+	if !found || token.UserID != user.ID {
+		return storage.ErrNotFound
+	}
+	delete(tr.Tokens, tokenID)
+	return nil
+}
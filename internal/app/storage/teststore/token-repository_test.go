@@ -0,0 +1,64 @@
+package teststore_test
+
+import (
+	"context"
+	"testing"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"Gotcha/internal/app/storage/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRepository_NewToken(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+	tokenRepo := store.Token()
+
+	user := model.TestUser(t)
+	_ = store.User().SaveUser(ctx, user)
+
+	token, _ := model.NewAPIToken(user.ID, "cli", model.TokenScopeReadWrite, nil)
+	assert.NoError(t, tokenRepo.NewToken(ctx, token), "Failed to save token")
+	assert.NotEmpty(t, token.Token, "Token must be shown after creation")
+
+	found, err := tokenRepo.FindTokenByHash(ctx, model.HashToken(token.Token))
+	assert.NoError(t, err, "Failed to find token by hash")
+	assert.Equal(t, found.ID, token.ID)
+	assert.Empty(t, found.Token, "Raw token is saved")
+
+	_, err = tokenRepo.FindTokenByHash(ctx, model.HashToken("unknown"))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	malformed, _ := model.NewAPIToken(user.ID, "", model.TokenScopeReadWrite, nil)
+	assert.Error(t, tokenRepo.NewToken(ctx, malformed), "Malformed token saved")
+}
+
+func TestTokenRepository_RevokeToken(t *testing.T) {
+	ctx := context.Background()
+	store := teststore.New()
+	tokenRepo := store.Token()
+
+	user := model.TestUser(t)
+	anotherUser := model.TestUser(t)
+	anotherUser.Username += "a"
+	anotherUser.Email += "a"
+	_ = store.User().SaveUser(ctx, user)
+	_ = store.User().SaveUser(ctx, anotherUser)
+
+	first, _ := model.NewAPIToken(user.ID, "first", model.TokenScopeReadWrite, nil)
+	second, _ := model.NewAPIToken(user.ID, "second", model.TokenScopeReadOnly, nil)
+	_ = tokenRepo.NewToken(ctx, first)
+	_ = tokenRepo.NewToken(ctx, second)
+
+	tokens, err := tokenRepo.GetTokensOfUser(ctx, user)
+	assert.NoError(t, err, "Failed to list tokens")
+	assert.Len(t, tokens, 2)
+
+	assert.ErrorIs(t, tokenRepo.RevokeToken(ctx, first.ID, anotherUser), storage.ErrNotFound, "Revoked token of another user")
+	assert.NoError(t, tokenRepo.RevokeToken(ctx, first.ID, user), "Failed to revoke token")
+
+	tokens, _ = tokenRepo.GetTokensOfUser(ctx, user)
+	assert.Len(t, tokens, 1, "Token not revoked")
+	assert.Equal(t, tokens[0].ID, second.ID)
+}
@@ -1,6 +1,7 @@
 package teststore
 
 import (
+	"context"
 	"time"
 
 	"Gotcha/internal/app/model"
@@ -14,7 +15,7 @@ type UserRepository struct {
 }
 
 // FindUserBySobriquet is very slow, but still usable for tests
-func (u *UserRepository) FindUserBySobriquet(sobriquet string) (*model.User, error) {
+func (u *UserRepository) FindUserBySobriquet(ctx context.Context, sobriquet string) (*model.User, error) {
 
 	for _, user := range u.users {
 		if sobriquet == user.Email || sobriquet == user.Username {
@@ -24,7 +25,7 @@ func (u *UserRepository) FindUserBySobriquet(sobriquet string) (*model.User, err
 	return nil, storage.ErrNotFound
 }
 
-func (u *UserRepository) FindUserByID(userID uuid.UUID) (*model.User, error) {
+func (u *UserRepository) FindUserByID(ctx context.Context, userID uuid.UUID) (*model.User, error) {
 	user, found := u.users[userID]
 
 	if !found {
@@ -33,7 +34,7 @@ func (u *UserRepository) FindUserByID(userID uuid.UUID) (*model.User, error) {
 	return user, nil
 }
 
-func (u *UserRepository) SaveUser(user *model.User) error {
+func (u *UserRepository) SaveUser(ctx context.Context, user *model.User) error {
 	// Some sanity checks before saving
 	if err := user.Validate(); err != nil {
 		return err
@@ -41,10 +42,10 @@ func (u *UserRepository) SaveUser(user *model.User) error {
 	if err := user.BeforeCreate(); err != nil {
 		return err
 	}
-	if _, err := u.FindUserBySobriquet(user.Username); err == nil {
+	if _, err := u.FindUserBySobriquet(ctx, user.Username); err == nil {
 		return storage.ErrEntityDuplicate
 	}
-	if _, err := u.FindUserBySobriquet(user.Email); err == nil {
+	if _, err := u.FindUserBySobriquet(ctx, user.Email); err == nil {
 		return storage.ErrEntityDuplicate
 	}
 
@@ -54,12 +55,38 @@ func (u *UserRepository) SaveUser(user *model.User) error {
 	return nil
 }
 
-func (u *UserRepository) GetAllUsers(user *model.User) ([]*model.User, error) {
+func (u *UserRepository) GetColleagues(ctx context.Context, user *model.User, opts *model.ListOptions) (*model.Page[*model.User], error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	workspaces := u.storage.Workspace().(*WorkspaceRepository)
+
 	users := make([]*model.User, 0)
-	for _, u := range u.users {
-		if u.ID != user.ID {
-			users = append(users, u)
+	for _, colleague := range u.users {
+		if colleague.ID == user.ID {
+			continue
+		}
+		for _, membership := range workspaces.Members {
+			if membership.UserID == colleague.ID && workspaces.findMembership(membership.WorkspaceID, user.ID) != nil {
+				users = append(users, colleague)
+				break
+			}
 		}
 	}
-	return users, nil
+
+	return paginate(users, opts, func(user *model.User) pageKeys {
+		return pageKeys{title: user.Username, createdAt: user.CreatedAt, id: user.ID}
+	}), nil
+}
+
+func (u *UserRepository) RevokeSessions(ctx context.Context, user *model.User) error {
+	savedUser, found := u.users[user.ID]
+	if !found {
+		return storage.ErrNotFound
+	}
+
+	savedUser.SessionsRevokedAt = time.Now()
+	user.SessionsRevokedAt = savedUser.SessionsRevokedAt
+	return nil
 }
@@ -0,0 +1,203 @@
+package teststore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"Gotcha/internal/app/model"
+	"Gotcha/internal/app/storage"
+	"github.com/google/uuid"
+)
+
+type WorkspaceMembership struct {
+	WorkspaceID uuid.UUID
+	UserID      uuid.UUID
+	Role        model.WorkspaceRole
+	JoinedAt    time.Time
+}
+
+type WorkspaceRepository struct {
+	storage    *Storage
+	Workspaces map[uuid.UUID]*model.Workspace
+	Members    []*WorkspaceMembership
+}
+
+func (wr *WorkspaceRepository) NewWorkspace(ctx context.Context, workspace *model.Workspace, user *model.User) error {
+	if err := workspace.Validate(); err != nil {
+		return err
+	}
+
+	workspace.ID = uuid.New()
+	workspace.CreatedAt = time.Now()
+
+	savedWorkspace := *workspace
+	wr.Workspaces[workspace.ID] = &savedWorkspace
+	wr.Members = append(wr.Members, &WorkspaceMembership{
+		WorkspaceID: workspace.ID,
+		UserID:      user.ID,
+		Role:        model.WorkspaceRoleAdmin,
+		JoinedAt:    workspace.CreatedAt,
+	})
+	return nil
+}
+
+func (wr *WorkspaceRepository) GetWorkspacesOfUser(ctx context.Context, user *model.User) ([]*model.Workspace, error) {
+	workspaces := make([]*model.Workspace, 0)
+	for _, membership := range wr.Members {
+		if membership.UserID == user.ID {
+			workspaceCopy := *wr.Workspaces[membership.WorkspaceID]
+			workspaces = append(workspaces, &workspaceCopy)
+		}
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].CreatedAt.Before(workspaces[j].CreatedAt)
+	})
+	return workspaces, nil
+}
+
+func (wr *WorkspaceRepository) GetWorkspaceMembers(ctx context.Context, workspaceID uuid.UUID, user *model.User) ([]*model.WorkspaceMember, error) {
+	if _, err := wr.roleOfUser(workspaceID, user.ID); err != nil {
+		return nil, err
+	}
+
+	members := make([]*model.WorkspaceMember, 0)
+	for _, membership := range wr.Members {
+		if membership.WorkspaceID != workspaceID {
+			continue
+		}
+		member, err := wr.storage.User().FindUserByID(ctx, membership.UserID)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, &model.WorkspaceMember{
+			UserID:   member.ID,
+			Username: member.Username,
+			Role:     membership.Role,
+			JoinedAt: membership.JoinedAt,
+		})
+	}
+	return members, nil
+}
+
+func (wr *WorkspaceRepository) AddWorkspaceMember(ctx context.Context, workspaceID, memberID uuid.UUID, role model.WorkspaceRole, user *model.User) error {
+	if err := wr.permitAdmin(workspaceID, user); err != nil {
+		return err
+	}
+	if _, err := wr.storage.User().FindUserByID(ctx, memberID); err != nil {
+		return storage.ErrNotFound
+	}
+	if wr.findMembership(workspaceID, memberID) != nil {
+		return storage.ErrEntityDuplicate
+	}
+
+	wr.Members = append(wr.Members, &WorkspaceMembership{
+		WorkspaceID: workspaceID,
+		UserID:      memberID,
+		Role:        role,
+		JoinedAt:    time.Now(),
+	})
+	return nil
+}
+
+func (wr *WorkspaceRepository) UpdateWorkspaceMember(ctx context.Context, workspaceID, memberID uuid.UUID, role model.WorkspaceRole, user *model.User) error {
+	if err := wr.permitAdmin(workspaceID, user); err != nil {
+		return err
+	}
+	if err := wr.keepLastAdmin(workspaceID, memberID, role); err != nil {
+		return err
+	}
+
+	wr.findMembership(workspaceID, memberID).Role = role
+	return nil
+}
+
+func (wr *WorkspaceRepository) RemoveWorkspaceMember(ctx context.Context, workspaceID, memberID uuid.UUID, user *model.User) error {
+	if memberID != user.ID {
+		if err := wr.permitAdmin(workspaceID, user); err != nil {
+			return err
+		}
+	}
+	if err := wr.keepLastAdmin(workspaceID, memberID, ""); err != nil {
+		return err
+	}
+
+	removed := wr.findMembership(workspaceID, memberID)
+	members := make([]*WorkspaceMembership, 0, len(wr.Members))
+	for _, membership := range wr.Members {
+		if membership != removed {
+			members = append(members, membership)
+		}
+	}
+	wr.Members = members
+	return nil
+}
+
+// isMember reports whether user can access boards of the workspace. Everyone can access personal boards
+func (wr *WorkspaceRepository) isMember(workspaceID, userID uuid.UUID) bool {
+	return workspaceID == uuid.Nil || wr.findMembership(workspaceID, userID) != nil
+}
+
+// areColleagues reports whether users share at least one workspace
+func (wr *WorkspaceRepository) areColleagues(userID, colleagueID uuid.UUID) bool {
+	for _, membership := range wr.Members {
+		if membership.UserID == userID && wr.findMembership(membership.WorkspaceID, colleagueID) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (wr *WorkspaceRepository) roleOfUser(workspaceID, userID uuid.UUID) (model.WorkspaceRole, error) {
+	if _, found := wr.Workspaces[workspaceID]; !found {
+		return "", storage.ErrNotFound
+	}
+	membership := wr.findMembership(workspaceID, userID)
+	if membership == nil {
+		return "", storage.ErrSecurityError
+	}
+	return membership.Role, nil
+}
+
+func (wr *WorkspaceRepository) permitAdmin(workspaceID uuid.UUID, user *model.User) error {
+	role, err := wr.roleOfUser(workspaceID, user.ID)
+	if err != nil {
+		return err
+	}
+	if role != model.WorkspaceRoleAdmin {
+		return storage.ErrSecurityError
+	}
+	return nil
+}
+
+// keepLastAdmin fails if the member is the last admin and is going to lose the role.
+// Empty role means that the member leaves the workspace.
+func (wr *WorkspaceRepository) keepLastAdmin(workspaceID, memberID uuid.UUID, role model.WorkspaceRole) error {
+	membership := wr.findMembership(workspaceID, memberID)
+	if membership == nil {
+		return storage.ErrNotFound
+	}
+	if membership.Role != model.WorkspaceRoleAdmin || role == model.WorkspaceRoleAdmin {
+		return nil
+	}
+
+	admins := 0
+	for _, m := range wr.Members {
+		if m.WorkspaceID == workspaceID && m.Role == model.WorkspaceRoleAdmin {
+			admins++
+		}
+	}
+	if admins <= 1 {
+		return storage.ErrSecurityError
+	}
+	return nil
+}
+
+func (wr *WorkspaceRepository) findMembership(workspaceID, userID uuid.UUID) *WorkspaceMembership {
+	for _, membership := range wr.Members {
+		if membership.WorkspaceID == workspaceID && membership.UserID == userID {
+			return membership
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Package tracing configures OpenTelemetry tracer provider and W3C context propagation of gotcha
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// TracingConfiguration selects the exporter of spans. Nothing is exported with ExporterNone,
+// but incoming trace context is still propagated.
+type TracingConfiguration struct {
+	Exporter    string  `toml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	Endpoint    string  `toml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4318"` // OTLP/HTTP collector
+	Insecure    bool    `toml:"insecure" env:"TRACING_INSECURE" env-default:"false"`
+	SampleRatio float64 `toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Attributes of gotcha entities attached to spans
+const (
+	UserIDKey  = attribute.Key("gotcha.user.id")
+	BoardIDKey = attribute.Key("gotcha.board.id")
+)
+
+func UserID(id uuid.UUID) attribute.KeyValue {
+	return UserIDKey.String(id.String())
+}
+
+func BoardID(id uuid.UUID) attribute.KeyValue {
+	return BoardIDKey.String(id.String())
+}
+
+// ShutdownFunc flushes the buffered spans and stops the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the global tracer provider and W3C trace context propagator. Spans of the
+// parent-based sampled traces are exported to the configured exporter.
+func Setup(ctx context.Context, cfg *TracingConfiguration, serviceName string) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,36 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"Gotcha/internal/app/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+	defer func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	}()
+
+	_, err := tracing.Setup(ctx, &tracing.TracingConfiguration{Exporter: "zipkin"}, "Gotcha test")
+	assert.Error(t, err, "Unknown exporter accepted")
+
+	// Trace context is propagated even if spans aren't exported
+	shutdown, err := tracing.Setup(ctx, &tracing.TracingConfiguration{Exporter: tracing.ExporterNone}, "Gotcha test")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(ctx))
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+
+	shutdown, err = tracing.Setup(ctx, &tracing.TracingConfiguration{Exporter: tracing.ExporterStdout, SampleRatio: 1}, "Gotcha test")
+	assert.NoError(t, err)
+	_, span := otel.Tracer("test").Start(ctx, "span")
+	assert.True(t, span.SpanContext().IsSampled(), "Span isn't sampled")
+	span.End()
+	assert.NoError(t, shutdown(ctx))
+}
@@ -0,0 +1,15 @@
+CREATE TABLE "ApiToken"(
+                           "id" UUID NOT NULL DEFAULT uuid_generate_v4(),
+                           "user_id" UUID NOT NULL,
+                           "name" VARCHAR(64) NOT NULL,
+                           "hash" VARCHAR(64) NOT NULL UNIQUE,
+                           "scope" VARCHAR(2) NOT NULL,
+                           "expires_at" TIMESTAMPTZ NULL,
+                           "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "ApiToken" ADD PRIMARY KEY("id");
+CREATE INDEX "apitoken_user_id_index" ON
+    "ApiToken"("user_id");
+ALTER TABLE
+    "ApiToken" ADD CONSTRAINT "apitoken_user_id_foreign" FOREIGN KEY("user_id") REFERENCES "Users"("id");
@@ -0,0 +1,2 @@
+ALTER TABLE
+    "Users" DROP COLUMN "sessions_revoked_at";
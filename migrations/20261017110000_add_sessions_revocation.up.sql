@@ -0,0 +1,5 @@
+ALTER TABLE
+    "Users" ADD COLUMN "sessions_revoked_at" TIMESTAMPTZ NULL;
+COMMENT
+    ON COLUMN
+    "Users"."sessions_revoked_at" IS 'Sessions issued before this moment are invalid';
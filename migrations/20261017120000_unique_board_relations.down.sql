@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "usertoboard_board_id_user_id_unique";
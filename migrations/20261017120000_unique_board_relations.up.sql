@@ -0,0 +1,12 @@
+-- Keep the strongest relation of each user/board pair: author > rw > ro
+DELETE FROM "UserToBoard" a USING "UserToBoard" b
+WHERE a.board_id = b.board_id AND a.user_id = b.user_id AND a.id <> b.id AND (
+    CASE a.access_type WHEN 1 THEN 3 WHEN 3 THEN 2 ELSE 1 END,
+    a.id
+) < (
+    CASE b.access_type WHEN 1 THEN 3 WHEN 3 THEN 2 ELSE 1 END,
+    b.id
+);
+
+CREATE UNIQUE INDEX "usertoboard_board_id_user_id_unique" ON
+    "UserToBoard"("board_id", "user_id");
@@ -0,0 +1,21 @@
+CREATE TABLE "BoardTransfer"(
+                                "id" UUID NOT NULL DEFAULT uuid_generate_v4(),
+                                "board_id" UUID NOT NULL,
+                                "from_user_id" UUID NOT NULL,
+                                "to_user_id" UUID NOT NULL,
+                                "keep_access" BOOLEAN NOT NULL,
+                                "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "BoardTransfer" ADD PRIMARY KEY("id");
+CREATE INDEX "boardtransfer_board_id_index" ON
+    "BoardTransfer"("board_id");
+ALTER TABLE
+    "BoardTransfer" ADD CONSTRAINT "boardtransfer_board_id_foreign" FOREIGN KEY("board_id") REFERENCES "Board"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "BoardTransfer" ADD CONSTRAINT "boardtransfer_from_user_id_foreign" FOREIGN KEY("from_user_id") REFERENCES "Users"("id");
+ALTER TABLE
+    "BoardTransfer" ADD CONSTRAINT "boardtransfer_to_user_id_foreign" FOREIGN KEY("to_user_id") REFERENCES "Users"("id");
+COMMENT
+    ON TABLE
+    "BoardTransfer" IS 'Audit of board authorship transfers';
@@ -0,0 +1,16 @@
+CREATE TABLE "ShareLink"(
+                            "id" UUID NOT NULL DEFAULT uuid_generate_v4(),
+                            "board_id" UUID NOT NULL,
+                            "created_by" UUID NOT NULL,
+                            "hash" VARCHAR(64) NOT NULL UNIQUE,
+                            "expires_at" TIMESTAMPTZ NULL,
+                            "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "ShareLink" ADD PRIMARY KEY("id");
+CREATE INDEX "sharelink_board_id_index" ON
+    "ShareLink"("board_id");
+ALTER TABLE
+    "ShareLink" ADD CONSTRAINT "sharelink_board_id_foreign" FOREIGN KEY("board_id") REFERENCES "Board"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "ShareLink" ADD CONSTRAINT "sharelink_created_by_foreign" FOREIGN KEY("created_by") REFERENCES "Users"("id");
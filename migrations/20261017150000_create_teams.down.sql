@@ -0,0 +1,3 @@
+DROP table "TeamToBoard" CASCADE;
+DROP table "TeamMember" CASCADE;
+DROP table "Team" CASCADE;
@@ -0,0 +1,44 @@
+CREATE TABLE "Team"(
+                       "id" UUID NOT NULL DEFAULT uuid_generate_v4(),
+                       "title" VARCHAR(255) NOT NULL,
+                       "owner_id" UUID NOT NULL,
+                       "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "Team" ADD PRIMARY KEY("id");
+
+CREATE TABLE "TeamMember"(
+                             "team_id" UUID NOT NULL,
+                             "user_id" UUID NOT NULL,
+                             "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "TeamMember" ADD PRIMARY KEY("team_id", "user_id");
+CREATE INDEX "teammember_user_id_index" ON
+    "TeamMember"("user_id");
+
+CREATE TABLE "TeamToBoard"(
+                              "id" UUID NOT NULL DEFAULT uuid_generate_v4(),
+                              "team_id" UUID NOT NULL,
+                              "board_id" UUID NOT NULL,
+                              "access_type" int NOT NULL,
+                              "description" VARCHAR(255) NOT NULL,
+                              "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "TeamToBoard" ADD PRIMARY KEY("id");
+CREATE UNIQUE INDEX "teamtoboard_board_id_team_id_unique" ON
+    "TeamToBoard"("board_id", "team_id");
+
+ALTER TABLE
+    "Team" ADD CONSTRAINT "team_owner_id_foreign" FOREIGN KEY("owner_id") REFERENCES "Users"("id");
+ALTER TABLE
+    "TeamMember" ADD CONSTRAINT "teammember_team_id_foreign" FOREIGN KEY("team_id") REFERENCES "Team"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "TeamMember" ADD CONSTRAINT "teammember_user_id_foreign" FOREIGN KEY("user_id") REFERENCES "Users"("id");
+ALTER TABLE
+    "TeamToBoard" ADD CONSTRAINT "teamtoboard_team_id_foreign" FOREIGN KEY("team_id") REFERENCES "Team"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "TeamToBoard" ADD CONSTRAINT "teamtoboard_board_id_foreign" FOREIGN KEY("board_id") REFERENCES "Board"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "TeamToBoard" ADD CONSTRAINT "teamtoboard_access_type_foreign" FOREIGN KEY("access_type") REFERENCES "BoardPrivileges"("id");
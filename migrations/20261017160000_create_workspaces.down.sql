@@ -0,0 +1,3 @@
+ALTER TABLE "Board" DROP COLUMN "workspace_id";
+DROP table "WorkspaceMember" CASCADE;
+DROP table "Workspace" CASCADE;
@@ -0,0 +1,31 @@
+CREATE TABLE "Workspace"(
+                            "id" UUID NOT NULL DEFAULT uuid_generate_v4(),
+                            "title" VARCHAR(255) NOT NULL,
+                            "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "Workspace" ADD PRIMARY KEY("id");
+
+CREATE TABLE "WorkspaceMember"(
+                                  "workspace_id" UUID NOT NULL,
+                                  "user_id" UUID NOT NULL,
+                                  "role" VARCHAR(16) NOT NULL CHECK ("role" IN ('admin', 'member')),
+                                  "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "WorkspaceMember" ADD PRIMARY KEY("workspace_id", "user_id");
+CREATE INDEX "workspacemember_user_id_index" ON
+    "WorkspaceMember"("user_id");
+
+-- Existing boards stay personal
+ALTER TABLE
+    "Board" ADD COLUMN "workspace_id" UUID NULL;
+CREATE INDEX "board_workspace_id_index" ON
+    "Board"("workspace_id");
+
+ALTER TABLE
+    "WorkspaceMember" ADD CONSTRAINT "workspacemember_workspace_id_foreign" FOREIGN KEY("workspace_id") REFERENCES "Workspace"("id") ON DELETE CASCADE;
+ALTER TABLE
+    "WorkspaceMember" ADD CONSTRAINT "workspacemember_user_id_foreign" FOREIGN KEY("user_id") REFERENCES "Users"("id");
+ALTER TABLE
+    "Board" ADD CONSTRAINT "board_workspace_id_foreign" FOREIGN KEY("workspace_id") REFERENCES "Workspace"("id");
@@ -0,0 +1,2 @@
+ALTER TABLE "Note" DROP COLUMN "search_vector";
+ALTER TABLE "Board" DROP COLUMN "search_vector";
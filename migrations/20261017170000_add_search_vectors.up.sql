@@ -0,0 +1,14 @@
+-- 'simple' configuration doesn't stem words, so search works the same for any language
+ALTER TABLE
+    "Board" ADD COLUMN "search_vector" tsvector
+        GENERATED ALWAYS AS (setweight(to_tsvector('simple', "title"), 'A')) STORED;
+CREATE INDEX "board_search_vector_index" ON
+    "Board" USING GIN("search_vector");
+
+ALTER TABLE
+    "Note" ADD COLUMN "search_vector" tsvector
+        GENERATED ALWAYS AS (
+            setweight(to_tsvector('simple', "title"), 'A') || setweight(to_tsvector('simple', "content"), 'B')
+        ) STORED;
+CREATE INDEX "note_search_vector_index" ON
+    "Note" USING GIN("search_vector");
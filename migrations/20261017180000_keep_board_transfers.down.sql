@@ -0,0 +1,3 @@
+DELETE FROM "BoardTransfer" WHERE "board_id" NOT IN (SELECT "id" FROM "Board");
+ALTER TABLE
+    "BoardTransfer" ADD CONSTRAINT "boardtransfer_board_id_foreign" FOREIGN KEY("board_id") REFERENCES "Board"("id") ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+-- Transfers are an audit log, so they outlive the board. board_id keeps the id of the deleted board
+ALTER TABLE
+    "BoardTransfer" DROP CONSTRAINT "boardtransfer_board_id_foreign";
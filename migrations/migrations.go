@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL schema migrations, so the server binary can apply them by itself.
+package migrations
+
+import "embed"
+
+// FS contains migration files named as <version>_<name>.(up|down).sql
+//
+//go:embed *.sql
+var FS embed.FS